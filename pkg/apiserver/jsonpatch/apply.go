@@ -0,0 +1,374 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Apply applies the patch to the given JSON document in memory and returns the patched document.
+//
+// It simulates what a server does with the output of Marshal and is meant
+// to verify generated patches in unit tests and dry runs.
+func (p *PatchSet) Apply(document []byte) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode the document: %w", err)
+	}
+	for i, patch := range p.patches {
+		var err error
+		if doc, err = applyOperation(doc, patch); err != nil {
+			return nil, fmt.Errorf("%s operation at index: %d failed: %w", patch.Op, i, err)
+		}
+	}
+	if p.rejectEmptyDocument && isEmptyDocument(doc) {
+		return nil, fmt.Errorf("the patch empties the document")
+	}
+	return json.Marshal(doc)
+}
+
+// isEmptyDocument returns true for a decoded JSON document that is null or an empty object.
+func isEmptyDocument(doc interface{}) bool {
+	if doc == nil {
+		return true
+	}
+	object, ok := doc.(map[string]interface{})
+	return ok && len(object) == 0
+}
+
+func applyOperation(doc interface{}, patch PatchOperation) (interface{}, error) {
+	tokens, err := parsePointer(patch.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch patch.Op {
+	case patchTestOperation:
+		if patch.arrayLength != nil {
+			return doc, testArrayLength(doc, tokens, patch)
+		}
+		value, err := testValue(doc, patch)
+		if err != nil {
+			return nil, err
+		}
+		current, err := getValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		// both values are decoded JSON, so a string never equals a number, even one with the same representation
+		if expectedType, currentType := jsonTypeName(value), jsonTypeName(current); expectedType != currentType {
+			return nil, fmt.Errorf("test failed for path: %q, expected %s: %v, got %s: %v", patch.Path, expectedType, value, currentType, current)
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("test failed for path: %q, expected: %v, got: %v", patch.Path, value, current)
+		}
+		return doc, nil
+	case patchAddOperation:
+		value, err := toJSONValue(patch.Value)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(doc, tokens, value)
+	case patchReplaceOperation:
+		value, err := toJSONValue(patch.Value)
+		if err != nil {
+			return nil, err
+		}
+		return replaceValue(doc, tokens, value)
+	case patchRemoveOperation:
+		return removeValue(doc, tokens)
+	case patchMoveOperation, patchCopyOperation:
+		fromTokens, err := parsePointer(patch.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+		if patch.Op == patchMoveOperation {
+			if doc, err = removeValue(doc, fromTokens); err != nil {
+				return nil, err
+			}
+		} else if value, err = toJSONValue(value); err != nil {
+			// the copy must not share its containers with the original
+			return nil, err
+		}
+		return addValue(doc, tokens, value)
+	default:
+		return nil, fmt.Errorf("unsupported operation: %q", patch.Op)
+	}
+}
+
+// testValue returns the value the given test operation expects, which is either
+// its literal value or the value found at the path it reads its value from.
+func testValue(doc interface{}, patch PatchOperation) (interface{}, error) {
+	if len(patch.valueFrom) == 0 {
+		return toJSONValue(patch.Value)
+	}
+	tokens, err := parsePointer(patch.valueFrom)
+	if err != nil {
+		return nil, err
+	}
+	value, err := getValue(doc, tokens)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the expected value from path: %q: %w", patch.valueFrom, err)
+	}
+	return value, nil
+}
+
+// testArrayLength checks that the value at the path of the given test operation is an array of the expected length.
+func testArrayLength(doc interface{}, tokens []string, patch PatchOperation) error {
+	current, err := getValue(doc, tokens)
+	if err != nil {
+		return err
+	}
+	array, ok := current.([]interface{})
+	if !ok {
+		return fmt.Errorf("test failed for path: %q, expected array, got %s: %v", patch.Path, jsonTypeName(current), current)
+	}
+	if len(array) != *patch.arrayLength {
+		return fmt.Errorf("test failed for path: %q, expected array length: %d, got: %d", patch.Path, *patch.arrayLength, len(array))
+	}
+	return nil
+}
+
+// parsePointer splits the given RFC 6901 JSON pointer into its unescaped reference tokens.
+func parsePointer(path string) ([]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid path: %q, must be empty or start with a slash", path)
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// toJSONValue converts the given value to its generic JSON representation
+// so that it can be compared with and stored in a decoded document.
+func toJSONValue(value interface{}) (interface{}, error) {
+	rawValue, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var ret interface{}
+	if err := json.Unmarshal(rawValue, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// jsonTypeName returns the name of the JSON type of the given decoded value.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func getValue(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch typedNode := node.(type) {
+		case map[string]interface{}:
+			child, ok := typedNode[token]
+			if !ok {
+				return nil, fmt.Errorf("key: %q not found", token)
+			}
+			node = child
+		case []interface{}:
+			index, err := parseArrayIndex(token, len(typedNode)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = typedNode[index]
+		default:
+			return nil, fmt.Errorf("unable to reference %q in a non-container value", token)
+		}
+	}
+	return node, nil
+}
+
+// updateParent navigates to the container referenced by all but the last token and replaces it
+// with the result of fn, which is given the container and the last token.
+func updateParent(node interface{}, tokens []string, fn func(container interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	child, err := getValue(node, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+	newChild, err := updateParent(child, tokens[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		typedNode[tokens[0]] = newChild
+	case []interface{}:
+		// the index has already been validated by getValue
+		index, _ := strconv.Atoi(tokens[0])
+		typedNode[index] = newChild
+	}
+	return node, nil
+}
+
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch typedContainer := container.(type) {
+		case map[string]interface{}:
+			typedContainer[key] = value
+			return typedContainer, nil
+		case []interface{}:
+			if key == "-" {
+				return append(typedContainer, value), nil
+			}
+			index, err := parseArrayIndex(key, len(typedContainer))
+			if err != nil {
+				return nil, err
+			}
+			typedContainer = append(typedContainer, nil)
+			copy(typedContainer[index+1:], typedContainer[index:])
+			typedContainer[index] = value
+			return typedContainer, nil
+		default:
+			return nil, fmt.Errorf("unable to add %q to a non-container value", key)
+		}
+	})
+}
+
+func replaceValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if _, err := getValue(doc, tokens); err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch typedContainer := container.(type) {
+		case map[string]interface{}:
+			typedContainer[key] = value
+			return typedContainer, nil
+		case []interface{}:
+			// the index has already been validated by getValue
+			index, _ := strconv.Atoi(key)
+			typedContainer[index] = value
+			return typedContainer, nil
+		default:
+			return nil, fmt.Errorf("unable to replace %q in a non-container value", key)
+		}
+	})
+}
+
+func removeValue(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	return updateParent(doc, tokens, func(container interface{}, key string) (interface{}, error) {
+		switch typedContainer := container.(type) {
+		case map[string]interface{}:
+			if _, ok := typedContainer[key]; !ok {
+				return nil, fmt.Errorf("key: %q not found", key)
+			}
+			delete(typedContainer, key)
+			return typedContainer, nil
+		case []interface{}:
+			index, err := parseArrayIndex(key, len(typedContainer)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(typedContainer[:index], typedContainer[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("unable to remove %q from a non-container value", key)
+		}
+	})
+}
+
+// parseArrayIndex parses the given reference token as an array index in the range [0, maxIndex].
+func parseArrayIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || strconv.Itoa(index) != token {
+		return 0, fmt.Errorf("invalid array index: %q", token)
+	}
+	if index > maxIndex {
+		return 0, fmt.Errorf("array index: %d out of bounds", index)
+	}
+	return index, nil
+}
+
+// Prune returns a new patch set without the operations added by WithReplaceIfChanged that wouldn't change
+// the given JSON document, so that no-op replaces are not sent. The operations are simulated in order,
+// a replace is compared with the document as left by the operations before it.
+// The receiver is not modified.
+func (p *PatchSet) Prune(current []byte) (*PatchSet, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode the document: %w", err)
+	}
+	ret := p.derive()
+	for i, patch := range p.patches {
+		if patch.ifChanged {
+			unchanged, err := replacesWithSameValue(doc, patch)
+			if err != nil {
+				return nil, fmt.Errorf("%s operation at index: %d failed: %w", patch.Op, i, err)
+			}
+			if unchanged {
+				continue
+			}
+		}
+		var err error
+		if doc, err = applyOperation(doc, patch); err != nil {
+			return nil, fmt.Errorf("%s operation at index: %d failed: %w", patch.Op, i, err)
+		}
+		ret.patches = append(ret.patches, patch)
+	}
+	return ret, nil
+}
+
+// replacesWithSameValue returns true if the given replace operation sets its path to the value it already has.
+func replacesWithSameValue(doc interface{}, patch PatchOperation) (bool, error) {
+	tokens, err := parsePointer(patch.Path)
+	if err != nil {
+		return false, err
+	}
+	current, err := getValue(doc, tokens)
+	if err != nil {
+		// let the replace report the missing path
+		return false, nil
+	}
+	value, err := toJSONValue(patch.Value)
+	if err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(current, value), nil
+}
@@ -0,0 +1,587 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	evanphxjsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+func TestApply(t *testing.T) {
+	scenarios := []struct {
+		name             string
+		target           *PatchSet
+		document         string
+		expectedDocument string
+		expectedError    string
+	}{
+		{
+			name:             "empty patch leaves the document untouched",
+			target:           New(),
+			document:         `{"status":{"foo":"bar"}}`,
+			expectedDocument: `{"status":{"foo":"bar"}}`,
+		},
+		{
+			name:             "passing test",
+			target:           New().WithTest("/status/foo", "bar"),
+			document:         `{"status":{"foo":"bar"}}`,
+			expectedDocument: `{"status":{"foo":"bar"}}`,
+		},
+		{
+			name:          "failing test",
+			target:        New().WithTest("/status/foo", "baz"),
+			document:      `{"status":{"foo":"bar"}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/foo", expected: baz, got: bar`,
+		},
+		{
+			name:             "numeric test matches a number",
+			target:           New().WithTest("/status/observedGeneration", 1),
+			document:         `{"status":{"observedGeneration":1}}`,
+			expectedDocument: `{"status":{"observedGeneration":1}}`,
+		},
+		{
+			name:          "string test doesn't match a number",
+			target:        New().WithTest("/status/observedGeneration", "1"),
+			document:      `{"status":{"observedGeneration":1}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/observedGeneration", expected string: 1, got number: 1`,
+		},
+		{
+			name:          "numeric test doesn't match a string",
+			target:        New().WithTest("/metadata/annotations/generation", int64(1)),
+			document:      `{"metadata":{"annotations":{"generation":"1"}}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/metadata/annotations/generation", expected number: 1, got string: 1`,
+		},
+		{
+			name:          "test of a missing path",
+			target:        New().WithTest("/status/missing", "bar"),
+			document:      `{"status":{"foo":"bar"}}`,
+			expectedError: `test operation at index: 0 failed: key: "missing" not found`,
+		},
+		{
+			name:             "remove guarded by a test",
+			target:           New().WithRemove("/status/foo", NewTestCondition("/status/condition", "ok")),
+			document:         `{"status":{"condition":"ok","foo":"bar"}}`,
+			expectedDocument: `{"status":{"condition":"ok"}}`,
+		},
+		{
+			name:          "remove guarded by a failing test",
+			target:        New().WithRemove("/status/foo", NewTestCondition("/status/condition", "ok")),
+			document:      `{"status":{"condition":"bad","foo":"bar"}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/condition", expected: ok, got: bad`,
+		},
+		{
+			name:             "remove an array element",
+			target:           New().WithRemove("/status/items/1", NewTestCondition("/status/items/1", "b")),
+			document:         `{"status":{"items":["a","b","c"]}}`,
+			expectedDocument: `{"status":{"items":["a","c"]}}`,
+		},
+		{
+			name:          "remove an out of bounds array element",
+			target:        New().WithRemove("/status/items/3", NewTestCondition("/status/items/0", "a")),
+			document:      `{"status":{"items":["a","b","c"]}}`,
+			expectedError: `remove operation at index: 1 failed: array index: 3 out of bounds`,
+		},
+		{
+			name:             "escaped keys",
+			target:           New().WithRemove("/metadata/annotations/example.com~1foo~0bar", NewTestCondition("/metadata/annotations/example.com~1foo~0bar", "1")),
+			document:         `{"metadata":{"annotations":{"example.com/foo~bar":"1","other":"2"}}}`,
+			expectedDocument: `{"metadata":{"annotations":{"other":"2"}}}`,
+		},
+		{
+			name:             "prepend to an array",
+			target:           New().WithPrepend("/spec/items", "a"),
+			document:         `{"spec":{"items":["b","c"]}}`,
+			expectedDocument: `{"spec":{"items":["a","b","c"]}}`,
+		},
+		{
+			name:             "prepend to an empty array",
+			target:           New().WithPrepend("/spec/items", map[string]interface{}{"name": "a"}),
+			document:         `{"spec":{"items":[]}}`,
+			expectedDocument: `{"spec":{"items":[{"name":"a"}]}}`,
+		},
+		{
+			name:             "prepend multiple times",
+			target:           New().WithPrepend("/spec/items", "b").WithPrepend("/spec/items", "a"),
+			document:         `{"spec":{"items":["c"]}}`,
+			expectedDocument: `{"spec":{"items":["a","b","c"]}}`,
+		},
+		{
+			name:          "prepend to a missing array",
+			target:        New().WithPrepend("/spec/items", "a"),
+			document:      `{"spec":{}}`,
+			expectedError: `add operation at index: 0 failed: key: "items" not found`,
+		},
+		{
+			name:          "prepend to a non-array",
+			target:        New().WithPrepend("/spec/items", "a"),
+			document:      `{"spec":{"items":"b"}}`,
+			expectedError: `add operation at index: 0 failed: unable to add "0" to a non-container value`,
+		},
+		{
+			name:             "add an object member",
+			target:           New().WithAdd("/metadata/labels/foo", "bar"),
+			document:         `{"metadata":{"labels":{}}}`,
+			expectedDocument: `{"metadata":{"labels":{"foo":"bar"}}}`,
+		},
+		{
+			name:             "append to an array",
+			target:           New().WithAdd("/spec/items/-", "c"),
+			document:         `{"spec":{"items":["a","b"]}}`,
+			expectedDocument: `{"spec":{"items":["a","b","c"]}}`,
+		},
+		{
+			name:             "move an object member",
+			target:           New().WithMove("/spec/foo", "/spec/bar"),
+			document:         `{"spec":{"foo":{"a":"b"}}}`,
+			expectedDocument: `{"spec":{"bar":{"a":"b"}}}`,
+		},
+		{
+			name:             "move an array element",
+			target:           New().WithMove("/spec/items/0", "/spec/items/-"),
+			document:         `{"spec":{"items":["a","b"]}}`,
+			expectedDocument: `{"spec":{"items":["b","a"]}}`,
+		},
+		{
+			name:          "move into a child",
+			target:        New().WithMove("/spec", "/spec/nested"),
+			document:      `{"spec":{"foo":"bar"}}`,
+			expectedError: `move operation at index: 0 moves path: "/spec" into its own child: "/spec/nested"`,
+		},
+		{
+			name:             "copy an object member into itself",
+			target:           New().WithCopy("/spec/foo", "/spec/foo/backup"),
+			document:         `{"spec":{"foo":{"a":"b"}}}`,
+			expectedDocument: `{"spec":{"foo":{"a":"b","backup":{"a":"b"}}}}`,
+		},
+		{
+			name:          "copy a missing member",
+			target:        New().WithCopy("/spec/missing", "/spec/foo"),
+			document:      `{"spec":{}}`,
+			expectedError: `copy operation at index: 0 failed: key: "missing" not found`,
+		},
+		{
+			name:             "replace an object member",
+			target:           New().WithReplace("/spec/replicas", 3),
+			document:         `{"spec":{"replicas":1}}`,
+			expectedDocument: `{"spec":{"replicas":3}}`,
+		},
+		{
+			name:             "replace an array element",
+			target:           New().WithReplace("/spec/items/1", "x"),
+			document:         `{"spec":{"items":["a","b"]}}`,
+			expectedDocument: `{"spec":{"items":["a","x"]}}`,
+		},
+		{
+			name:          "replace a missing member",
+			target:        New().WithReplace("/spec/replicas", 3),
+			document:      `{"spec":{}}`,
+			expectedError: `replace operation at index: 0 failed: key: "replicas" not found`,
+		},
+		{
+			name:             "remove guarded by a test reading its value from the document",
+			target:           New().WithRemove("/status/foo", NewTestFromPath("/status/observedGeneration", "/metadata/generation")),
+			document:         `{"metadata":{"generation":2},"status":{"foo":"bar","observedGeneration":2}}`,
+			expectedDocument: `{"metadata":{"generation":2},"status":{"observedGeneration":2}}`,
+		},
+		{
+			name:          "failing test reading its value from the document",
+			target:        New().WithRemove("/status/foo", NewTestFromPath("/status/observedGeneration", "/metadata/generation")),
+			document:      `{"metadata":{"generation":3},"status":{"foo":"bar","observedGeneration":2}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/observedGeneration", expected: 3, got: 2`,
+		},
+		{
+			name:          "test reading its value from a missing path",
+			target:        New().WithRemove("/status/foo", NewTestFromPath("/status/observedGeneration", "/metadata/generation")),
+			document:      `{"metadata":{},"status":{"foo":"bar","observedGeneration":2}}`,
+			expectedError: `test operation at index: 0 failed: unable to read the expected value from path: "/metadata/generation": key: "generation" not found`,
+		},
+		{
+			name:             "test reading its value from the document is rebased by a path prefix",
+			target:           New().WithRemoveAll([]string{"/foo"}, NewTestFromPath("/bar", "/baz")).WithPathPrefix("/spec"),
+			document:         `{"spec":{"bar":"1","baz":"1","foo":"2"}}`,
+			expectedDocument: `{"spec":{"bar":"1","baz":"1"}}`,
+		},
+		{
+			name:             "append guarded by an array length test",
+			target:           New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 2)).WithAdd("/status/items/-", "c"),
+			document:         `{"status":{"items":["a","b"]}}`,
+			expectedDocument: `{"status":{"items":["a","b","c"]}}`,
+		},
+		{
+			name:          "append guarded by a failing array length test",
+			target:        New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 2)).WithAdd("/status/items/-", "c"),
+			document:      `{"status":{"items":["a","b","x"]}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/items", expected array length: 2, got: 3`,
+		},
+		{
+			name:             "empty array length test",
+			target:           New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 0)).WithAdd("/status/items/-", "a"),
+			document:         `{"status":{"items":[]}}`,
+			expectedDocument: `{"status":{"items":["a"]}}`,
+		},
+		{
+			name:          "array length test of a non-array value",
+			target:        New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 1)),
+			document:      `{"status":{"items":"a"}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/items", expected array, got string: a`,
+		},
+		{
+			name:          "array length test of a missing path",
+			target:        New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 0)),
+			document:      `{"status":{}}`,
+			expectedError: `test operation at index: 0 failed: key: "items" not found`,
+		},
+		{
+			name:             "array length test is rebased by a path prefix",
+			target:           New().WithRemoveAll(nil, NewArrayLengthCondition("/items", 1)).WithAdd("/items/-", "b").WithPathPrefix("/status"),
+			document:         `{"status":{"items":["a"]}}`,
+			expectedDocument: `{"status":{"items":["a","b"]}}`,
+		},
+		{
+			name:          "validation errors are reported",
+			target:        New().WithTest("/metadata/resourceVersion", "1"),
+			document:      `{"metadata":{"resourceVersion":"1"}}`,
+			expectedError: `test operation at index: 0 contains forbidden path: "/metadata/resourceVersion"`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			actualDocument, err := scenario.target.Apply([]byte(scenario.document))
+			if len(scenario.expectedError) > 0 {
+				if err == nil || err.Error() != scenario.expectedError {
+					t.Fatalf("unexpected err: %v, expected: %v", err, scenario.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actualDocument) != scenario.expectedDocument {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedDocument, actualDocument)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		target         *PatchSet
+		document       string
+		expectedString string
+		expectedError  string
+	}{
+		{
+			name:           "unchanged value is dropped",
+			target:         New().WithReplaceIfChanged("/spec/replicas", 3),
+			document:       `{"spec":{"replicas":3}}`,
+			expectedString: ``,
+		},
+		{
+			name:           "changed value is kept",
+			target:         New().WithReplaceIfChanged("/spec/replicas", 3),
+			document:       `{"spec":{"replicas":2}}`,
+			expectedString: `0: replace "/spec/replicas" 3 if changed`,
+		},
+		{
+			name:           "value of a different type is kept",
+			target:         New().WithReplaceIfChanged("/spec/replicas", "3"),
+			document:       `{"spec":{"replicas":3}}`,
+			expectedString: `0: replace "/spec/replicas" "3" if changed`,
+		},
+		{
+			name: "unchanged objects are dropped regardless of their key order",
+			target: New().
+				WithReplaceIfChanged("/spec/selector", map[string]interface{}{"b": "2", "a": "1"}).
+				WithReplaceIfChanged("/spec/replicas", 1),
+			document:       `{"spec":{"replicas":3,"selector":{"a":"1","b":"2"}}}`,
+			expectedString: `0: replace "/spec/replicas" 1 if changed`,
+		},
+		{
+			name:           "plain replaces are kept",
+			target:         New().WithReplace("/spec/replicas", 3).WithReplaceIfChanged("/spec/paused", false),
+			document:       `{"spec":{"paused":false,"replicas":3}}`,
+			expectedString: `0: replace "/spec/replicas" 3`,
+		},
+		{
+			name:           "replace is compared with the document as left by the previous operations",
+			target:         New().WithReplace("/spec/replicas", 1).WithReplaceIfChanged("/spec/replicas", 3),
+			document:       `{"spec":{"replicas":3}}`,
+			expectedString: "0: replace \"/spec/replicas\" 1\n1: replace \"/spec/replicas\" 3 if changed",
+		},
+		{
+			name:           "tests are kept",
+			target:         New().WithTest("/metadata/name", "foo").WithReplaceIfChanged("/spec/replicas", 3),
+			document:       `{"metadata":{"name":"foo"},"spec":{"replicas":3}}`,
+			expectedString: `0: test "/metadata/name" "foo"`,
+		},
+		{
+			name:          "replace of a missing path fails",
+			target:        New().WithReplaceIfChanged("/spec/replicas", 3),
+			document:      `{"spec":{}}`,
+			expectedError: `replace operation at index: 0 failed: key: "replicas" not found`,
+		},
+		{
+			name:          "failing test",
+			target:        New().WithTest("/metadata/name", "bar").WithReplaceIfChanged("/spec/replicas", 3),
+			document:      `{"metadata":{"name":"foo"},"spec":{"replicas":2}}`,
+			expectedError: `test operation at index: 0 failed: test failed for path: "/metadata/name", expected: bar, got: foo`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			before := scenario.target.String()
+			pruned, err := scenario.target.Prune([]byte(scenario.document))
+			if len(scenario.expectedError) > 0 {
+				if err == nil || err.Error() != scenario.expectedError {
+					t.Fatalf("unexpected err: %v, expected: %v", err, scenario.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := pruned.String(); actual != scenario.expectedString {
+				t.Fatalf("expected =\n%s\ngot =\n%s", scenario.expectedString, actual)
+			}
+			if after := scenario.target.String(); after != before {
+				t.Errorf("expected the receiver to be left untouched, got:\n%s", after)
+			}
+
+			// the pruned patch has the same effect
+			expectedDocument, err := scenario.target.Apply([]byte(scenario.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualDocument, err := pruned.Apply([]byte(scenario.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actualDocument) != string(expectedDocument) {
+				t.Errorf("expected the pruned patch to produce %s, got %s", expectedDocument, actualDocument)
+			}
+		})
+	}
+}
+
+// FuzzApply compares Apply with the reference RFC 6902 implementation used by the API server on random documents
+// and patches, generated from the fuzzed seed. Failures report the seed, which replays them once added to the seed corpus.
+// The operations are compared one by one, so that the known deviations of the reference implementation from
+// RFC 6902 can be told apart, see referenceIsLenient.
+func FuzzApply(f *testing.F) {
+	for seed := int64(0); seed < 500; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		rng := rand.New(rand.NewSource(seed))
+		var doc interface{} = randomObject(rng, 3)
+		patch := randomPatch(rng, doc)
+
+		document, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, operation := range patch.patches {
+			single := &PatchSet{patches: []PatchOperation{operation}}
+			patchBytes, err := single.Marshal()
+			if err != nil {
+				t.Fatalf("seed %d: unable to marshal the operation at index: %d %s: %v", seed, i, single, err)
+			}
+			referencePatch, err := evanphxjsonpatch.DecodePatch(patchBytes)
+			if err != nil {
+				t.Fatalf("seed %d: unable to decode the operation at index: %d %s: %v", seed, i, patchBytes, err)
+			}
+
+			actual, actualErr := single.Apply(document)
+			expected, expectedErr, panicked := applyReference(referencePatch, document)
+			switch {
+			case panicked:
+				// the reference can't tell the outcome, the remaining operations can't be compared
+				return
+			case actualErr != nil && expectedErr != nil:
+				// the patch fails, the remaining operations don't matter
+				return
+			case actualErr != nil && referenceIsLenient(t, document, operation):
+				return
+			case actualErr != nil || expectedErr != nil:
+				t.Fatalf("seed %d: applying %s to %s: expected error %v, got error %v", seed, patchBytes, document, expectedErr, actualErr)
+			case !jsonEqual(t, actual, expected):
+				t.Fatalf("seed %d: applying %s to %s: expected %s, got %s", seed, patchBytes, document, expected, actual)
+			}
+			document = actual
+		}
+	})
+}
+
+// applyReference applies the given patch with the reference implementation, which panics when a test operation
+// compares arrays holding null.
+func applyReference(patch evanphxjsonpatch.Patch, document []byte) (ret []byte, err error, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+		}
+	}()
+	ret, err = patch.Apply(document)
+	return ret, err, false
+}
+
+// referenceIsLenient returns true if the reference implementation is known to apply the given operation
+// to the given document although RFC 6902 requires it to fail:
+//   - a replace operation of a missing object member adds it
+//   - a copy operation from a missing object member copies null
+//   - a test operation of a missing object member against null succeeds
+func referenceIsLenient(t *testing.T, document []byte, operation PatchOperation) bool {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		t.Fatal(err)
+	}
+	missingObjectMember := func(path string) bool {
+		tokens, err := parsePointer(path)
+		if err != nil || len(tokens) == 0 {
+			return false
+		}
+		parent, err := getValue(doc, tokens[:len(tokens)-1])
+		if err != nil {
+			return false
+		}
+		object, ok := parent.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		_, found := object[tokens[len(tokens)-1]]
+		return !found
+	}
+	switch operation.Op {
+	case patchReplaceOperation:
+		return missingObjectMember(operation.Path)
+	case patchCopyOperation:
+		return missingObjectMember(operation.From)
+	case patchTestOperation:
+		return operation.Value == nil && missingObjectMember(operation.Path)
+	}
+	return false
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var aValue, bValue interface{}
+	if err := json.Unmarshal(a, &aValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &bValue); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+// randomKeys include keys that must be escaped in JSON pointers.
+var randomKeys = []string{"a", "b", "c", "d/e", "f~g"}
+
+func randomObject(rng *rand.Rand, depth int) map[string]interface{} {
+	object := map[string]interface{}{}
+	for i := rng.Intn(4); i > 0; i-- {
+		object[randomKeys[rng.Intn(len(randomKeys))]] = randomValue(rng, depth-1)
+	}
+	return object
+}
+
+// randomValue returns a random JSON value.
+func randomValue(rng *rand.Rand, depth int) interface{} {
+	kind := rng.Intn(6)
+	if depth <= 0 {
+		kind = rng.Intn(4)
+	}
+	switch kind {
+	case 0:
+		return float64(rng.Intn(3))
+	case 1:
+		return []string{"x", "y"}[rng.Intn(2)]
+	case 2:
+		return rng.Intn(2) == 0
+	case 3:
+		return nil
+	case 4:
+		array := []interface{}{}
+		for i := rng.Intn(4); i > 0; i-- {
+			array = append(array, randomValue(rng, depth-1))
+		}
+		return array
+	default:
+		return randomObject(rng, depth)
+	}
+}
+
+// randomPath returns a path into the given document, which mostly exists but may also reference a missing member.
+// Paths are never the root, patching the whole document is out of scope of the reference implementation.
+func randomPath(rng *rand.Rand, doc interface{}, forAdd bool) string {
+	path := ""
+	for {
+		var tokens []string
+		var children []interface{}
+		switch typedDoc := doc.(type) {
+		case map[string]interface{}:
+			for _, key := range randomKeys {
+				if child, ok := typedDoc[key]; ok {
+					tokens = append(tokens, escapePointerToken(key))
+					children = append(children, child)
+				}
+			}
+			// a missing member
+			tokens = append(tokens, escapePointerToken(randomKeys[rng.Intn(len(randomKeys))]))
+			children = append(children, nil)
+		case []interface{}:
+			for i, child := range typedDoc {
+				tokens = append(tokens, strconv.Itoa(i))
+				children = append(children, child)
+			}
+			// past the end
+			tokens = append(tokens, strconv.Itoa(len(typedDoc)))
+			children = append(children, nil)
+			if forAdd {
+				tokens = append(tokens, "-")
+				children = append(children, nil)
+			}
+		default:
+			// a member of a scalar
+			return path + "/" + escapePointerToken(randomKeys[rng.Intn(len(randomKeys))])
+		}
+
+		i := rng.Intn(len(tokens))
+		path += "/" + tokens[i]
+		if children[i] == nil || rng.Intn(3) == 0 {
+			return path
+		}
+		doc = children[i]
+	}
+}
+
+func randomPatch(rng *rand.Rand, doc interface{}) *PatchSet {
+	patch := New()
+	for i := rng.Intn(4) + 1; i > 0; i-- {
+		switch rng.Intn(6) {
+		case 0:
+			patch.WithAdd(randomPath(rng, doc, true), randomValue(rng, 2))
+		case 1:
+			patch.WithReplace(randomPath(rng, doc, false), randomValue(rng, 2))
+		case 2:
+			patch.WithRemove(randomPath(rng, doc, false), NewTestCondition(randomPath(rng, doc, false), randomValue(rng, 1)))
+		case 3:
+			patch.WithTest(randomPath(rng, doc, false), randomValue(rng, 1))
+		case 4:
+			from, path := randomPath(rng, doc, false), randomPath(rng, doc, true)
+			// moving a location into one of its children is rejected by validate, as by the reference implementation
+			if path != from && strings.HasPrefix(path, from+"/") {
+				continue
+			}
+			patch.WithMove(from, path)
+		default:
+			patch.WithCopy(randomPath(rng, doc, false), randomPath(rng, doc, true))
+		}
+	}
+	return patch
+}
@@ -0,0 +1,35 @@
+package jsonpatch
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WithSetCondition adds the operations setting the given condition in the conditions array at conditionsPath,
+// e.g. "/status/conditions", whose current content is existing.
+//
+// The element of the same type is replaced, guarded by a test on its type so that the patch fails if the array
+// was reordered in the meantime. A condition that is not present yet is appended, guarded by a test on the
+// whole array so that the patch fails if a condition was added or removed in the meantime.
+// If existing is empty, the array is added as a whole, since a JSON patch can't test for a missing path.
+// Nothing is added when the existing condition is already equal to condition.
+func (p *PatchSet) WithSetCondition(conditionsPath string, existing []metav1.Condition, condition metav1.Condition) *PatchSet {
+	for i, existingCondition := range existing {
+		if existingCondition.Type != condition.Type {
+			continue
+		}
+		if equality.Semantic.DeepEqual(existingCondition, condition) {
+			return p
+		}
+		p.withTestCondition(NewTestCondition(fmt.Sprintf("%s/%d/type", conditionsPath, i), condition.Type))
+		return p.WithReplace(fmt.Sprintf("%s/%d", conditionsPath, i), condition)
+	}
+
+	if len(existing) == 0 {
+		return p.WithAdd(conditionsPath, []metav1.Condition{condition})
+	}
+	p.withTestCondition(NewTestCondition(conditionsPath, existing))
+	return p.WithAdd(conditionsPath+"/-", condition)
+}
@@ -0,0 +1,118 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWithSetCondition(t *testing.T) {
+	now := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	available := metav1.Condition{Type: "Available", Status: metav1.ConditionTrue, Reason: "AsExpected", LastTransitionTime: now}
+	degraded := metav1.Condition{Type: "Degraded", Status: metav1.ConditionFalse, Reason: "AsExpected", LastTransitionTime: now}
+	nowDegraded := metav1.Condition{Type: "Degraded", Status: metav1.ConditionTrue, Reason: "SyncError", Message: "boom", LastTransitionTime: now}
+
+	scenarios := []struct {
+		name               string
+		existing           []metav1.Condition
+		condition          metav1.Condition
+		document           []metav1.Condition
+		expectedPatch      string
+		expectedConditions []metav1.Condition
+		expectedError      string
+	}{
+		{
+			name:               "update existing",
+			existing:           []metav1.Condition{available, degraded},
+			condition:          nowDegraded,
+			expectedPatch:      `[{"op":"test","path":"/status/conditions/1/type","value":"Degraded"},{"op":"replace","path":"/status/conditions/1","value":{"type":"Degraded","status":"True","lastTransitionTime":"2024-01-01T00:00:00Z","reason":"SyncError","message":"boom"}}]`,
+			expectedConditions: []metav1.Condition{available, nowDegraded},
+		},
+		{
+			name:               "append new",
+			existing:           []metav1.Condition{available},
+			condition:          degraded,
+			expectedPatch:      `[{"op":"test","path":"/status/conditions","value":[{"type":"Available","status":"True","lastTransitionTime":"2024-01-01T00:00:00Z","reason":"AsExpected","message":""}]},{"op":"add","path":"/status/conditions/-","value":{"type":"Degraded","status":"False","lastTransitionTime":"2024-01-01T00:00:00Z","reason":"AsExpected","message":""}}]`,
+			expectedConditions: []metav1.Condition{available, degraded},
+		},
+		{
+			name:               "append to empty conditions",
+			condition:          degraded,
+			expectedPatch:      `[{"op":"add","path":"/status/conditions","value":[{"type":"Degraded","status":"False","lastTransitionTime":"2024-01-01T00:00:00Z","reason":"AsExpected","message":""}]}]`,
+			expectedConditions: []metav1.Condition{degraded},
+		},
+		{
+			name:               "unchanged condition",
+			existing:           []metav1.Condition{available, degraded},
+			condition:          degraded,
+			expectedPatch:      "null",
+			expectedConditions: []metav1.Condition{available, degraded},
+		},
+		{
+			name:          "update fails when the conditions were reordered",
+			existing:      []metav1.Condition{available, degraded},
+			condition:     nowDegraded,
+			document:      []metav1.Condition{degraded, available},
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/conditions/1/type", expected: Degraded, got: Available`,
+		},
+		{
+			name:          "append fails when a condition was added in the meantime",
+			existing:      []metav1.Condition{available},
+			condition:     degraded,
+			document:      []metav1.Condition{available, nowDegraded},
+			expectedError: `test operation at index: 0 failed: test failed for path: "/status/conditions"`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			target := New().WithSetCondition("/status/conditions", scenario.existing, scenario.condition)
+
+			if len(scenario.expectedPatch) > 0 {
+				patchBytes, err := target.Marshal()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(patchBytes) != scenario.expectedPatch {
+					t.Fatalf("expected = %s, got = %s", scenario.expectedPatch, patchBytes)
+				}
+			}
+
+			document := scenario.document
+			if document == nil {
+				document = scenario.existing
+			}
+			type status struct {
+				Conditions []metav1.Condition `json:"conditions,omitempty"`
+			}
+			type object struct {
+				Status status `json:"status"`
+			}
+			documentBytes, err := json.Marshal(object{Status: status{Conditions: document}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patchedBytes, err := target.Apply(documentBytes)
+			if len(scenario.expectedError) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), scenario.expectedError) {
+					t.Fatalf("expected error starting with %q, got %v", scenario.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched := object{}
+			if err := json.Unmarshal(patchedBytes, &patched); err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(patched.Status.Conditions, scenario.expectedConditions) {
+				t.Fatalf("expected conditions %v, got %v", scenario.expectedConditions, patched.Status.Conditions)
+			}
+		})
+	}
+}
@@ -1,42 +1,364 @@
 package jsonpatch
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type PatchOperation struct {
 	Op    string      `json:"op,omitempty"`
+	From  string      `json:"from,omitempty"`
 	Path  string      `json:"path,omitempty"`
 	Value interface{} `json:"value,omitempty"`
+
+	// valueFrom is the path in the patched document the expected value of a test operation is read from.
+	// It can't be expressed in a JSON patch and is only honoured by Apply.
+	valueFrom string
+
+	// arrayLength is the length the array at the path of a test operation is expected to have.
+	// It can't be expressed in a JSON patch and is only honoured by Apply.
+	arrayLength *int
+
+	// ifChanged marks a replace operation that Prune drops when it doesn't change the value at its path.
+	ifChanged bool
+
+	// annotation is a human readable note explaining why the operation exists.
+	// It is only used for debugging and never sent on the wire.
+	annotation string
+}
+
+// MarshalJSON encodes the operation with the members RFC 6902 requires for its op.
+// The value of an add, replace or test operation is always encoded, even when it is null,
+// while remove, move and copy operations never have one.
+func (o PatchOperation) MarshalJSON() ([]byte, error) {
+	type operation struct {
+		Op    string       `json:"op"`
+		From  *string      `json:"from,omitempty"`
+		Path  string       `json:"path"`
+		Value *interface{} `json:"value,omitempty"`
+	}
+	ret := operation{Op: o.Op, Path: o.Path}
+	switch o.Op {
+	case patchMoveOperation, patchCopyOperation:
+		ret.From = &o.From
+	case patchRemoveOperation:
+	default:
+		ret.Value = &o.Value
+	}
+	return json.Marshal(ret)
 }
 
 const (
-	patchTestOperation   = "test"
-	patchRemoveOperation = "remove"
+	patchTestOperation    = "test"
+	patchRemoveOperation  = "remove"
+	patchAddOperation     = "add"
+	patchReplaceOperation = "replace"
+	patchMoveOperation    = "move"
+	patchCopyOperation    = "copy"
 )
 
 type PatchSet struct {
 	patches []PatchOperation
+
+	// canonicalValues makes Marshal re-encode the values of the operations in their canonical form.
+	canonicalValues bool
+	// rejectEmptyDocument makes Marshal and Apply fail for a patch that would empty the whole document.
+	rejectEmptyDocument bool
+	// statusSubresource marks a patch meant for the status subresource, see StatusPatch.
+	statusSubresource bool
 }
 
 func New() *PatchSet {
 	return &PatchSet{}
 }
 
+// StatusPatch returns a new patch set meant to be sent to the status subresource.
+//
+// The paths of a status patch are still relative to the whole object, e.g. "/status/conditions/0"
+// rather than "/conditions/0", but the server ignores changes outside of "/status".
+// Lint warns about operations modifying such paths.
+func StatusPatch() *PatchSet {
+	return &PatchSet{statusSubresource: true}
+}
+
+// derive returns an empty patch set with the options of the receiver.
+func (p *PatchSet) derive() *PatchSet {
+	return &PatchSet{canonicalValues: p.canonicalValues, rejectEmptyDocument: p.rejectEmptyDocument, statusSubresource: p.statusSubresource}
+}
+
+// WithCanonicalValues makes Marshal encode the values of the operations canonically,
+// with object keys sorted at all depths, so that the output is byte-stable.
+//
+// encoding/json already sorts the keys of maps, this matters for values that encode themselves,
+// e.g. json.RawMessage or types implementing json.Marshaler.
+func (p *PatchSet) WithCanonicalValues() *PatchSet {
+	p.canonicalValues = true
+	return p
+}
+
+// WithEmptyDocumentGuard makes Marshal fail for a patch removing the whole document or replacing it with
+// null or an empty object, and Apply fail for a patch resulting in such a document, so that a generated patch
+// can't wipe out the object it is applied to.
+func (p *PatchSet) WithEmptyDocumentGuard() *PatchSet {
+	p.rejectEmptyDocument = true
+	return p
+}
+
 func (p *PatchSet) WithRemove(path string, test TestCondition) *PatchSet {
-	p.WithTest(test.path, test.value)
+	p.withTestCondition(test)
 	p.addOperation(patchRemoveOperation, path, nil)
 	return p
 }
 
+// WithRemoveAll removes all the given paths guarded by the given tests.
+// Unlike repeated calls to WithRemove, the tests are emitted only once, before the first remove operation.
+func (p *PatchSet) WithRemoveAll(paths []string, tests ...TestCondition) *PatchSet {
+	for _, test := range tests {
+		p.withTestCondition(test)
+	}
+	for _, path := range paths {
+		p.addOperation(patchRemoveOperation, path, nil)
+	}
+	return p
+}
+
+// WithRemoveMapEntry removes the entry with the given key from the object referenced by mapPath,
+// e.g. an annotation from "/metadata/annotations". The key is escaped as a JSON pointer token,
+// so "example.com/foo" removes "/metadata/annotations/example.com~1foo".
+// The patch fails if the entry doesn't exist.
+func (p *PatchSet) WithRemoveMapEntry(mapPath, key string) *PatchSet {
+	p.addOperation(patchRemoveOperation, mapPath+"/"+escapePointerToken(key), nil)
+	return p
+}
+
+// escapePointerToken escapes the given object key as an RFC 6901 reference token, see parsePointer.
+func escapePointerToken(token string) string {
+	// "~" must be escaped first, otherwise the "~" of the escaped "/" would be escaped again
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// WithPrepend inserts the given value at the beginning of the array referenced by arrayPath.
+func (p *PatchSet) WithPrepend(arrayPath string, value interface{}) *PatchSet {
+	p.addOperation(patchAddOperation, arrayPath+"/0", value)
+	return p
+}
+
+// WithAdd adds the given value at the given path.
+// An existing object member is replaced, an array element is inserted at the given index.
+func (p *PatchSet) WithAdd(path string, value interface{}) *PatchSet {
+	p.addOperation(patchAddOperation, path, value)
+	return p
+}
+
+// WithReplace replaces the value at the given path, which must already exist.
+func (p *PatchSet) WithReplace(path string, value interface{}) *PatchSet {
+	p.addOperation(patchReplaceOperation, path, value)
+	return p
+}
+
+// WithReplaceIfChanged is like WithReplace but the operation is dropped by Prune
+// when the value at the given path already equals the given value.
+func (p *PatchSet) WithReplaceIfChanged(path string, value interface{}) *PatchSet {
+	p.addOperation(patchReplaceOperation, path, value)
+	p.patches[len(p.patches)-1].ifChanged = true
+	return p
+}
+
+// WithCompareAndReplace replaces the value at the given path with newValue only if it currently equals oldValue,
+// by a test operation with oldValue followed by a replace operation with newValue. The patch fails otherwise,
+// e.g. when the value was concurrently updated.
+func (p *PatchSet) WithCompareAndReplace(path string, oldValue, newValue interface{}) *PatchSet {
+	p.addOperation(patchTestOperation, path, oldValue)
+	p.addOperation(patchReplaceOperation, path, newValue)
+	return p
+}
+
+// WithMove removes the value at the from path and adds it at the given path.
+// The path must not be a child of from, a location can't be moved into itself.
+func (p *PatchSet) WithMove(from, path string) *PatchSet {
+	p.addOperation(patchMoveOperation, path, nil)
+	p.patches[len(p.patches)-1].From = from
+	return p
+}
+
+// WithCopy adds a copy of the value at the from path at the given path.
+func (p *PatchSet) WithCopy(from, path string) *PatchSet {
+	p.addOperation(patchCopyOperation, path, nil)
+	p.patches[len(p.patches)-1].From = from
+	return p
+}
+
 func (p *PatchSet) WithTest(path string, value interface{}) *PatchSet {
 	p.addOperation(patchTestOperation, path, value)
 	return p
 }
 
+// WithIf calls fn with the receiver only when cond is true, so that operations can be added conditionally without
+// breaking a chain of calls. It returns what fn returns, or the receiver when cond is false.
+func (p *PatchSet) WithIf(cond bool, fn func(*PatchSet) *PatchSet) *PatchSet {
+	if !cond {
+		return p
+	}
+	return fn(p)
+}
+
+// WithPathPrefix returns a new patch set with the given prefix prepended to the path of every operation.
+// This allows a patch built against a sub-object to be applied to its parent, e.g.
+// the prefix "/spec" rebases "/replicas" to "/spec/replicas" and the root path "" to "/spec".
+// The receiver is not modified.
+func (p *PatchSet) WithPathPrefix(prefix string) *PatchSet {
+	prefix = strings.TrimSuffix(prefix, "/")
+	ret := p.derive()
+	for _, patch := range p.patches {
+		patch.Path = prefix + patch.Path
+		if patch.Op == patchMoveOperation || patch.Op == patchCopyOperation {
+			patch.From = prefix + patch.From
+		}
+		if len(patch.valueFrom) > 0 {
+			patch.valueFrom = prefix + patch.valueFrom
+		}
+		ret.patches = append(ret.patches, patch)
+	}
+	return ret
+}
+
+// Filter returns a new patch set with the operations for which pred returns true, in their original order.
+// The receiver is not modified.
+func (p *PatchSet) Filter(pred func(PatchOperation) bool) *PatchSet {
+	ret := p.derive()
+	for _, patch := range p.patches {
+		if pred(patch) {
+			ret.patches = append(ret.patches, patch)
+		}
+	}
+	return ret
+}
+
+// Append adds the operations of other to the end of the patch, in their original order,
+// and returns the receiver. Test operations stay right in front of the operations they guard.
+// A nil or empty other is a no-op, other is not modified.
+func (p *PatchSet) Append(other *PatchSet) *PatchSet {
+	if other == nil {
+		return p
+	}
+	p.patches = append(p.patches, other.patches...)
+	return p
+}
+
+// Tests returns a new patch set with only the test operations.
+func (p *PatchSet) Tests() *PatchSet {
+	return p.Filter(func(patch PatchOperation) bool {
+		return patch.Op == patchTestOperation
+	})
+}
+
+// Mutations returns a new patch set with all but the test operations.
+func (p *PatchSet) Mutations() *PatchSet {
+	return p.Filter(func(patch PatchOperation) bool {
+		return patch.Op != patchTestOperation
+	})
+}
+
+// Paths returns the sorted and deduplicated paths referenced by the operations of the patch, i.e. their path
+// and the from path of move and copy operations, e.g. to analyse the impact of a patch.
+// The paths of the test operations, including the paths their expected value is read from, are only
+// returned when includeTests is true.
+func (p *PatchSet) Paths(includeTests bool) []string {
+	paths := sets.New[string]()
+	for _, patch := range p.patches {
+		if patch.Op == patchTestOperation {
+			if !includeTests {
+				continue
+			}
+			if len(patch.valueFrom) > 0 {
+				paths.Insert(patch.valueFrom)
+			}
+		}
+		paths.Insert(patch.Path)
+		if patch.Op == patchMoveOperation || patch.Op == patchCopyOperation {
+			paths.Insert(patch.From)
+		}
+	}
+	return sets.List(paths)
+}
+
+// RestrictTo returns an error if an operation of the patch modifies a path outside of the given prefixes,
+// e.g. RestrictTo("/status") rejects a patch that would modify the spec. A prefix matches the path itself
+// and its members, as with "/status" and "/status/conditions/0", but not "/statusDetails".
+// Test operations don't modify the document and may reference any path, as may the source of a copy.
+// The source of a move is removed, so it must match one of the prefixes too.
+func (p *PatchSet) RestrictTo(prefixes ...string) error {
+	var errs []error
+	for i, patch := range p.patches {
+		if patch.Op == patchTestOperation {
+			continue
+		}
+		if !hasPathPrefix(patch.Path, prefixes) {
+			errs = append(errs, fmt.Errorf("%s operation at index: %d modifies path: %q outside of the allowed paths: %q", patch.Op, i, patch.Path, prefixes))
+		}
+		if patch.Op == patchMoveOperation && !hasPathPrefix(patch.From, prefixes) {
+			errs = append(errs, fmt.Errorf("%s operation at index: %d removes path: %q outside of the allowed paths: %q", patch.Op, i, patch.From, prefixes))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// hasPathPrefix returns true if the path is one of the prefixes or one of their members.
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// WithAnnotation attaches the given human readable note to the last operation of the patch,
+// e.g. to explain why it exists. Annotations are shown by String and Lint but are never marshalled.
+// It is a no-op on an empty patch.
+func (p *PatchSet) WithAnnotation(annotation string) *PatchSet {
+	if len(p.patches) > 0 {
+		p.patches[len(p.patches)-1].annotation = annotation
+	}
+	return p
+}
+
+// String returns a human readable representation of the patch with one operation per line, including their annotations.
+// It is meant for debugging, use Marshal to get the JSON patch.
+func (p *PatchSet) String() string {
+	lines := make([]string, 0, len(p.patches))
+	for i, patch := range p.patches {
+		line := fmt.Sprintf("%d: %s %q", i, patch.Op, patch.Path)
+		switch {
+		case len(patch.valueFrom) > 0:
+			line += fmt.Sprintf(" value from %q", patch.valueFrom)
+		case patch.arrayLength != nil:
+			line += fmt.Sprintf(" length %d", *patch.arrayLength)
+		case patch.Op == patchMoveOperation || patch.Op == patchCopyOperation:
+			line += fmt.Sprintf(" from %q", patch.From)
+		case patch.Op != patchRemoveOperation:
+			rawValue, err := json.Marshal(patch.Value)
+			if err != nil {
+				rawValue = []byte(fmt.Sprintf("<%v>", err))
+			}
+			line += " " + string(rawValue)
+		}
+		if patch.ifChanged {
+			line += " if changed"
+		}
+		if len(patch.annotation) > 0 {
+			line += " # " + patch.annotation
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 func (p *PatchSet) IsEmpty() bool {
 	return len(p.patches) == 0
 }
@@ -45,13 +367,65 @@ func (p *PatchSet) Marshal() ([]byte, error) {
 	if err := p.validate(); err != nil {
 		return nil, err
 	}
-	jsonBytes, err := json.Marshal(p.patches)
+	for i, patch := range p.patches {
+		if len(patch.valueFrom) > 0 {
+			return nil, fmt.Errorf("%s operation at index: %d reads its value from path: %q which can't be expressed in a JSON patch", patch.Op, i, patch.valueFrom)
+		}
+		if patch.arrayLength != nil {
+			return nil, fmt.Errorf("%s operation at index: %d tests the length of the array at path: %q which can't be expressed in a JSON patch", patch.Op, i, patch.Path)
+		}
+	}
+	patches := p.patches
+	if p.canonicalValues {
+		var err error
+		if patches, err = canonicalizeValues(p.patches); err != nil {
+			return nil, err
+		}
+	}
+	jsonBytes, err := json.Marshal(patches)
 	if err != nil {
 		return nil, err
 	}
 	return jsonBytes, nil
 }
 
+// MarshalIndent is like Marshal but produces human-readable, indented JSON, e.g. for logging while debugging.
+// It represents the same operations as Marshal, whose compact output should be preferred on the wire.
+func (p *PatchSet) MarshalIndent() ([]byte, error) {
+	jsonBytes, err := p.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, jsonBytes, "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// canonicalizeValues returns a copy of the given operations with their values decoded into generic
+// JSON values, which encoding/json encodes with sorted object keys. Numbers are kept verbatim.
+func canonicalizeValues(patches []PatchOperation) ([]PatchOperation, error) {
+	ret := make([]PatchOperation, 0, len(patches))
+	for i, patch := range patches {
+		if patch.Value != nil {
+			rawValue, err := json.Marshal(patch.Value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode the value of the %s operation at index: %d: %w", patch.Op, i, err)
+			}
+			decoder := json.NewDecoder(bytes.NewReader(rawValue))
+			decoder.UseNumber()
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				return nil, fmt.Errorf("unable to decode the value of the %s operation at index: %d: %w", patch.Op, i, err)
+			}
+			patch.Value = value
+		}
+		ret = append(ret, patch)
+	}
+	return ret, nil
+}
+
 func (p *PatchSet) addOperation(op, path string, value interface{}) {
 	patch := PatchOperation{
 		Op:    op,
@@ -61,6 +435,16 @@ func (p *PatchSet) addOperation(op, path string, value interface{}) {
 	p.patches = append(p.patches, patch)
 }
 
+func (p *PatchSet) withTestCondition(test TestCondition) {
+	p.WithTest(test.path, test.value)
+	if len(test.sourcePath) > 0 {
+		p.patches[len(p.patches)-1].valueFrom = test.sourcePath
+	}
+	if test.arrayLength != nil {
+		p.patches[len(p.patches)-1].arrayLength = test.arrayLength
+	}
+}
+
 func (p *PatchSet) validate() error {
 	var errs []error
 	for i, patch := range p.patches {
@@ -73,6 +457,22 @@ func (p *PatchSet) validate() error {
 				errs = append(errs, fmt.Errorf("test operation at index: %d contains forbidden path: %q", i, patch.Path))
 			}
 		}
+		if patch.Op == patchMoveOperation {
+			// RFC 6902 forbids moving a location into one of its children
+			if patch.Path != patch.From && (len(patch.From) == 0 || strings.HasPrefix(patch.Path, patch.From+"/")) {
+				errs = append(errs, fmt.Errorf("move operation at index: %d moves path: %q into its own child: %q", i, patch.From, patch.Path))
+			}
+		}
+		if p.rejectEmptyDocument && len(patch.Path) == 0 {
+			switch patch.Op {
+			case patchRemoveOperation:
+				errs = append(errs, fmt.Errorf("remove operation at index: %d removes the whole document", i))
+			case patchAddOperation, patchReplaceOperation:
+				if value, err := toJSONValue(patch.Value); err == nil && isEmptyDocument(value) {
+					errs = append(errs, fmt.Errorf("%s operation at index: %d replaces the whole document with an empty value", patch.Op, i))
+				}
+			}
+		}
 	}
 	return utilerrors.NewAggregate(errs)
 }
@@ -80,8 +480,32 @@ func (p *PatchSet) validate() error {
 type TestCondition struct {
 	path  string
 	value interface{}
+
+	// sourcePath is the path the expected value is read from, see NewTestFromPath.
+	sourcePath string
+
+	// arrayLength is the expected length of the array at path, see NewArrayLengthCondition.
+	arrayLength *int
 }
 
 func NewTestCondition(path string, value interface{}) TestCondition {
-	return TestCondition{path, value}
+	return TestCondition{path: path, value: value}
+}
+
+// NewTestFromPath returns a test condition that expects the value at testPath to equal
+// the value found at sourcePath in the document the patch is applied to.
+//
+// JSON patches can only test against literal values, so patches using this condition
+// are simulation only: Apply resolves sourcePath at apply time while Marshal fails.
+func NewTestFromPath(testPath, sourcePath string) TestCondition {
+	return TestCondition{path: testPath, sourcePath: sourcePath}
+}
+
+// NewArrayLengthCondition returns a test condition that expects the array at arrayPath to have the given length,
+// e.g. to guard an append against a concurrent modification of the array.
+//
+// JSON patches can't test the length of an array, so patches using this condition
+// are simulation only: Apply checks the length while Marshal fails.
+func NewArrayLengthCondition(arrayPath string, length int) TestCondition {
+	return TestCondition{path: arrayPath, arrayLength: &length}
 }
@@ -1,7 +1,11 @@
 package jsonpatch
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -36,6 +40,31 @@ func TestJSONPatchNegative(t *testing.T) {
 				WithTest("/metadata/resourceVersion", "2"),
 			expectedError: fmt.Errorf(`[test operation at index: 0 contains forbidden path: "/metadata/resourceVersion", test operation at index: 2 contains forbidden path: "/metadata/resourceVersion"]`),
 		},
+		{
+			name:          "test reading its value from the document can't be marshalled",
+			target:        New().WithRemove("/status/foo", NewTestFromPath("/status/observedGeneration", "/metadata/generation")),
+			expectedError: fmt.Errorf(`test operation at index: 0 reads its value from path: "/metadata/generation" which can't be expressed in a JSON patch`),
+		},
+		{
+			name:          "array length test can't be marshalled",
+			target:        New().WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 2)).WithAdd("/status/items/-", "c"),
+			expectedError: fmt.Errorf(`test operation at index: 0 tests the length of the array at path: "/status/items" which can't be expressed in a JSON patch`),
+		},
+		{
+			name:          "move into a child is forbidden",
+			target:        New().WithMove("/spec/template", "/spec/template/metadata"),
+			expectedError: fmt.Errorf(`move operation at index: 0 moves path: "/spec/template" into its own child: "/spec/template/metadata"`),
+		},
+		{
+			name:          "move of the root is forbidden",
+			target:        New().WithMove("", "/spec"),
+			expectedError: fmt.Errorf(`move operation at index: 0 moves path: "" into its own child: "/spec"`),
+		},
+		{
+			name:          "move into a child array element is forbidden",
+			target:        New().WithTest("/spec/items/0", "a").WithMove("/spec/items", "/spec/items/0"),
+			expectedError: fmt.Errorf(`move operation at index: 1 moves path: "/spec/items" into its own child: "/spec/items/0"`),
+		},
 	}
 	for _, scenario := range scenarios {
 		t.Run(scenario.name, func(t *testing.T) {
@@ -66,6 +95,26 @@ func TestJSONPatch(t *testing.T) {
 			target:         New().WithTest("/status/condition", "foo"),
 			expectedOutput: `[{"op":"test","path":"/status/condition","value":"foo"}]`,
 		},
+		{
+			name:           "null values of add, replace and test operations are encoded",
+			target:         New().WithTest("/spec/foo", nil).WithAdd("/spec/bar", nil).WithReplace("/spec/baz", nil),
+			expectedOutput: `[{"op":"test","path":"/spec/foo","value":null},{"op":"add","path":"/spec/bar","value":null},{"op":"replace","path":"/spec/baz","value":null}]`,
+		},
+		{
+			name:           "root paths are encoded",
+			target:         New().WithReplace("", map[string]interface{}{"spec": nil}).WithCopy("", "/spec"),
+			expectedOutput: `[{"op":"replace","path":"","value":{"spec":null}},{"op":"copy","from":"","path":"/spec"}]`,
+		},
+		{
+			name:           "patch WithMove to a sibling with a common prefix",
+			target:         New().WithMove("/spec/template", "/spec/templateBackup"),
+			expectedOutput: `[{"op":"move","from":"/spec/template","path":"/spec/templateBackup"}]`,
+		},
+		{
+			name:           "patch WithCopy into a child",
+			target:         New().WithCopy("/spec/template", "/spec/template/backup"),
+			expectedOutput: `[{"op":"copy","from":"/spec/template","path":"/spec/template/backup"}]`,
+		},
 		{
 			name:           "patch WithTest and WithRemove",
 			target:         New().WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")),
@@ -86,6 +135,26 @@ func TestJSONPatch(t *testing.T) {
 			target:         New().WithTest("/status/secondCondition", "foo").WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")),
 			expectedOutput: `[{"op":"test","path":"/status/secondCondition","value":"foo"},{"op":"test","path":"/status/condition","value":"bar"},{"op":"remove","path":"/status/foo"}]`,
 		},
+		{
+			name:           "patch WithRemoveAll emits the shared test once",
+			target:         New().WithRemoveAll([]string{"/status/foo", "/status/bar"}, NewTestCondition("/status/condition", "bar")),
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"remove","path":"/status/foo"},{"op":"remove","path":"/status/bar"}]`,
+		},
+		{
+			name:           "patch WithRemoveAll multiple tests",
+			target:         New().WithRemoveAll([]string{"/status/foo", "/status/bar"}, NewTestCondition("/status/condition", "bar"), NewTestCondition("/metadata/uid", "1")),
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"test","path":"/metadata/uid","value":"1"},{"op":"remove","path":"/status/foo"},{"op":"remove","path":"/status/bar"}]`,
+		},
+		{
+			name:           "patch WithRemoveAll without tests",
+			target:         New().WithRemoveAll([]string{"/status/foo"}),
+			expectedOutput: `[{"op":"remove","path":"/status/foo"}]`,
+		},
+		{
+			name:           "patch WithPrepend",
+			target:         New().WithPrepend("/spec/items", "foo"),
+			expectedOutput: `[{"op":"add","path":"/spec/items/0","value":"foo"}]`,
+		},
 	}
 	for _, scenario := range scenarios {
 		t.Run(scenario.name, func(t *testing.T) {
@@ -99,3 +168,688 @@ func TestJSONPatch(t *testing.T) {
 		})
 	}
 }
+
+func TestWithPathPrefix(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		target         *PatchSet
+		prefix         string
+		expectedOutput string
+	}{
+		{
+			name:           "empty patch",
+			target:         New(),
+			prefix:         "/spec",
+			expectedOutput: "null",
+		},
+		{
+			name:           "rebase under /spec",
+			target:         New().WithRemove("/foo", NewTestCondition("/bar", "baz")).WithPrepend("/items", "a"),
+			prefix:         "/spec",
+			expectedOutput: `[{"op":"test","path":"/spec/bar","value":"baz"},{"op":"remove","path":"/spec/foo"},{"op":"add","path":"/spec/items/0","value":"a"}]`,
+		},
+		{
+			name:           "from paths are rebased too",
+			target:         New().WithMove("/foo", "/bar").WithCopy("", "/backup"),
+			prefix:         "/spec",
+			expectedOutput: `[{"op":"move","from":"/spec/foo","path":"/spec/bar"},{"op":"copy","from":"/spec","path":"/spec/backup"}]`,
+		},
+		{
+			name:           "trailing slash in the prefix is ignored",
+			target:         New().WithTest("/foo", "bar"),
+			prefix:         "/spec/",
+			expectedOutput: `[{"op":"test","path":"/spec/foo","value":"bar"}]`,
+		},
+		{
+			name:           "root path",
+			target:         New().WithTest("", map[string]interface{}{"foo": "bar"}),
+			prefix:         "/spec",
+			expectedOutput: `[{"op":"test","path":"/spec","value":{"foo":"bar"}}]`,
+		},
+		{
+			name:           "nested prefix",
+			target:         New().WithTest("/foo", "bar"),
+			prefix:         "/spec/template",
+			expectedOutput: `[{"op":"test","path":"/spec/template/foo","value":"bar"}]`,
+		},
+		{
+			name:           "empty prefix",
+			target:         New().WithTest("/foo", "bar"),
+			prefix:         "",
+			expectedOutput: `[{"op":"test","path":"/foo","value":"bar"}]`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			original, err := scenario.target.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patchBytes, err := scenario.target.WithPathPrefix(scenario.prefix).Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patchBytes) != scenario.expectedOutput {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedOutput, patchBytes)
+			}
+
+			unchanged, err := scenario.target.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(original) != string(unchanged) {
+				t.Fatalf("expected the original patch to be unmodified, got = %s", unchanged)
+			}
+		})
+	}
+}
+
+func TestWithPathPrefixApply(t *testing.T) {
+	target := New().WithRemove("/foo", NewTestCondition("/bar", "baz")).WithPathPrefix("/spec")
+	actual, err := target.Apply([]byte(`{"spec":{"bar":"baz","foo":"1"},"status":{"foo":"2"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"spec":{"bar":"baz"},"status":{"foo":"2"}}`; string(actual) != expected {
+		t.Fatalf("expected = %s, got = %s", expected, actual)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	target := New().
+		WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")).
+		WithReplace("/status/replicas", 3).
+		WithRemove("/status/bar", NewTestCondition("/status/secondCondition", "baz"))
+
+	scenarios := []struct {
+		name           string
+		filter         func(*PatchSet) *PatchSet
+		expectedOutput string
+	}{
+		{
+			name:           "tests",
+			filter:         (*PatchSet).Tests,
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"test","path":"/status/secondCondition","value":"baz"}]`,
+		},
+		{
+			name:           "mutations",
+			filter:         (*PatchSet).Mutations,
+			expectedOutput: `[{"op":"remove","path":"/status/foo"},{"op":"replace","path":"/status/replicas","value":3},{"op":"remove","path":"/status/bar"}]`,
+		},
+		{
+			name: "custom predicate",
+			filter: func(p *PatchSet) *PatchSet {
+				return p.Filter(func(patch PatchOperation) bool { return patch.Path == "/status/bar" })
+			},
+			expectedOutput: `[{"op":"remove","path":"/status/bar"}]`,
+		},
+		{
+			name: "nothing matches",
+			filter: func(p *PatchSet) *PatchSet {
+				return p.Filter(func(PatchOperation) bool { return false })
+			},
+			expectedOutput: "null",
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			original, err := target.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patchBytes, err := scenario.filter(target).Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patchBytes) != scenario.expectedOutput {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedOutput, patchBytes)
+			}
+
+			unchanged, err := target.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(original) != string(unchanged) {
+				t.Fatalf("expected the original patch to be unmodified, got = %s", unchanged)
+			}
+		})
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	target := New().
+		WithTest("/metadata/name", "foo").
+		WithReplace("/spec/replicas", 3).
+		WithRemove("/status/conditions/0", NewTestCondition("/status/conditions/0/type", "Degraded"))
+
+	compact, err := target.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	indented, err := target.MarshalIndent()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[
+  {
+    "op": "test",
+    "path": "/metadata/name",
+    "value": "foo"
+  },
+  {
+    "op": "replace",
+    "path": "/spec/replicas",
+    "value": 3
+  },
+  {
+    "op": "test",
+    "path": "/status/conditions/0/type",
+    "value": "Degraded"
+  },
+  {
+    "op": "remove",
+    "path": "/status/conditions/0"
+  }
+]`
+	if string(indented) != expected {
+		t.Fatalf("expected = %s, got = %s", expected, indented)
+	}
+	if bytes.ContainsAny(compact, "\n ") {
+		t.Errorf("expected Marshal to stay compact, got %s", compact)
+	}
+
+	var compactOps, indentedOps []PatchOperation
+	if err := json.Unmarshal(compact, &compactOps); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(indented, &indentedOps); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(compactOps, indentedOps) {
+		t.Errorf("expected both encodings to represent the same operations, compact = %s, indented = %s", compact, indented)
+	}
+
+	// invalid patch sets fail the same way
+	invalid := New().WithTest("/metadata/resourceVersion", "1")
+	if _, err := invalid.MarshalIndent(); err == nil {
+		t.Error("expected an error for an invalid patch set")
+	} else if _, compactErr := invalid.Marshal(); compactErr == nil || compactErr.Error() != err.Error() {
+		t.Errorf("expected the same error as Marshal, got %v and %v", err, compactErr)
+	}
+}
+
+func TestWithCanonicalValues(t *testing.T) {
+	value := map[string]interface{}{
+		"zeta": []interface{}{
+			map[string]interface{}{"b": 1, "a": 2},
+			json.RawMessage(`{"y":{"d":[{"f":1,"e":2}],"c":3},"x":12345678901234567890}`),
+		},
+		"alpha": json.RawMessage(`{"b":"1","a":"2"}`),
+	}
+
+	rawPatch, err := New().WithTest("/spec", value).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"op":"test","path":"/spec","value":{"alpha":{"b":"1","a":"2"},"zeta":[{"a":2,"b":1},{"y":{"d":[{"f":1,"e":2}],"c":3},"x":12345678901234567890}]}}]`; string(rawPatch) != expected {
+		t.Fatalf("expected the self-encoded values to be kept verbatim without the option, expected = %s, got = %s", expected, rawPatch)
+	}
+
+	expected := `[{"op":"test","path":"/spec","value":{"alpha":{"a":"2","b":"1"},"zeta":[{"a":2,"b":1},{"x":12345678901234567890,"y":{"c":3,"d":[{"e":2,"f":1}]}}]}},{"op":"add","path":"/items/0","value":{"a":"2","b":"1"}}]`
+	target := New().WithCanonicalValues().WithTest("/spec", value).WithPrepend("/items", json.RawMessage(`{"b":"1","a":"2"}`))
+	for i := 0; i < 10; i++ {
+		patchBytes, err := target.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(patchBytes) != expected {
+			t.Fatalf("expected = %s, got = %s", expected, patchBytes)
+		}
+	}
+
+	// derived patch sets keep the option
+	patchBytes, err := target.Tests().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"op":"test","path":"/spec","value":{"alpha":{"a":"2","b":"1"},"zeta":[{"a":2,"b":1},{"x":12345678901234567890,"y":{"c":3,"d":[{"e":2,"f":1}]}}]}}]`; string(patchBytes) != expected {
+		t.Fatalf("expected = %s, got = %s", expected, patchBytes)
+	}
+}
+
+func TestWithAnnotation(t *testing.T) {
+	target := New().
+		WithAnnotation("ignored on an empty patch").
+		WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")).WithAnnotation("foo is stale").
+		WithReplace("/spec/replicas", 3).
+		WithRemoveAll(nil, NewTestFromPath("/status/observedGeneration", "/metadata/generation")).WithAnnotation("only when observed").
+		WithRemoveAll(nil, NewArrayLengthCondition("/status/items", 2))
+
+	expectedString := `0: test "/status/condition" "bar"
+1: remove "/status/foo" # foo is stale
+2: replace "/spec/replicas" 3
+3: test "/status/observedGeneration" value from "/metadata/generation" # only when observed
+4: test "/status/items" length 2`
+	if actual := target.String(); actual != expectedString {
+		t.Errorf("expected String() =\n%s\ngot:\n%s", expectedString, actual)
+	}
+
+	annotated := New().WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")).WithAnnotation("foo is stale").WithPathPrefix("/sub")
+	patchBytes, err := annotated.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `[{"op":"test","path":"/sub/status/condition","value":"bar"},{"op":"remove","path":"/sub/status/foo"}]`; string(patchBytes) != expected {
+		t.Errorf("expected the annotations not to be marshalled, expected = %s, got = %s", expected, patchBytes)
+	}
+	canonicalPatchBytes, err := annotated.WithCanonicalValues().Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(canonicalPatchBytes) != string(patchBytes) {
+		t.Errorf("expected the annotations not to be marshalled with canonical values, got = %s", canonicalPatchBytes)
+	}
+	if expected := `1: remove "/sub/status/foo" # foo is stale`; !strings.Contains(annotated.String(), expected) {
+		t.Errorf("expected the annotations to survive WithPathPrefix, got:\n%s", annotated.String())
+	}
+}
+
+func TestAppend(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		target         *PatchSet
+		other          *PatchSet
+		expectedOutput string
+	}{
+		{
+			name:           "nil other",
+			target:         New().WithReplace("/status/replicas", 3),
+			expectedOutput: `[{"op":"replace","path":"/status/replicas","value":3}]`,
+		},
+		{
+			name:           "empty other",
+			target:         New().WithReplace("/status/replicas", 3),
+			other:          New(),
+			expectedOutput: `[{"op":"replace","path":"/status/replicas","value":3}]`,
+		},
+		{
+			name:           "empty target",
+			target:         New(),
+			other:          New().WithReplace("/status/replicas", 3),
+			expectedOutput: `[{"op":"replace","path":"/status/replicas","value":3}]`,
+		},
+		{
+			name:           "tests are kept in front of the operations they guard",
+			target:         New().WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")),
+			other:          New().WithRemove("/status/bar", NewTestCondition("/status/secondCondition", "baz")).WithReplace("/status/replicas", 3),
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"remove","path":"/status/foo"},{"op":"test","path":"/status/secondCondition","value":"baz"},{"op":"remove","path":"/status/bar"},{"op":"replace","path":"/status/replicas","value":3}]`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			var originalOther []byte
+			if scenario.other != nil {
+				var err error
+				if originalOther, err = scenario.other.Marshal(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ret := scenario.target.Append(scenario.other)
+			if ret != scenario.target {
+				t.Fatal("expected the receiver to be returned")
+			}
+			patchBytes, err := ret.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patchBytes) != scenario.expectedOutput {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedOutput, patchBytes)
+			}
+
+			if scenario.other != nil {
+				unchanged, err := scenario.other.Marshal()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(originalOther) != string(unchanged) {
+					t.Fatalf("expected the other patch to be unmodified, got = %s", unchanged)
+				}
+			}
+		})
+	}
+
+	t.Run("annotations and value sources are kept", func(t *testing.T) {
+		target := New().WithReplace("/spec/replicas", 3).
+			Append(New().WithRemove("/spec/foo", NewTestFromPath("/spec/foo", "/status/foo")).WithAnnotation("drop foo"))
+		expected := "0: replace \"/spec/replicas\" 3\n1: test \"/spec/foo\" value from \"/status/foo\"\n2: remove \"/spec/foo\" # drop foo"
+		if actual := target.String(); actual != expected {
+			t.Fatalf("expected:\n%s\ngot:\n%s", expected, actual)
+		}
+	})
+}
+
+func TestRestrictTo(t *testing.T) {
+	scenarios := []struct {
+		name          string
+		patch         *PatchSet
+		prefixes      []string
+		expectedError string
+	}{
+		{
+			name:     "empty patch",
+			patch:    New(),
+			prefixes: []string{"/status"},
+		},
+		{
+			name: "in bounds",
+			patch: New().
+				WithRemove("/status/foo", NewTestCondition("/metadata/generation", 2)).
+				WithReplace("/status", map[string]interface{}{}).
+				WithAdd("/status/conditions/-", "bar").
+				WithMove("/status/bar", "/status/baz").
+				WithCopy("/spec/replicas", "/status/replicas"),
+			prefixes: []string{"/status"},
+		},
+		{
+			name:     "multiple prefixes",
+			patch:    New().WithReplace("/status/replicas", 3).WithReplace("/metadata/labels/foo", "bar"),
+			prefixes: []string{"/status", "/metadata/labels/"},
+		},
+		{
+			name:          "out of bounds",
+			patch:         New().WithReplace("/status/replicas", 3).WithReplace("/spec/replicas", 3),
+			prefixes:      []string{"/status"},
+			expectedError: `replace operation at index: 1 modifies path: "/spec/replicas" outside of the allowed paths: ["/status"]`,
+		},
+		{
+			name:          "sibling sharing the prefix",
+			patch:         New().WithAdd("/statusDetails", "foo"),
+			prefixes:      []string{"/status"},
+			expectedError: `add operation at index: 0 modifies path: "/statusDetails" outside of the allowed paths: ["/status"]`,
+		},
+		{
+			name:          "root",
+			patch:         New().WithReplace("", map[string]interface{}{}),
+			prefixes:      []string{"/status"},
+			expectedError: `replace operation at index: 0 modifies path: "" outside of the allowed paths: ["/status"]`,
+		},
+		{
+			name:          "move out of bounds",
+			patch:         New().WithMove("/spec/foo", "/status/foo"),
+			prefixes:      []string{"/status"},
+			expectedError: `move operation at index: 0 removes path: "/spec/foo" outside of the allowed paths: ["/status"]`,
+		},
+		{
+			name:          "no prefixes",
+			patch:         New().WithReplace("/status/replicas", 3).WithRemove("/spec/foo", NewTestCondition("/spec/foo", "bar")),
+			expectedError: `[replace operation at index: 0 modifies path: "/status/replicas" outside of the allowed paths: [], remove operation at index: 2 modifies path: "/spec/foo" outside of the allowed paths: []]`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			err := scenario.patch.RestrictTo(scenario.prefixes...)
+			if len(scenario.expectedError) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != scenario.expectedError {
+				t.Fatalf("expected error %q, got %v", scenario.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestWithRemoveMapEntry(t *testing.T) {
+	scenarios := []struct {
+		name           string
+		key            string
+		expectedOutput string
+	}{
+		{
+			name:           "plain key",
+			key:            "foo",
+			expectedOutput: `[{"op":"remove","path":"/metadata/annotations/foo"}]`,
+		},
+		{
+			name:           "key with a slash",
+			key:            "example.com/foo",
+			expectedOutput: `[{"op":"remove","path":"/metadata/annotations/example.com~1foo"}]`,
+		},
+		{
+			name:           "key with a tilde",
+			key:            "foo~bar",
+			expectedOutput: `[{"op":"remove","path":"/metadata/annotations/foo~0bar"}]`,
+		},
+		{
+			name:           "key with an escaped slash",
+			key:            "foo~1bar/baz",
+			expectedOutput: `[{"op":"remove","path":"/metadata/annotations/foo~01bar~1baz"}]`,
+		},
+		{
+			name:           "key with dots",
+			key:            "operator.openshift.io/dep-foo.bar",
+			expectedOutput: `[{"op":"remove","path":"/metadata/annotations/operator.openshift.io~1dep-foo.bar"}]`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			patch := New().WithRemoveMapEntry("/metadata/annotations", scenario.key)
+			patchBytes, err := patch.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patchBytes) != scenario.expectedOutput {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedOutput, patchBytes)
+			}
+
+			// the escaped path references the given key
+			document := fmt.Sprintf(`{"metadata":{"annotations":{%q:"1","other":"2"}}}`, scenario.key)
+			actual, err := patch.Apply([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := `{"metadata":{"annotations":{"other":"2"}}}`; string(actual) != expected {
+				t.Errorf("expected = %s, got = %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestPaths(t *testing.T) {
+	patch := New().
+		WithRemove("/status/foo", NewTestCondition("/metadata/generation", 2)).
+		WithReplace("/status/replicas", 3).
+		WithReplace("/status/replicas", 4).
+		WithMove("/spec/bar", "/status/bar").
+		WithCopy("/spec/replicas", "/status/copy").
+		WithRemove("/spec/baz", NewTestFromPath("/spec/baz", "/status/baz")).
+		WithRemove("/spec/list/1", NewArrayLengthCondition("/spec/list", 2))
+
+	scenarios := []struct {
+		name          string
+		patch         *PatchSet
+		includeTests  bool
+		expectedPaths []string
+	}{
+		{
+			name:          "empty patch",
+			patch:         New(),
+			expectedPaths: []string{},
+		},
+		{
+			name:          "tests excluded",
+			patch:         patch,
+			expectedPaths: []string{"/spec/bar", "/spec/baz", "/spec/list/1", "/spec/replicas", "/status/bar", "/status/copy", "/status/foo", "/status/replicas"},
+		},
+		{
+			name:          "tests included",
+			patch:         patch,
+			includeTests:  true,
+			expectedPaths: []string{"/metadata/generation", "/spec/bar", "/spec/baz", "/spec/list", "/spec/list/1", "/spec/replicas", "/status/bar", "/status/baz", "/status/copy", "/status/foo", "/status/replicas"},
+		},
+		{
+			name:          "root",
+			patch:         New().WithReplace("", map[string]interface{}{}),
+			expectedPaths: []string{""},
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			if actual := scenario.patch.Paths(scenario.includeTests); !reflect.DeepEqual(scenario.expectedPaths, actual) {
+				t.Errorf("expected = %q, got = %q", scenario.expectedPaths, actual)
+			}
+		})
+	}
+}
+
+func TestWithEmptyDocumentGuard(t *testing.T) {
+	document := []byte(`{"metadata":{"name":"foo"},"spec":{"replicas":1}}`)
+
+	scenarios := []struct {
+		name            string
+		patch           *PatchSet
+		expectMarshalOK bool
+		expectApplyOK   bool
+	}{
+		{
+			name:            "remove of the root",
+			patch:           New().WithRemove("", NewTestCondition("/metadata/name", "foo")),
+			expectMarshalOK: false,
+			expectApplyOK:   false,
+		},
+		{
+			name:            "replace of the root with an empty object",
+			patch:           New().WithReplace("", map[string]interface{}{}),
+			expectMarshalOK: false,
+			expectApplyOK:   false,
+		},
+		{
+			name:            "removal of every field",
+			patch:           New().WithRemove("/metadata", NewTestCondition("/metadata/name", "foo")).WithRemove("/spec", NewTestCondition("/spec/replicas", 1)),
+			expectMarshalOK: true,
+			expectApplyOK:   false,
+		},
+		{
+			name:            "replace of the root with an object",
+			patch:           New().WithReplace("", map[string]interface{}{"metadata": map[string]interface{}{"name": "bar"}}),
+			expectMarshalOK: true,
+			expectApplyOK:   true,
+		},
+		{
+			name:            "sub-object emptied",
+			patch:           New().WithRemove("/spec/replicas", NewTestCondition("/spec/replicas", 1)),
+			expectMarshalOK: true,
+			expectApplyOK:   true,
+		},
+		{
+			name:            "root rebased to a sub-object",
+			patch:           New().WithRemove("", NewTestCondition("/replicas", 1)).WithPathPrefix("/spec"),
+			expectMarshalOK: true,
+			expectApplyOK:   true,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			// without the guard, the patch is accepted
+			if _, err := scenario.patch.Marshal(); err != nil {
+				t.Fatalf("unexpected marshal error without the guard: %v", err)
+			}
+			if _, err := scenario.patch.Apply(document); err != nil {
+				t.Fatalf("unexpected apply error without the guard: %v", err)
+			}
+
+			guarded := scenario.patch.WithEmptyDocumentGuard()
+			if _, err := guarded.Marshal(); (err == nil) != scenario.expectMarshalOK {
+				t.Errorf("expected marshal to succeed: %v, got error: %v", scenario.expectMarshalOK, err)
+			}
+			if _, err := guarded.Apply(document); (err == nil) != scenario.expectApplyOK {
+				t.Errorf("expected apply to succeed: %v, got error: %v", scenario.expectApplyOK, err)
+			}
+		})
+	}
+
+	// derived patch sets keep the guard
+	if _, err := New().WithEmptyDocumentGuard().WithRemove("", NewTestCondition("/metadata/name", "foo")).Mutations().Marshal(); err == nil {
+		t.Errorf("expected the guard to be kept by derived patch sets")
+	}
+}
+
+func TestWithIf(t *testing.T) {
+	build := func(withReplicas bool) *PatchSet {
+		return New().
+			WithRemove("/status/foo", NewTestCondition("/status/condition", "bar")).
+			WithIf(withReplicas, func(p *PatchSet) *PatchSet {
+				return p.WithReplace("/spec/replicas", 3)
+			}).
+			WithAdd("/spec/bar", "baz")
+	}
+
+	scenarios := []struct {
+		name           string
+		patch          *PatchSet
+		expectedOutput string
+	}{
+		{
+			name:           "true",
+			patch:          build(true),
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"remove","path":"/status/foo"},{"op":"replace","path":"/spec/replicas","value":3},{"op":"add","path":"/spec/bar","value":"baz"}]`,
+		},
+		{
+			name:           "false",
+			patch:          build(false),
+			expectedOutput: `[{"op":"test","path":"/status/condition","value":"bar"},{"op":"remove","path":"/status/foo"},{"op":"add","path":"/spec/bar","value":"baz"}]`,
+		},
+		{
+			name: "returns the result of fn",
+			patch: New().WithReplace("/replicas", 3).WithIf(true, func(p *PatchSet) *PatchSet {
+				return p.WithPathPrefix("/spec")
+			}),
+			expectedOutput: `[{"op":"replace","path":"/spec/replicas","value":3}]`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			patchBytes, err := scenario.patch.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(patchBytes) != scenario.expectedOutput {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedOutput, patchBytes)
+			}
+		})
+	}
+}
+
+func TestWithCompareAndReplace(t *testing.T) {
+	patch := New().
+		WithAdd("/spec/foo", "bar").
+		WithCompareAndReplace("/spec/replicas", 1, 3).
+		WithCompareAndReplace("/metadata/labels/app", "old", "new")
+
+	patchBytes, err := patch.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := `[{"op":"add","path":"/spec/foo","value":"bar"},{"op":"test","path":"/spec/replicas","value":1},{"op":"replace","path":"/spec/replicas","value":3},{"op":"test","path":"/metadata/labels/app","value":"old"},{"op":"replace","path":"/metadata/labels/app","value":"new"}]`
+	if string(patchBytes) != expectedOutput {
+		t.Fatalf("expected = %s, got = %s", expectedOutput, patchBytes)
+	}
+
+	updated, err := patch.Apply([]byte(`{"metadata":{"labels":{"app":"old"}},"spec":{"replicas":1}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"metadata":{"labels":{"app":"new"}},"spec":{"foo":"bar","replicas":3}}`; string(updated) != expected {
+		t.Errorf("expected = %s, got = %s", expected, updated)
+	}
+
+	if _, err := patch.Apply([]byte(`{"metadata":{"labels":{"app":"old"}},"spec":{"replicas":2}}`)); err == nil {
+		t.Error("expected the patch to fail on a value different from the old value")
+	}
+}
@@ -0,0 +1,82 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Lint returns warnings about redundant operations in the patch, which usually point at a bug in how the patch is built:
+//   - a replace operation setting a path to the value it was just tested to have
+//   - a remove operation immediately followed by an add operation at the same path, which is a replace
+//
+// For a StatusPatch, it also warns about operations modifying paths outside of "/status", which the status
+// subresource ignores.
+//
+// Warnings include the annotation of the reported operation, if any.
+func (p *PatchSet) Lint() []string {
+	var warnings []string
+	for i, patch := range p.patches {
+		if p.statusSubresource && patch.Op != patchTestOperation {
+			if !hasPathPrefix(patch.Path, []string{"/status"}) {
+				warnings = append(warnings, withAnnotation(fmt.Sprintf("%s operation at index: %d of a status patch modifies path: %q outside of \"/status\", it is ignored", patch.Op, i, patch.Path), patch))
+			}
+			if patch.Op == patchMoveOperation && !hasPathPrefix(patch.From, []string{"/status"}) {
+				warnings = append(warnings, withAnnotation(fmt.Sprintf("%s operation at index: %d of a status patch removes path: %q outside of \"/status\", it is ignored", patch.Op, i, patch.From), patch))
+			}
+		}
+		switch patch.Op {
+		case patchReplaceOperation:
+			if testIndex, ok := p.lastTestOfPath(i, patch.Path); ok && sameValues(p.patches[testIndex].Value, patch.Value) {
+				warnings = append(warnings, withAnnotation(fmt.Sprintf("%s operation at index: %d sets path: %q to the value tested by the operation at index: %d, it is a no-op", patch.Op, i, patch.Path, testIndex), patch))
+			}
+		case patchRemoveOperation:
+			if i+1 < len(p.patches) && p.patches[i+1].Op == patchAddOperation && p.patches[i+1].Path == patch.Path {
+				warnings = append(warnings, withAnnotation(fmt.Sprintf("%s operation at index: %d is followed by an add operation at the same path: %q, use a replace operation instead", patch.Op, i, patch.Path), patch))
+			}
+		}
+	}
+	return warnings
+}
+
+func withAnnotation(warning string, patch PatchOperation) string {
+	if len(patch.annotation) == 0 {
+		return warning
+	}
+	return fmt.Sprintf("%s (annotation: %q)", warning, patch.annotation)
+}
+
+// lastTestOfPath returns the index of the last test operation with a literal value on the given path
+// before the operation at the given index, unless the path might have been mutated in between.
+func (p *PatchSet) lastTestOfPath(index int, path string) (int, bool) {
+	for i := index - 1; i >= 0; i-- {
+		patch := p.patches[i]
+		if patch.Op != patchTestOperation {
+			if pathsOverlap(patch.Path, path) || (patch.Op == patchMoveOperation && pathsOverlap(patch.From, path)) {
+				return 0, false
+			}
+			continue
+		}
+		if patch.Path == path {
+			return i, len(patch.valueFrom) == 0 && patch.arrayLength == nil
+		}
+	}
+	return 0, false
+}
+
+// pathsOverlap returns true if one of the paths references the other one or one of its members.
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(b, a+"/") || strings.HasPrefix(a, b+"/") || len(a) == 0 || len(b) == 0
+}
+
+func sameValues(a, b interface{}) bool {
+	aValue, err := toJSONValue(a)
+	if err != nil {
+		return false
+	}
+	bValue, err := toJSONValue(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
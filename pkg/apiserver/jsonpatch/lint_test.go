@@ -0,0 +1,113 @@
+package jsonpatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	scenarios := []struct {
+		name             string
+		target           *PatchSet
+		expectedWarnings []string
+	}{
+		{
+			name:   "empty patch",
+			target: New(),
+		},
+		{
+			name:   "guarded replace with a different value",
+			target: New().WithTest("/spec/replicas", 1).WithReplace("/spec/replicas", 3),
+		},
+		{
+			name:   "test then replace with the same value",
+			target: New().WithTest("/spec/replicas", 3).WithReplace("/spec/replicas", 3),
+			expectedWarnings: []string{
+				`replace operation at index: 1 sets path: "/spec/replicas" to the value tested by the operation at index: 0, it is a no-op`,
+			},
+		},
+		{
+			name:   "test then replace with the same value after unrelated operations",
+			target: New().WithTest("/spec/replicas", map[string]int{"a": 1}).WithRemove("/spec/foo", NewTestCondition("/spec/bar", "baz")).WithReplace("/spec/replicas", map[string]interface{}{"a": 1}),
+			expectedWarnings: []string{
+				`replace operation at index: 3 sets path: "/spec/replicas" to the value tested by the operation at index: 0, it is a no-op`,
+			},
+		},
+		{
+			name:   "test then replace with the same value after the path was mutated",
+			target: New().WithTest("/spec/replicas", 3).WithAdd("/spec", map[string]int{"replicas": 1}).WithReplace("/spec/replicas", 3),
+		},
+		{
+			name:   "test reading its value from the document then replace",
+			target: New().WithRemoveAll(nil, NewTestFromPath("/spec/replicas", "/status/replicas")).WithReplace("/spec/replicas", nil),
+		},
+		{
+			name:   "array length test then replace",
+			target: New().WithRemoveAll(nil, NewArrayLengthCondition("/spec/items", 0)).WithReplace("/spec/items", nil),
+		},
+		{
+			name:   "remove then add at the same path",
+			target: New().WithRemove("/spec/items/0", NewTestCondition("/spec/items/0", "a")).WithAdd("/spec/items/0", "b"),
+			expectedWarnings: []string{
+				`remove operation at index: 1 is followed by an add operation at the same path: "/spec/items/0", use a replace operation instead`,
+			},
+		},
+		{
+			name:   "remove then add at a different path",
+			target: New().WithRemoveAll([]string{"/spec/foo"}).WithAdd("/spec/bar", "b"),
+		},
+		{
+			name:   "warnings include the annotation",
+			target: New().WithTest("/spec/replicas", 3).WithReplace("/spec/replicas", 3).WithAnnotation("scale to the default"),
+			expectedWarnings: []string{
+				`replace operation at index: 1 sets path: "/spec/replicas" to the value tested by the operation at index: 0, it is a no-op (annotation: "scale to the default")`,
+			},
+		},
+		{
+			name:   "status patch modifying the status",
+			target: StatusPatch().WithTest("/spec/replicas", 3).WithReplace("/status/replicas", 3).WithMove("/status/foo", "/status/bar"),
+		},
+		{
+			name: "status patch modifying the spec",
+			target: StatusPatch().
+				WithReplace("/status/replicas", 3).
+				WithReplace("/spec/replicas", 3).WithAnnotation("scale up").
+				WithMove("/spec/foo", "/status/foo").
+				WithAdd("/statusDetails", "b"),
+			expectedWarnings: []string{
+				`replace operation at index: 1 of a status patch modifies path: "/spec/replicas" outside of "/status", it is ignored (annotation: "scale up")`,
+				`move operation at index: 2 of a status patch removes path: "/spec/foo" outside of "/status", it is ignored`,
+				`add operation at index: 3 of a status patch modifies path: "/statusDetails" outside of "/status", it is ignored`,
+			},
+		},
+		{
+			name:   "status patch built against the status",
+			target: StatusPatch().WithReplace("/replicas", 3).WithPathPrefix("/status"),
+		},
+		{
+			name:   "patch derived from a status patch",
+			target: StatusPatch().WithTest("/spec/replicas", 1).WithReplace("/spec/replicas", 3).Mutations(),
+			expectedWarnings: []string{
+				`replace operation at index: 0 of a status patch modifies path: "/spec/replicas" outside of "/status", it is ignored`,
+			},
+		},
+		{
+			name: "multiple warnings",
+			target: New().
+				WithRemoveAll([]string{"/spec/foo"}).WithAdd("/spec/foo", "b").
+				WithTest("/spec/bar", "c").WithReplace("/spec/bar", "c"),
+			expectedWarnings: []string{
+				`remove operation at index: 0 is followed by an add operation at the same path: "/spec/foo", use a replace operation instead`,
+				`replace operation at index: 3 sets path: "/spec/bar" to the value tested by the operation at index: 2, it is a no-op`,
+			},
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			actualWarnings := scenario.target.Lint()
+			if !reflect.DeepEqual(scenario.expectedWarnings, actualWarnings) {
+				t.Errorf("expected warnings %q, got %q", scenario.expectedWarnings, actualWarnings)
+			}
+		})
+	}
+}
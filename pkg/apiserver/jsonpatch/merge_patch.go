@@ -0,0 +1,96 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ToMergePatch converts the patch set to an equivalent JSON merge patch (RFC 7386).
+//
+// Only sets made of add, replace and remove operations on object members can be converted.
+// An error is returned for operations that have no merge patch representation:
+//   - test operations, a merge patch can't be made conditional,
+//   - operations referencing array elements, a merge patch can only replace whole arrays,
+//     note that member names that look like array indices are rejected for that reason too,
+//   - operations on the root of the document,
+//   - null values, a merge patch uses null to remove a member,
+//   - object values, a merge patch merges them into the existing object instead of replacing it.
+//
+// Unlike the JSON patch, the merge patch creates missing parent objects
+// and doesn't fail when a removed member doesn't exist.
+func (p *PatchSet) ToMergePatch() ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	mergePatch := map[string]interface{}{}
+	for i, patch := range p.patches {
+		if err := addToMergePatch(mergePatch, patch); err != nil {
+			return nil, fmt.Errorf("%s operation at index: %d can't be converted to a merge patch: %w", patch.Op, i, err)
+		}
+	}
+	return json.Marshal(mergePatch)
+}
+
+func addToMergePatch(mergePatch map[string]interface{}, patch PatchOperation) error {
+	var value interface{}
+	switch patch.Op {
+	case patchAddOperation, patchReplaceOperation:
+		var err error
+		if value, err = toJSONValue(patch.Value); err != nil {
+			return err
+		}
+		switch value.(type) {
+		case nil:
+			return fmt.Errorf("null values are not supported")
+		case map[string]interface{}:
+			return fmt.Errorf("object values are not supported")
+		}
+	case patchRemoveOperation:
+		value = nil
+	case patchTestOperation:
+		return fmt.Errorf("a merge patch can't be made conditional")
+	default:
+		return fmt.Errorf("unsupported operation")
+	}
+
+	tokens, err := parsePointer(patch.Path)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("the root of the document can't be referenced")
+	}
+
+	node := mergePatch
+	for i, token := range tokens {
+		if isArrayReference(token) {
+			return fmt.Errorf("array elements can't be referenced, got %q", token)
+		}
+		if i == len(tokens)-1 {
+			node[token] = value
+			break
+		}
+		child, ok := node[token]
+		if !ok {
+			child = map[string]interface{}{}
+			node[token] = child
+		}
+		childObject, ok := child.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("key: %q is set by a previous operation", token)
+		}
+		node = childObject
+	}
+	return nil
+}
+
+// isArrayReference returns true when the given reference token can refer to an array element.
+func isArrayReference(token string) bool {
+	if token == "-" {
+		return true
+	}
+	index, err := strconv.Atoi(token)
+	return err == nil && index >= 0 && strconv.Itoa(index) == token
+}
@@ -0,0 +1,127 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+func TestToMergePatch(t *testing.T) {
+	removeLabel := New()
+	removeLabel.addOperation(patchRemoveOperation, "/metadata/labels/foo", nil)
+
+	scenarios := []struct {
+		name               string
+		target             *PatchSet
+		document           string
+		expectedMergePatch string
+		expectedError      string
+	}{
+		{
+			name:               "empty patch",
+			target:             New(),
+			document:           `{"spec":{"replicas":1}}`,
+			expectedMergePatch: `{}`,
+		},
+		{
+			name:               "add and replace object members",
+			target:             New().WithAdd("/metadata/labels/foo", "bar").WithReplace("/spec/replicas", 3),
+			document:           `{"metadata":{"labels":{"baz":"qux"}},"spec":{"replicas":1}}`,
+			expectedMergePatch: `{"metadata":{"labels":{"foo":"bar"}},"spec":{"replicas":3}}`,
+		},
+		{
+			name:               "remove an object member",
+			target:             removeLabel,
+			document:           `{"metadata":{"labels":{"baz":"qux","foo":"bar"}}}`,
+			expectedMergePatch: `{"metadata":{"labels":{"foo":null}}}`,
+		},
+		{
+			name:               "array values replace the whole array",
+			target:             New().WithReplace("/spec/items", []string{"c"}),
+			document:           `{"spec":{"items":["a","b"]}}`,
+			expectedMergePatch: `{"spec":{"items":["c"]}}`,
+		},
+		{
+			name:               "later operations win",
+			target:             New().WithAdd("/spec/replicas", 2).WithReplace("/spec/replicas", 3),
+			document:           `{"spec":{}}`,
+			expectedMergePatch: `{"spec":{"replicas":3}}`,
+		},
+		{
+			name:               "escaped keys",
+			target:             New().WithAdd("/metadata/annotations/example.com~1foo~0bar", "1"),
+			document:           `{"metadata":{"annotations":{}}}`,
+			expectedMergePatch: `{"metadata":{"annotations":{"example.com/foo~bar":"1"}}}`,
+		},
+		{
+			name:          "test operation",
+			target:        New().WithRemove("/status/foo", NewTestCondition("/status/condition", "ok")),
+			expectedError: `test operation at index: 0 can't be converted to a merge patch: a merge patch can't be made conditional`,
+		},
+		{
+			name:          "array element",
+			target:        New().WithPrepend("/spec/items", "a"),
+			expectedError: `add operation at index: 0 can't be converted to a merge patch: array elements can't be referenced, got "0"`,
+		},
+		{
+			name:          "array append",
+			target:        New().WithAdd("/spec/items/-", "a"),
+			expectedError: `add operation at index: 0 can't be converted to a merge patch: array elements can't be referenced, got "-"`,
+		},
+		{
+			name:          "object value",
+			target:        New().WithReplace("/spec", map[string]interface{}{"replicas": 3}),
+			expectedError: `replace operation at index: 0 can't be converted to a merge patch: object values are not supported`,
+		},
+		{
+			name:          "null value",
+			target:        New().WithAdd("/spec/selector", nil),
+			expectedError: `add operation at index: 0 can't be converted to a merge patch: null values are not supported`,
+		},
+		{
+			name:          "root of the document",
+			target:        New().WithReplace("", "foo"),
+			expectedError: `replace operation at index: 0 can't be converted to a merge patch: the root of the document can't be referenced`,
+		},
+		{
+			name:          "member of a value set by a previous operation",
+			target:        New().WithReplace("/spec/replicas", 3).WithAdd("/spec/replicas/foo", "bar"),
+			expectedError: `add operation at index: 1 can't be converted to a merge patch: key: "replicas" is set by a previous operation`,
+		},
+		{
+			name:          "validation errors are reported",
+			target:        New().WithTest("/metadata/resourceVersion", "1"),
+			expectedError: `test operation at index: 0 contains forbidden path: "/metadata/resourceVersion"`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			actualMergePatch, err := scenario.target.ToMergePatch()
+			if len(scenario.expectedError) > 0 {
+				if err == nil || err.Error() != scenario.expectedError {
+					t.Fatalf("unexpected err: %v, expected: %v", err, scenario.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actualMergePatch) != scenario.expectedMergePatch {
+				t.Fatalf("expected = %s, got = %s", scenario.expectedMergePatch, actualMergePatch)
+			}
+
+			// the merge patch must yield the same document as the JSON patch
+			expectedDocument, err := scenario.target.Apply([]byte(scenario.document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualDocument, err := jsonpatch.MergePatch([]byte(scenario.document), actualMergePatch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonpatch.Equal(expectedDocument, actualDocument) {
+				t.Fatalf("expected the merge patch to yield %s, got %s", expectedDocument, actualDocument)
+			}
+		})
+	}
+}
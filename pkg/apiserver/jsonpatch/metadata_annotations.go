@@ -0,0 +1,34 @@
+package jsonpatch
+
+const annotationsPath = "/metadata/annotations"
+
+// WithReplaceMetadataAnnotation adds the operations setting the metadata annotation with the given key to value,
+// on an object whose current annotations are existing. Unlike WithAnnotation, it patches the object.
+// The key is escaped as a JSON pointer token, see WithRemoveMapEntry.
+//
+// An existing annotation is replaced, a new one is added to the annotations map. If existing is empty, the map
+// is added as a whole, since adding a member fails when the map is absent and a JSON patch can't test for a
+// missing path. Nothing is added when the annotation already has the given value.
+func (p *PatchSet) WithReplaceMetadataAnnotation(existing map[string]string, key, value string) *PatchSet {
+	existingValue, ok := existing[key]
+	switch {
+	case ok && existingValue == value:
+		return p
+	case ok:
+		return p.WithReplace(annotationsPath+"/"+escapePointerToken(key), value)
+	case len(existing) == 0:
+		return p.WithAdd(annotationsPath, map[string]string{key: value})
+	default:
+		return p.WithAdd(annotationsPath+"/"+escapePointerToken(key), value)
+	}
+}
+
+// WithRemoveMetadataAnnotation adds the operation removing the metadata annotation with the given key from an object
+// whose current annotations are existing. Nothing is added when the annotation doesn't exist, so that
+// the patch doesn't fail on an object without it or without annotations at all.
+func (p *PatchSet) WithRemoveMetadataAnnotation(existing map[string]string, key string) *PatchSet {
+	if _, ok := existing[key]; !ok {
+		return p
+	}
+	return p.WithRemoveMapEntry(annotationsPath, key)
+}
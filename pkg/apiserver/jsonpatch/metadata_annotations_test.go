@@ -0,0 +1,139 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+func TestWithMetadataAnnotation(t *testing.T) {
+	scenarios := []struct {
+		name                string
+		existing            map[string]string
+		patch               func(*PatchSet, map[string]string) *PatchSet
+		document            map[string]string
+		expectedPatch       string
+		expectedAnnotations map[string]string
+		expectedError       string
+	}{
+		{
+			name:     "replace existing key with special characters",
+			existing: map[string]string{"example.com/foo~bar": "old", "other": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithReplaceMetadataAnnotation(existing, "example.com/foo~bar", "new")
+			},
+			expectedPatch:       `[{"op":"replace","path":"/metadata/annotations/example.com~1foo~0bar","value":"new"}]`,
+			expectedAnnotations: map[string]string{"example.com/foo~bar": "new", "other": "value"},
+		},
+		{
+			name:     "add new key",
+			existing: map[string]string{"other": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithReplaceMetadataAnnotation(existing, "example.com/foo", "new")
+			},
+			expectedPatch:       `[{"op":"add","path":"/metadata/annotations/example.com~1foo","value":"new"}]`,
+			expectedAnnotations: map[string]string{"example.com/foo": "new", "other": "value"},
+		},
+		{
+			name: "add to absent annotations",
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithReplaceMetadataAnnotation(existing, "example.com/foo", "new")
+			},
+			expectedPatch:       `[{"op":"add","path":"/metadata/annotations","value":{"example.com/foo":"new"}}]`,
+			expectedAnnotations: map[string]string{"example.com/foo": "new"},
+		},
+		{
+			name:     "unchanged annotation",
+			existing: map[string]string{"example.com/foo": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithReplaceMetadataAnnotation(existing, "example.com/foo", "value")
+			},
+			expectedPatch:       "null",
+			expectedAnnotations: map[string]string{"example.com/foo": "value"},
+		},
+		{
+			name:     "add fails when the annotations were removed in the meantime",
+			existing: map[string]string{"other": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithReplaceMetadataAnnotation(existing, "example.com/foo", "new")
+			},
+			document:      map[string]string{},
+			expectedError: `add operation at index: 0 failed`,
+		},
+		{
+			name:     "remove key with special characters",
+			existing: map[string]string{"example.com/foo~bar": "value", "other": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithRemoveMetadataAnnotation(existing, "example.com/foo~bar")
+			},
+			expectedPatch:       `[{"op":"remove","path":"/metadata/annotations/example.com~1foo~0bar"}]`,
+			expectedAnnotations: map[string]string{"other": "value"},
+		},
+		{
+			name:     "remove missing key",
+			existing: map[string]string{"other": "value"},
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithRemoveMetadataAnnotation(existing, "example.com/foo")
+			},
+			expectedPatch:       "null",
+			expectedAnnotations: map[string]string{"other": "value"},
+		},
+		{
+			name: "remove from absent annotations",
+			patch: func(p *PatchSet, existing map[string]string) *PatchSet {
+				return p.WithRemoveMetadataAnnotation(existing, "example.com/foo")
+			},
+			expectedPatch: "null",
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			target := scenario.patch(New(), scenario.existing)
+
+			if len(scenario.expectedPatch) > 0 {
+				patchBytes, err := target.Marshal()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(patchBytes) != scenario.expectedPatch {
+					t.Fatalf("expected = %s, got = %s", scenario.expectedPatch, patchBytes)
+				}
+			}
+
+			document := scenario.document
+			if document == nil {
+				document = scenario.existing
+			}
+			type metadata struct {
+				Annotations map[string]string `json:"annotations,omitempty"`
+			}
+			type object struct {
+				Metadata metadata `json:"metadata"`
+			}
+			documentBytes, err := json.Marshal(object{Metadata: metadata{Annotations: document}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patchedBytes, err := target.Apply(documentBytes)
+			if len(scenario.expectedError) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), scenario.expectedError) {
+					t.Fatalf("expected error starting with %q, got %v", scenario.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			patched := object{}
+			if err := json.Unmarshal(patchedBytes, &patched); err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(patched.Metadata.Annotations, scenario.expectedAnnotations) {
+				t.Fatalf("expected annotations %v, got %v", scenario.expectedAnnotations, patched.Metadata.Annotations)
+			}
+		})
+	}
+}
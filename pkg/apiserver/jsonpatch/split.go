@@ -0,0 +1,115 @@
+package jsonpatch
+
+import (
+	"fmt"
+)
+
+// Split partitions the patch into sequential patches whose marshalled JSON patch is at most maxBytes long,
+// e.g. to stay under the request size limit of the server. Applying the patches in order has the same effect as
+// applying the receiver, but not atomically: a failing patch doesn't revert the ones applied before.
+//
+// Test operations guard all the operations after them, so the tests of the previous patches are repeated at the
+// beginning of every next patch, unless an operation in between modified their path and they would fail.
+// An error is returned when an operation, together with the tests to repeat before it, doesn't fit in maxBytes,
+// or when the patch can't be marshalled.
+// The patches have the options of the receiver, which is not modified.
+func (p *PatchSet) Split(maxBytes int) ([]*PatchSet, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("the size limit must be positive, got %d", maxBytes)
+	}
+
+	var ret []*PatchSet
+	current := p.derive()
+	// currentSize is the size of the current patch without its enclosing brackets
+	currentSize := 0
+	// guards are the tests still holding at the current position, with their sizes
+	var guards []PatchOperation
+	var guardSizes []int
+
+	for i, patch := range p.patches {
+		size, err := p.operationSize(patch)
+		if err != nil {
+			return nil, err
+		}
+		if !fits(0, size, maxBytes) {
+			return nil, fmt.Errorf("%s operation at index: %d doesn't fit in %d bytes", patch.Op, i, maxBytes)
+		}
+		if !fits(currentSize, size, maxBytes) {
+			// a patch of tests only doesn't modify anything, its tests are repeated in the next patch anyway
+			if !current.Mutations().IsEmpty() {
+				ret = append(ret, current)
+			}
+			current = p.derive()
+			currentSize = 0
+			for j, guard := range guards {
+				current.patches = append(current.patches, guard)
+				currentSize = addSize(currentSize, guardSizes[j])
+			}
+		}
+		if !fits(currentSize, size, maxBytes) {
+			return nil, fmt.Errorf("%s operation at index: %d doesn't fit in %d bytes with the test operations guarding it", patch.Op, i, maxBytes)
+		}
+		current.patches = append(current.patches, patch)
+		currentSize = addSize(currentSize, size)
+
+		if patch.Op == patchTestOperation {
+			guards = append(guards, patch)
+			guardSizes = append(guardSizes, size)
+			continue
+		}
+		var holding []PatchOperation
+		var holdingSizes []int
+		for j, guard := range guards {
+			if modifiesTestedPath(patch, guard) {
+				continue
+			}
+			holding = append(holding, guard)
+			holdingSizes = append(holdingSizes, guardSizes[j])
+		}
+		guards, guardSizes = holding, holdingSizes
+	}
+	if !current.IsEmpty() {
+		ret = append(ret, current)
+	}
+	return ret, nil
+}
+
+// operationSize returns the length of the given operation marshalled as part of the receiver.
+func (p *PatchSet) operationSize(patch PatchOperation) (int, error) {
+	single := p.derive()
+	single.patches = []PatchOperation{patch}
+	jsonBytes, err := single.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	// drop the enclosing brackets
+	return len(jsonBytes) - 2, nil
+}
+
+// addSize returns the size of a list of operations of the given size with an operation of the given size appended.
+func addSize(listSize, size int) int {
+	if listSize == 0 {
+		return size
+	}
+	return listSize + 1 + size
+}
+
+// fits returns true if a list of operations of the given size with an operation of the given size appended
+// is at most maxBytes long once enclosed in brackets.
+func fits(listSize, size, maxBytes int) bool {
+	return addSize(listSize, size)+2 <= maxBytes
+}
+
+// modifiesTestedPath returns true if the given mutation might change the outcome of the given test.
+func modifiesTestedPath(mutation, test PatchOperation) bool {
+	paths := []string{test.Path}
+	if len(test.valueFrom) > 0 {
+		paths = append(paths, test.valueFrom)
+	}
+	for _, path := range paths {
+		if pathsOverlap(mutation.Path, path) || (mutation.Op == patchMoveOperation && pathsOverlap(mutation.From, path)) {
+			return true
+		}
+	}
+	return false
+}
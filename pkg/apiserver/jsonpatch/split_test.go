@@ -0,0 +1,136 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+func TestSplit(t *testing.T) {
+	document := `{"metadata":{"name":"foo","labels":{"a":"1"}},"spec":{"replicas":1,"paused":false}}`
+	value := strings.Repeat("x", 40)
+
+	scenarios := []struct {
+		name           string
+		patch          *PatchSet
+		maxBytes       int
+		expectedChunks []string
+		expectedError  string
+	}{
+		{
+			name:           "patch under the limit",
+			patch:          New().WithTest("/metadata/name", "foo").WithReplace("/spec/replicas", 3),
+			maxBytes:       1000,
+			expectedChunks: []string{`[{"op":"test","path":"/metadata/name","value":"foo"},{"op":"replace","path":"/spec/replicas","value":3}]`},
+		},
+		{
+			name:     "tests are repeated in every chunk",
+			patch:    New().WithTest("/metadata/name", "foo").WithAdd("/metadata/labels/b", value).WithAdd("/metadata/labels/c", value).WithReplace("/spec/replicas", 3),
+			maxBytes: 200,
+			expectedChunks: []string{
+				`[{"op":"test","path":"/metadata/name","value":"foo"},{"op":"add","path":"/metadata/labels/b","value":"` + value + `"}]`,
+				`[{"op":"test","path":"/metadata/name","value":"foo"},{"op":"add","path":"/metadata/labels/c","value":"` + value + `"},{"op":"replace","path":"/spec/replicas","value":3}]`,
+			},
+		},
+		{
+			name:     "tests of modified paths are not repeated",
+			patch:    New().WithTest("/metadata/name", "foo").WithTest("/spec/paused", false).WithReplace("/spec/paused", true).WithAdd("/metadata/labels/b", value).WithAdd("/metadata/labels/c", value),
+			maxBytes: 250,
+			expectedChunks: []string{
+				`[{"op":"test","path":"/metadata/name","value":"foo"},{"op":"test","path":"/spec/paused","value":false},{"op":"replace","path":"/spec/paused","value":true},{"op":"add","path":"/metadata/labels/b","value":"` + value + `"}]`,
+				`[{"op":"test","path":"/metadata/name","value":"foo"},{"op":"add","path":"/metadata/labels/c","value":"` + value + `"}]`,
+			},
+		},
+		{
+			name:           "empty patch",
+			patch:          New(),
+			maxBytes:       10,
+			expectedChunks: nil,
+		},
+		{
+			name:          "operation over the limit",
+			patch:         New().WithReplace("/spec/replicas", 3).WithAdd("/metadata/labels/b", value),
+			maxBytes:      60,
+			expectedError: `add operation at index: 1 doesn't fit in 60 bytes`,
+		},
+		{
+			name:          "operation over the limit with its tests",
+			patch:         New().WithTest("/metadata/name", "foo").WithAdd("/metadata/labels/b", value),
+			maxBytes:      100,
+			expectedError: `add operation at index: 1 doesn't fit in 100 bytes with the test operations guarding it`,
+		},
+		{
+			name:          "invalid limit",
+			patch:         New().WithReplace("/spec/replicas", 3),
+			expectedError: `the size limit must be positive, got 0`,
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			chunks, err := scenario.patch.Split(scenario.maxBytes)
+			if len(scenario.expectedError) > 0 {
+				if err == nil || err.Error() != scenario.expectedError {
+					t.Fatalf("expected error %q, got %v", scenario.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var actualChunks []string
+			for i, chunk := range chunks {
+				chunkBytes, err := chunk.Marshal()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(chunkBytes) > scenario.maxBytes {
+					t.Errorf("chunk %d is %d bytes long, over the limit of %d bytes: %s", i, len(chunkBytes), scenario.maxBytes, chunkBytes)
+				}
+				actualChunks = append(actualChunks, string(chunkBytes))
+			}
+			if !equality.Semantic.DeepEqual(actualChunks, scenario.expectedChunks) {
+				t.Fatalf("expected chunks:\n%s\ngot:\n%s", strings.Join(scenario.expectedChunks, "\n"), strings.Join(actualChunks, "\n"))
+			}
+
+			// applying the chunks in order is the same as applying the whole patch
+			expected, err := scenario.patch.Apply([]byte(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := []byte(document)
+			for i, chunk := range chunks {
+				if actual, err = chunk.Apply(actual); err != nil {
+					t.Fatalf("unable to apply chunk %d: %v", i, err)
+				}
+			}
+			var expectedDocument, actualDocument interface{}
+			if err := json.Unmarshal(expected, &expectedDocument); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(actual, &actualDocument); err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(actualDocument, expectedDocument) {
+				t.Errorf("expected the chunks to patch the document into %s, got %s", expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitKeepsOptions(t *testing.T) {
+	chunks, err := StatusPatch().WithEmptyDocumentGuard().WithReplace("/status/a", 1).WithReplace("/status/b", 2).Split(60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if !chunk.statusSubresource || !chunk.rejectEmptyDocument {
+			t.Errorf("expected chunk %d to keep the options of the patch", i)
+		}
+	}
+}
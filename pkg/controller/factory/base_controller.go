@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -11,11 +13,13 @@ import (
 
 	"github.com/robfig/cron"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/library-go/pkg/operator/management"
@@ -39,10 +43,32 @@ type baseController struct {
 	resyncEvery            time.Duration
 	resyncSchedules        []cron.Schedule
 	postStartHooks         []PostStartHook
+	postSyncHooks          []PostSyncHook
 	cacheSyncTimeout       time.Duration
+	clock                  clock.WithTicker
+	leadership             *LeadershipStatus
+	recoverSyncPanics      bool
+	syncPanicDegradedFn    SyncPanicDegradedFunc
+	// workers overrides the number of workers passed to Run when set, see Factory.WithWorkers
+	workers int
+	// crdPreconditions must be met before the controller waits for its caches, see Factory.WithCRDPrecondition
+	crdPreconditions []crdPrecondition
+	// enqueueTracker is set when the staleness of the queued keys is tracked, see Factory.WithEnqueueStalenessDetection
+	enqueueTracker *enqueueTracker
+	// initialSyncJitter is the maximum delay of the start of the workers, see Factory.WithInitialSyncJitter
+	initialSyncJitter time.Duration
+	// handlersToSync report whether the event handlers of the informers were called for the objects of the
+	// initial list, which the informer caches don't wait for, see RunOnce
+	handlersToSync []cache.InformerSynced
+	// queueKeysOnly is set when the keys are only queued by the queue keys funcs of the informers,
+	// the DefaultQueueKey is never queued then
+	queueKeysOnly bool
 }
 
 var _ Controller = &baseController{}
+var _ OnceRunner = &baseController{}
+var _ QueueDepthGetter = &baseController{}
+var _ EnqueueStalenessChecker = &baseController{}
 
 // Name returns a controller name.
 func (c baseController) Name() string {
@@ -55,6 +81,29 @@ func (c baseController) ControllerInstanceName() string {
 	return c.controllerInstanceName
 }
 
+func (c *baseController) QueueDepth() int {
+	return c.syncContext.Queue().Len()
+}
+
+func (c *baseController) StaleKeys() []string {
+	if c.enqueueTracker == nil {
+		return nil
+	}
+	return c.enqueueTracker.staleKeys()
+}
+
+// addHandlerToSync tracks the sync of the given event handler registration, returned by Informer.AddEventHandler.
+// Informers that don't return a registration are only tracked by their HasSynced.
+func (c *baseController) addHandlerToSync(registration cache.ResourceEventHandlerRegistration, err error) {
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("%q controller failed to add an event handler: %w", c.name, err))
+		return
+	}
+	if registration != nil {
+		c.handlersToSync = append(c.handlersToSync, registration.HasSynced)
+	}
+}
+
 type scheduledJob struct {
 	queue workqueue.RateLimitingInterface
 	name  string
@@ -88,6 +137,11 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	// HandleCrash recovers panics
 	defer utilruntime.HandleCrash(c.degradedPanicHandler)
 
+	if err := waitForCRDPreconditions(ctx, c.name, c.crdPreconditions...); err != nil {
+		// the controller was requested to stop
+		return
+	}
+
 	// give caches 10 minutes to sync
 	cacheSyncCtx, cacheSyncCancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
 	defer cacheSyncCancel()
@@ -106,6 +160,11 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 		}
 	}
 
+	if err := c.waitForInitialSyncJitter(ctx); err != nil {
+		// the controller was requested to stop
+		return
+	}
+
 	var workerWg sync.WaitGroup
 	defer func() {
 		defer klog.Infof("All %s workers have been terminated", c.name)
@@ -115,6 +174,10 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	// queueContext is used to track and initiate queue shutdown
 	queueContext, queueContextCancel := context.WithCancel(context.TODO())
 
+	if c.workers > 0 {
+		workers = c.workers
+	}
+
 	for i := 1; i <= workers; i++ {
 		klog.Infof("Starting #%d worker of %s controller ...", i, c.name)
 		workerWg.Add(1)
@@ -147,7 +210,7 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 		}
 		go func() {
 			defer workerWg.Done()
-			wait.UntilWithContext(ctx, func(ctx context.Context) { c.syncContext.Queue().Add(DefaultQueueKey) }, c.resyncEvery)
+			c.runPeriodicResync(ctx)
 		}()
 	}
 
@@ -181,6 +244,94 @@ func (c *baseController) Run(ctx context.Context, workers int) {
 	klog.Infof("Shutting down %s ...", c.name)
 }
 
+func (c *baseController) RunOnce(ctx context.Context) error {
+	if err := waitForCRDPreconditions(ctx, c.name, c.crdPreconditions...); err != nil {
+		return err
+	}
+	cacheSyncCtx, cacheSyncCancel := context.WithTimeout(ctx, c.cacheSyncTimeout)
+	defer cacheSyncCancel()
+	// the keys of the objects in the caches are queued once their event handlers are synced too
+	cachesToSync := append(append([]cache.InformerSynced{}, c.cachesToSync...), c.handlersToSync...)
+	if err := waitForNamedCacheSync(c.name, cacheSyncCtx.Done(), cachesToSync...); err != nil {
+		return err
+	}
+
+	queue := c.syncContext.Queue()
+	if queue.Len() == 0 && !c.queueKeysOnly {
+		queue.Add(DefaultQueueKey)
+	}
+
+	// only process the keys queued so far, keys re-queued by the syncs would make this never end
+	var errs []error
+	for remaining := queue.Len(); remaining > 0; remaining-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		key, quit := queue.Get()
+		if quit {
+			break
+		}
+		if err := c.syncOnce(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *baseController) syncOnce(ctx context.Context, key interface{}) error {
+	defer c.syncContext.Queue().Done(key)
+	defer c.syncContext.Queue().Forget(key)
+
+	syncCtx := c.syncContext.(syncContext)
+	var ok bool
+	syncCtx.queueKey, ok = key.(string)
+	if !ok {
+		return fmt.Errorf("%q controller failed to process key %q (not a string)", c.name, key)
+	}
+
+	err := c.syncWithPanicRecovery(ctx, syncCtx)
+	if err == SyntheticRequeueError {
+		// there is no retry when running once, the sync itself didn't fail
+		klog.V(5).Infof("%q controller requested synthetic requeue with key %q", c.name, key)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%q controller failed to sync %q, err: %w", c.name, key, err)
+	}
+	return nil
+}
+
+// waitForInitialSyncJitter waits for a random duration of up to initialSyncJitter, or until the context is cancelled.
+func (c *baseController) waitForInitialSyncJitter(ctx context.Context) error {
+	if c.initialSyncJitter <= 0 {
+		return nil
+	}
+	delay := time.Duration(rand.Int63n(int64(c.initialSyncJitter))) + 1
+	klog.Infof("Delaying the start of %s workers by %s", c.name, delay)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(delay):
+		return nil
+	}
+}
+
+// runPeriodicResync queues the default key right away and then every resyncEvery interval, until the context is cancelled.
+func (c *baseController) runPeriodicResync(ctx context.Context) {
+	ticker := c.clock.NewTicker(c.resyncEvery)
+	defer ticker.Stop()
+
+	for {
+		c.syncContext.Queue().Add(DefaultQueueKey)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
 func (c *baseController) Sync(ctx context.Context, syncCtx SyncContext) error {
 	return c.sync(ctx, syncCtx)
 }
@@ -216,6 +367,60 @@ func (c *baseController) reconcile(ctx context.Context, syncCtx SyncContext) err
 	return degradedErr
 }
 
+// reconcileWithPostSyncHooks wraps the reconcile() call and runs the post-sync hooks with its result.
+// A panic in reconcile() is recovered to run the hooks and then re-raised.
+func (c *baseController) reconcileWithPostSyncHooks(ctx context.Context, syncCtx SyncContext) (err error) {
+	if len(c.postSyncHooks) == 0 {
+		return c.reconcile(ctx, syncCtx)
+	}
+
+	start := c.clock.Now()
+	defer func() {
+		result := SyncResult{QueueKey: syncCtx.QueueKey(), Duration: c.clock.Since(start), Err: err}
+		if panicVal := recover(); panicVal != nil {
+			result.Err = fmt.Errorf("panic caught:\n%v", panicVal)
+			c.runPostSyncHooks(ctx, syncCtx, result)
+			panic(panicVal)
+		}
+		c.runPostSyncHooks(ctx, syncCtx, result)
+	}()
+	return c.reconcile(ctx, syncCtx)
+}
+
+// syncWithPanicRecovery wraps the reconcileWithPostSyncHooks() call and, when requested via WithSyncPanicRecovery,
+// converts a panic into an error so that the key is re-queued with backoff like for any other sync() error.
+func (c *baseController) syncWithPanicRecovery(ctx context.Context, syncCtx SyncContext) (err error) {
+	if !c.recoverSyncPanics {
+		return c.reconcileWithPostSyncHooks(ctx, syncCtx)
+	}
+
+	defer func() {
+		var panicErr error
+		if panicVal := recover(); panicVal != nil {
+			// the stack of the panicking goroutine is still available in the deferred call
+			klog.Errorf("Observed a panic in %q controller syncing %q: %v\n%s", c.name, syncCtx.QueueKey(), panicVal, debug.Stack())
+			syncCtx.Recorder().Warningf("ControllerSyncPanic", "Controller %q recovered from a panic syncing %q: %v", c.name, syncCtx.QueueKey(), panicVal)
+			panicErr = fmt.Errorf("panic caught:\n%v", panicVal)
+			err = panicErr
+		}
+		if c.syncPanicDegradedFn == nil {
+			return
+		}
+		if degradedErr := c.syncPanicDegradedFn(ctx, panicErr); degradedErr != nil {
+			klog.Warningf("%s controller failed to report the sync panic state: %v", c.name, degradedErr)
+		}
+	}()
+	return c.reconcileWithPostSyncHooks(ctx, syncCtx)
+}
+
+func (c *baseController) runPostSyncHooks(ctx context.Context, syncCtx SyncContext, result SyncResult) {
+	for _, hook := range c.postSyncHooks {
+		if err := hook(ctx, syncCtx, result); err != nil {
+			klog.Warningf("%s controller post sync hook error: %v", c.name, err)
+		}
+	}
+}
+
 // degradedPanicHandler will go degraded on failures, then we should catch potential panics and covert them into bad status.
 func (c *baseController) degradedPanicHandler(panicVal interface{}) {
 	if c.syncDegradedClient == nil {
@@ -260,6 +465,12 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 	}
 	defer c.syncContext.Queue().Done(key)
 
+	// hold the key until the leadership is regained, a key picked up right before
+	// losing the leadership must not be synced
+	if c.leadership != nil && !c.leadership.waitForLeadership(queueCtx) {
+		return
+	}
+
 	syncCtx := c.syncContext.(syncContext)
 	var ok bool
 	syncCtx.queueKey, ok = key.(string)
@@ -268,7 +479,7 @@ func (c *baseController) processNextWorkItem(queueCtx context.Context) {
 		return
 	}
 
-	if err := c.reconcile(queueCtx, syncCtx); err != nil {
+	if err := c.syncWithPanicRecovery(queueCtx, syncCtx); err != nil {
 		if err == SyntheticRequeueError {
 			// logging this helps detecting wedged controllers with missing pre-requirements
 			klog.V(5).Infof("%q controller requested synthetic requeue with key %q", c.name, key)
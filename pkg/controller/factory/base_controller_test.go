@@ -5,13 +5,22 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"reflect"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
 )
@@ -38,6 +47,35 @@ func (f *fakeInformer) HasSynced() bool {
 	return true
 }
 
+// lateInformer reports its cache as synced right away, but calls its event handler for the objects of the initial
+// list only after a delay, like a shared informer still notifying its other handlers.
+type lateInformer struct {
+	objects   []runtime.Object
+	delay     time.Duration
+	delivered atomic.Bool
+}
+
+func (i *lateInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	go func() {
+		time.Sleep(i.delay)
+		for _, obj := range i.objects {
+			handler.OnAdd(obj, true)
+		}
+		i.delivered.Store(true)
+	}()
+	return registrationSyncedFunc(i.delivered.Load), nil
+}
+
+func (i *lateInformer) HasSynced() bool {
+	return true
+}
+
+type registrationSyncedFunc func() bool
+
+func (f registrationSyncedFunc) HasSynced() bool {
+	return f()
+}
+
 func TestBaseController_ExitOneIfCachesWontSync(t *testing.T) {
 	c := &baseController{
 		syncContext:      NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
@@ -132,6 +170,123 @@ func TestBaseController_Reconcile(t *testing.T) {
 	}
 }
 
+func TestBaseController_PostSyncHooks(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	var results []SyncResult
+	c := &baseController{
+		name: "test",
+		sync: func(ctx context.Context, syncCtx SyncContext) error {
+			fakeClock.Step(2 * time.Second)
+			switch syncCtx.QueueKey() {
+			case "fail":
+				return fmt.Errorf("test error")
+			case "panic":
+				panic("test panic")
+			}
+			return nil
+		},
+		syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+		clock:       fakeClock,
+		postSyncHooks: []PostSyncHook{
+			func(ctx context.Context, syncCtx SyncContext, result SyncResult) error {
+				results = append(results, result)
+				return nil
+			},
+			func(ctx context.Context, syncCtx SyncContext, result SyncResult) error {
+				// errors of the hooks are only logged
+				return fmt.Errorf("hook error")
+			},
+		},
+	}
+
+	processKey := func(key string) (panicVal interface{}) {
+		defer func() { panicVal = recover() }()
+		c.syncContext.Queue().Add(key)
+		c.processNextWorkItem(context.TODO())
+		return nil
+	}
+
+	if panicVal := processKey("ok"); panicVal != nil {
+		t.Fatalf("unexpected panic: %v", panicVal)
+	}
+	if panicVal := processKey("fail"); panicVal != nil {
+		t.Fatalf("unexpected panic: %v", panicVal)
+	}
+	// the panic is passed through once the hooks ran
+	if panicVal := processKey("panic"); panicVal != "test panic" {
+		t.Fatalf("expected the sync panic to be re-raised, got %v", panicVal)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("expected the hook to run after each of the 3 syncs, got %d runs", len(results))
+	}
+	for i, expected := range []struct {
+		key string
+		err string
+	}{
+		{key: "ok"},
+		{key: "fail", err: "test error"},
+		{key: "panic", err: "panic caught:\ntest panic"},
+	} {
+		result := results[i]
+		if result.QueueKey != expected.key {
+			t.Errorf("expected hook run %d for key %q, got %q", i, expected.key, result.QueueKey)
+		}
+		if result.Duration != 2*time.Second {
+			t.Errorf("expected hook run %d to report a 2s sync, got %v", i, result.Duration)
+		}
+		switch {
+		case len(expected.err) == 0 && result.Err != nil:
+			t.Errorf("expected hook run %d without error, got %v", i, result.Err)
+		case len(expected.err) > 0 && (result.Err == nil || result.Err.Error() != expected.err):
+			t.Errorf("expected hook run %d with error %q, got %v", i, expected.err, result.Err)
+		}
+	}
+}
+
+func TestBaseController_SyncPanicRecovery(t *testing.T) {
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	var degradedErrs []error
+	c := New().
+		WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			if syncCtx.QueueKey() == "panic" {
+				panic("test panic")
+			}
+			return nil
+		}).
+		WithSyncPanicRecovery(func(ctx context.Context, panicErr error) error {
+			degradedErrs = append(degradedErrs, panicErr)
+			return nil
+		}).
+		ToController("test", recorder).(*baseController)
+
+	queue := c.syncContext.Queue()
+	queue.Add("panic")
+	c.processNextWorkItem(context.TODO())
+
+	if requeues := queue.NumRequeues("panic"); requeues != 1 {
+		t.Errorf("expected the panicking key to be re-queued with backoff once, got %d requeues", requeues)
+	}
+	if len(degradedErrs) != 1 || degradedErrs[0] == nil || degradedErrs[0].Error() != "panic caught:\ntest panic" {
+		t.Fatalf("expected the panic to be reported as degraded, got %v", degradedErrs)
+	}
+	var panicEvents int
+	for _, event := range recorder.Events() {
+		if event.Reason == "ControllerSyncPanic" && event.Type == corev1.EventTypeWarning {
+			panicEvents++
+		}
+	}
+	if panicEvents != 1 {
+		t.Errorf("expected a single ControllerSyncPanic warning event, got %d in %v", panicEvents, recorder.Events())
+	}
+
+	queue.Add("ok")
+	c.processNextWorkItem(context.TODO())
+	if len(degradedErrs) != 2 || degradedErrs[1] != nil {
+		t.Errorf("expected the degraded state to be cleared after a successful sync, got %v", degradedErrs)
+	}
+}
+
 func TestBaseController_Run(t *testing.T) {
 	informer := &fakeInformer{hasSyncedDelay: 200 * time.Millisecond}
 	controllerCtx, cancel := context.WithCancel(context.Background())
@@ -153,6 +308,7 @@ func TestBaseController_Run(t *testing.T) {
 		},
 		syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
 		resyncEvery: 200 * time.Millisecond,
+		clock:       clock.RealClock{},
 		postStartHooks: []PostStartHook{func(ctx context.Context, syncContext SyncContext) error {
 			defer func() {
 				postStartHookDone = true
@@ -184,3 +340,187 @@ func TestBaseController_Run(t *testing.T) {
 		t.Errorf("expected the post start hook to be terminated when context is cancelled")
 	}
 }
+
+func TestBaseController_PausedWithoutLeadership(t *testing.T) {
+	leadership := NewLeadershipStatus(false)
+	syncCh := make(chan string, 10)
+
+	c := &baseController{
+		name: "test",
+		sync: func(ctx context.Context, syncCtx SyncContext) error {
+			syncCh <- syncCtx.QueueKey()
+			return nil
+		},
+		syncContext:      NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+		cacheSyncTimeout: defaultCacheSyncTimeout,
+		clock:            clock.RealClock{},
+		leadership:       leadership,
+	}
+
+	expectNoSync := func(reason string) {
+		t.Helper()
+		select {
+		case key := <-syncCh:
+			t.Fatalf("expected no sync %s, got sync with %q", reason, key)
+		case <-time.After(300 * time.Millisecond):
+		}
+	}
+	expectSync := func(expectedKey string) {
+		t.Helper()
+		select {
+		case key := <-syncCh:
+			if key != expectedKey {
+				t.Fatalf("expected sync with %q, got %q", expectedKey, key)
+			}
+		case <-time.After(wait.ForeverTestTimeout):
+			t.Fatalf("expected sync with %q, got none", expectedKey)
+		}
+	}
+
+	controllerCtx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		c.Run(controllerCtx, 2)
+	}()
+
+	c.syncContext.Queue().Add("first")
+	expectNoSync("before the leadership is acquired")
+
+	leadership.SetLeading(true)
+	expectSync("first")
+
+	leadership.SetLeading(false)
+	c.syncContext.Queue().Add("second")
+	expectNoSync("after the leadership is lost")
+
+	leadership.SetLeading(true)
+	expectSync("second")
+
+	// paused workers must not block the shutdown
+	leadership.SetLeading(false)
+	c.syncContext.Queue().Add("third")
+	cancel()
+	select {
+	case <-runDone:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("expected the controller to shut down while paused")
+	}
+	expectNoSync("after the shutdown")
+}
+
+func TestBaseController_RunOnce(t *testing.T) {
+	newController := func(syncFn func(ctx context.Context, syncCtx SyncContext) error) *baseController {
+		return &baseController{
+			name:             "test",
+			cachesToSync:     []cache.InformerSynced{(&fakeInformer{}).HasSynced},
+			sync:             syncFn,
+			syncContext:      NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+			cacheSyncTimeout: defaultCacheSyncTimeout,
+			clock:            clock.RealClock{},
+		}
+	}
+
+	t.Run("one pass over all queued keys", func(t *testing.T) {
+		synced := map[string]int{}
+		c := newController(func(ctx context.Context, syncCtx SyncContext) error {
+			synced[syncCtx.QueueKey()]++
+			// keys queued by the sync are not part of the pass
+			syncCtx.Queue().Add(syncCtx.QueueKey())
+			if syncCtx.QueueKey() == "b" {
+				return fmt.Errorf("b failed")
+			}
+			return nil
+		})
+		for _, key := range []string{"a", "b", "c", "a"} {
+			c.syncContext.Queue().Add(key)
+		}
+
+		err := c.RunOnce(context.TODO())
+		if err == nil || err.Error() != `"test" controller failed to sync "b", err: b failed` {
+			t.Errorf("expected the error of b, got %v", err)
+		}
+		if expected := map[string]int{"a": 1, "b": 1, "c": 1}; !reflect.DeepEqual(expected, synced) {
+			t.Errorf("expected every key to be synced once, got %v", synced)
+		}
+	})
+
+	t.Run("default key when nothing is queued", func(t *testing.T) {
+		var synced []string
+		c := newController(func(ctx context.Context, syncCtx SyncContext) error {
+			synced = append(synced, syncCtx.QueueKey())
+			return SyntheticRequeueError
+		})
+
+		if err := c.RunOnce(context.TODO()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual([]string{DefaultQueueKey}, synced) {
+			t.Errorf("expected a single sync of the default key, got %v", synced)
+		}
+	})
+
+	t.Run("keys of the objects delivered after the cache sync", func(t *testing.T) {
+		informer := &lateInformer{
+			objects: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "a"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "b"}},
+			},
+			delay: 100 * time.Millisecond,
+		}
+		var lock sync.Mutex
+		synced := map[string]int{}
+		controller := New().WithInformersQueueKeyFunc(func(obj runtime.Object) string {
+			key, _ := cache.MetaNamespaceKeyFunc(obj)
+			return key
+		}, informer).WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			lock.Lock()
+			defer lock.Unlock()
+			synced[syncCtx.QueueKey()]++
+			return nil
+		}).ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+		if err := controller.(OnceRunner).RunOnce(context.TODO()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if expected := map[string]int{"ns/a": 1, "ns/b": 1}; !reflect.DeepEqual(expected, synced) {
+			t.Errorf("expected every object to be synced once, got %v", synced)
+		}
+	})
+
+	t.Run("no default key when only queue keys funcs queue keys", func(t *testing.T) {
+		syncCount := 0
+		controller := New().WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			return []string{"unexpected"}
+		}, &lateInformer{}).WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			syncCount++
+			return nil
+		}).ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+		if err := controller.(OnceRunner).RunOnce(context.TODO()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if syncCount != 0 {
+			t.Errorf("expected no sync without objects, got %d", syncCount)
+		}
+	})
+
+	t.Run("built controllers run once", func(t *testing.T) {
+		syncCount := 0
+		controller := New().WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			syncCount++
+			return nil
+		}).ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+		runner, ok := controller.(OnceRunner)
+		if !ok {
+			t.Fatal("expected the controller to implement OnceRunner")
+		}
+		if err := runner.RunOnce(context.TODO()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if syncCount != 1 {
+			t.Errorf("expected one sync, got %d", syncCount)
+		}
+	})
+}
@@ -20,6 +20,8 @@ type syncContext struct {
 	eventRecorder events.Recorder
 	queue         workqueue.RateLimitingInterface
 	queueKey      string
+	// enqueueTracker, when set, records the keys queued by the informers, see Factory.WithEnqueueStalenessDetection
+	enqueueTracker *enqueueTracker
 }
 
 var _ SyncContext = syncContext{}
@@ -67,14 +69,14 @@ func (c syncContext) eventHandler(queueKeysFunc ObjectQueueKeysFunc, filter Even
 			runtimeObj, ok := obj.(runtime.Object)
 			if !ok {
 				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
-					c.enqueueKeys(queueKeysFunc(tombstone.Obj.(runtime.Object))...)
+					c.enqueueDeletedKeys(queueKeysFunc(tombstone.Obj.(runtime.Object))...)
 
 					return
 				}
 				utilruntime.HandleError(fmt.Errorf("updated object %+v is not runtime Object", runtimeObj))
 				return
 			}
-			c.enqueueKeys(queueKeysFunc(runtimeObj)...)
+			c.enqueueDeletedKeys(queueKeysFunc(runtimeObj)...)
 		},
 	}
 	if filter == nil {
@@ -90,6 +92,19 @@ func (c syncContext) enqueueKeys(keys ...string) {
 	for _, qKey := range keys {
 		c.queue.Add(qKey)
 	}
+	if c.enqueueTracker != nil {
+		c.enqueueTracker.enqueued(keys...)
+	}
+}
+
+// enqueueDeletedKeys queues the keys of a deleted object, which are not tracked anymore.
+func (c syncContext) enqueueDeletedKeys(keys ...string) {
+	for _, qKey := range keys {
+		c.queue.Add(qKey)
+	}
+	if c.enqueueTracker != nil {
+		c.enqueueTracker.forget(keys...)
+	}
 }
 
 // namespaceChecker returns a function which returns true if an inpuut obj
@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -175,6 +176,46 @@ func TestSyncContext_eventHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:        "label selector event handler",
+			syncContext: NewSyncContext("test", eventstesting.NewTestingEventRecorder(t)),
+			filterFunc:  LabelSelectorFilter(labels.SelectorFromSet(labels.Set{"app": "foo"})),
+			queueKeyFunc: func(object runtime.Object) []string {
+				m, _ := meta.Accessor(object)
+				return []string{fmt.Sprintf("%s/%s", m.GetNamespace(), m.GetName())}
+			},
+			runEventHandlers: func(handler cache.ResourceEventHandler) {
+				matching := map[string]string{"app": "foo"}
+				other := map[string]string{"app": "bar"}
+
+				handler.OnAdd(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "add", Labels: other}}, false /* isInInitialList */)
+				handler.OnUpdate(
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "update", Labels: other}},
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "update"}})
+				handler.OnDelete(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "delete"}})
+				handler.OnDelete(cache.DeletedFinalStateUnknown{
+					Obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "tombstone", Labels: other}},
+				})
+
+				handler.OnAdd(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "add", Labels: matching}}, false /* isInInitialList */)
+				handler.OnUpdate(
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "update", Labels: other}},
+					&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "update", Labels: matching}})
+				handler.OnDelete(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "delete", Labels: matching}})
+				handler.OnDelete(cache.DeletedFinalStateUnknown{
+					Obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "tombstone", Labels: matching}},
+				})
+			},
+			expectedItemCount: 4,
+			evalQueueItems: func(s *threadSafeStringSet, t *testing.T) {
+				expect := []string{"add", "update", "delete", "tombstone"}
+				for _, e := range expect {
+					if !s.Has("bar/" + e) {
+						t.Errorf("expected %#v to have 'bar/%s'", sets.List(s.Set), e)
+					}
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
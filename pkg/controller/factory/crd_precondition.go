@@ -0,0 +1,62 @@
+package factory
+
+import (
+	"context"
+	"time"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionslistersv1 "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// crdPreconditionPollInterval is how often the CRD informer cache is checked for the established CRD.
+var crdPreconditionPollInterval = 5 * time.Second
+
+// crdPrecondition defers the start of a controller until a CRD is established.
+type crdPrecondition struct {
+	crdName        string
+	crdSynced      cache.InformerSynced
+	crdLister      apiextensionslistersv1.CustomResourceDefinitionLister
+	startInformers func(ctx context.Context)
+}
+
+// waitForCRDPreconditions waits for the CRD of every precondition to be established, in order, and calls their
+// startInformers. It only returns an error when the context is done.
+func waitForCRDPreconditions(ctx context.Context, controllerName string, preconditions ...crdPrecondition) error {
+	for _, precondition := range preconditions {
+		klog.Infof("Waiting for CRD %q to be established for %s", precondition.crdName, controllerName)
+		if err := wait.PollUntilContextCancel(ctx, crdPreconditionPollInterval, true, precondition.established); err != nil {
+			return err
+		}
+		klog.Infof("CRD %q is established for %s", precondition.crdName, controllerName)
+		if precondition.startInformers != nil {
+			precondition.startInformers(ctx)
+		}
+	}
+	return nil
+}
+
+func (p crdPrecondition) established(context.Context) (bool, error) {
+	if !p.crdSynced() {
+		return false, nil
+	}
+	crd, err := p.crdLister.Get(p.crdName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		// keep waiting, the lister is a cache and is expected to recover
+		utilruntime.HandleError(err)
+		return false, nil
+	}
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue, nil
+		}
+	}
+	return false, nil
+}
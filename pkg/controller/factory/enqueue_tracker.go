@@ -0,0 +1,63 @@
+package factory
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/clock"
+)
+
+// enqueueTracker remembers when the informers last queued every key.
+type enqueueTracker struct {
+	clock     clock.PassiveClock
+	threshold time.Duration
+
+	lock         sync.Mutex
+	lastEnqueued map[string]time.Time
+}
+
+func newEnqueueTracker(clock clock.PassiveClock, threshold time.Duration) *enqueueTracker {
+	return &enqueueTracker{
+		clock:        clock,
+		threshold:    threshold,
+		lastEnqueued: map[string]time.Time{},
+	}
+}
+
+func (t *enqueueTracker) enqueued(keys ...string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.clock.Now()
+	for _, key := range keys {
+		t.lastEnqueued[key] = now
+	}
+}
+
+// forget stops tracking the given keys, e.g. the keys of deleted objects, which are not expected to be queued anymore.
+// The DefaultQueueKey is shared by all the objects and is kept.
+func (t *enqueueTracker) forget(keys ...string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, key := range keys {
+		if key != DefaultQueueKey {
+			delete(t.lastEnqueued, key)
+		}
+	}
+}
+
+// staleKeys returns the sorted keys queued longer than the threshold ago.
+func (t *enqueueTracker) staleKeys() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stale := sets.New[string]()
+	for key, lastEnqueued := range t.lastEnqueued {
+		if t.clock.Since(lastEnqueued) > t.threshold {
+			stale.Insert(key)
+		}
+	}
+	return sets.List(stale)
+}
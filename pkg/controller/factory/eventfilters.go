@@ -1,9 +1,12 @@
 package factory
 
 import (
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
 )
 
 func ObjectNameToKey(obj runtime.Object) string {
@@ -24,3 +27,18 @@ func NamesFilter(names ...string) EventFilterFunc {
 		return nameSet.Has(metaObj.GetObjectMeta().GetName())
 	}
 }
+
+// LabelSelectorFilter returns an event filter that accepts objects, or tombstones of objects,
+// whose labels match the given selector.
+func LabelSelectorFilter(selector labels.Selector) EventFilterFunc {
+	return func(obj interface{}) bool {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		metaObj, err := meta.Accessor(obj)
+		if err != nil {
+			return false
+		}
+		return selector.Matches(labels.Set(metaObj.GetLabels()))
+	}
+}
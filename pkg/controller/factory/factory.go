@@ -7,10 +7,16 @@ import (
 	"time"
 
 	"github.com/robfig/cron"
+	apiextensionslistersv1 "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	errorutil "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -36,9 +42,20 @@ type Factory struct {
 	informerQueueKeys      []informersWithQueueKey
 	bareInformers          []Informer
 	postStartHooks         []PostStartHook
+	postSyncHooks          []PostSyncHook
 	namespaceInformers     []*namespaceInformer
 	cachesToSync           []cache.InformerSynced
 	controllerInstanceName string
+	clock                  clock.WithTicker
+	leadership             *LeadershipStatus
+	recoverSyncPanics      bool
+	syncPanicDegradedFn    SyncPanicDegradedFunc
+	queueDepthRegistry     metrics.KubeRegistry
+	workers                int
+	crdPreconditions       []crdPrecondition
+	stalenessThreshold     time.Duration
+	stalenessRegistry      metrics.KubeRegistry
+	initialSyncJitter      time.Duration
 }
 
 // Informer represents any structure that allow to register event handlers and informs if caches are synced.
@@ -69,6 +86,25 @@ type filteredInformers struct {
 // The syncContext allow access to controller queue and event recorder.
 type PostStartHook func(ctx context.Context, syncContext SyncContext) error
 
+// SyncResult describes the outcome of a single sync() call.
+type SyncResult struct {
+	// QueueKey is the queue key the sync() was called with.
+	QueueKey string
+	// Duration is how long the sync() call took.
+	Duration time.Duration
+	// Err is the error returned by the sync() call, or the recovered panic converted to an error.
+	Err error
+}
+
+// PostSyncHook specify a function that will run after every sync() call, regardless of its outcome.
+// Errors returned by the hook are logged and do not affect the sync result.
+type PostSyncHook func(ctx context.Context, syncContext SyncContext, result SyncResult) error
+
+// SyncPanicDegradedFunc reports the degraded state of a controller recovering the panics of its sync() calls.
+// It is called with the recovered panic converted to an error, and with nil after every sync() call that
+// didn't panic, so that the reported condition is cleared once the controller syncs again.
+type SyncPanicDegradedFunc func(ctx context.Context, panicErr error) error
+
 // ObjectQueueKeyFunc is used to make a string work queue key out of the runtime object that is passed to it.
 // This can extract the "namespace/name" if you need to or just return "key" if you building controller that only use string
 // triggers.
@@ -115,6 +151,27 @@ func (f *Factory) WithFilteredEventsInformers(filter EventFilterFunc, informers
 	return f
 }
 
+// WithLabelSelectorFilteredEventsInformers is like WithFilteredEventsInformers but only events of objects whose labels match
+// the given selector trigger the Sync() call. An update removing the matching labels from an object is observed as its deletion.
+// To avoid caching the objects that don't match, the informers should be constructed with the same label selector,
+// e.g. using informers.WithTweakListOptions.
+func (f *Factory) WithLabelSelectorFilteredEventsInformers(selector labels.Selector, informers ...Informer) *Factory {
+	return f.WithFilteredEventsInformers(LabelSelectorFilter(selector), informers...)
+}
+
+// WithDynamicInformers is like WithInformers for the informers of the given resources, watched through the dynamic client.
+// This allows to react to changes on resources without typed clients, e.g. custom resources. The informers must be
+// requested before the informerFactory is started, i.e. the controller must be built first.
+// To use a queue key function or an event filter, pass informerFactory.ForResource(gvr).Informer() to the other With*Informers
+// methods, the unstructured objects it observes implement metav1.Object.
+func (f *Factory) WithDynamicInformers(informerFactory dynamicinformer.DynamicSharedInformerFactory, gvrs ...schema.GroupVersionResource) *Factory {
+	informers := make([]Informer, 0, len(gvrs))
+	for _, gvr := range gvrs {
+		informers = append(informers, informerFactory.ForResource(gvr).Informer())
+	}
+	return f.WithInformers(informers...)
+}
+
 // WithBareInformers allow to register informer that already has custom event handlers registered and no additional
 // event handlers will be added to this informer.
 // The controller will wait for the cache of this informer to be synced.
@@ -187,6 +244,13 @@ func (f *Factory) WithPostStartHooks(hooks ...PostStartHook) *Factory {
 	return f
 }
 
+// WithPostSyncHooks allows to register functions that will run after every sync() call with its result.
+// The hooks run even when the sync() call fails or panics, which makes them suitable for bookkeeping like flushing metrics.
+func (f *Factory) WithPostSyncHooks(hooks ...PostSyncHook) *Factory {
+	f.postSyncHooks = append(f.postSyncHooks, hooks...)
+	return f
+}
+
 // WithNamespaceInformer is used to register event handlers and get the caches synchronized functions.
 // The sync function will only trigger when the object observed by this informer is a namespace and its name matches the interestingNamespaces.
 // Do not use this to register non-namespace informers.
@@ -225,6 +289,93 @@ func (f *Factory) ResyncSchedule(schedules ...string) *Factory {
 	return f
 }
 
+// WithClock allows to specify the clock driving the periodic resyncs requested via ResyncEvery.
+// This is useful during unit testing where a fake clock can be used to deterministically trigger the resyncs.
+// If this function is not called, the real clock is used.
+func (f *Factory) WithClock(clock clock.WithTicker) *Factory {
+	f.clock = clock
+	return f
+}
+
+// WithInitialSyncJitter delays the start of the workers, and therefore the first sync, by a random duration of up to
+// maxDelay once the caches are synced, so that the controllers of a process don't all hit the API server at once on startup.
+// The delay is measured by the clock set with WithClock.
+// If this function is not called, the workers start right after the caches are synced.
+func (f *Factory) WithInitialSyncJitter(maxDelay time.Duration) *Factory {
+	f.initialSyncJitter = maxDelay
+	return f
+}
+
+// WithLeadershipStatus makes the controller process its queue only while the given status reports
+// the leadership is held. When the leadership is lost the workers stop picking up new keys, a sync
+// that is already running is allowed to finish. Keys queued in the meantime are processed once the
+// leadership is regained.
+//
+// The status is usually updated from the OnStartedLeading and OnStoppedLeading leader election callbacks.
+// If this function is not called, the controller always processes its queue.
+func (f *Factory) WithLeadershipStatus(leadership *LeadershipStatus) *Factory {
+	f.leadership = leadership
+	return f
+}
+
+// WithSyncPanicRecovery makes the controller recover the panics of its sync() calls instead of crashing the process.
+// A recovered panic is logged with its stack trace, recorded as a Warning event and treated as a sync() error,
+// so the queue key is re-queued with backoff. If degradedFn is not nil, it is used to report the panic, usually
+// by setting a Degraded condition, see SyncPanicDegradedFunc.
+// If this function is not called, the panics are passed through.
+func (f *Factory) WithSyncPanicRecovery(degradedFn SyncPanicDegradedFunc) *Factory {
+	f.recoverSyncPanics = true
+	f.syncPanicDegradedFn = degradedFn
+	return f
+}
+
+// WithQueueDepthMetric registers a controller_queue_depth gauge reporting the QueueDepth of the controller
+// in the given registry, labelled with the controller name.
+// ToController panics if the gauge can't be registered, e.g. when two controllers share the same name.
+func (f *Factory) WithQueueDepthMetric(registry metrics.KubeRegistry) *Factory {
+	f.queueDepthRegistry = registry
+	return f
+}
+
+// WithEnqueueStalenessDetection tracks when the informers last queued every key, and reports the keys not queued for longer
+// than threshold as stale, see EnqueueStalenessChecker, e.g. to detect an informer that stopped receiving events.
+// Informers resync periodically, so the threshold must be longer than their resync period. The keys of deleted objects are forgotten.
+// When registry is not nil, a controller_stale_keys gauge labelled with the controller name reports the number of stale keys.
+// ToController panics if the gauge can't be registered, e.g. when two controllers share the same name.
+func (f *Factory) WithEnqueueStalenessDetection(threshold time.Duration, registry metrics.KubeRegistry) *Factory {
+	f.stalenessThreshold = threshold
+	f.stalenessRegistry = registry
+	return f
+}
+
+// WithWorkers makes the controller process its queue with n concurrent workers, regardless of the number
+// of workers passed to Run. The queue never hands the same key to two workers at once, so the syncs of a key
+// stay serialized while different keys are synced in parallel. The sync() function must be safe for concurrent use.
+// It panics if n is not positive.
+// If this function is not called, the number of workers passed to Run is used.
+func (f *Factory) WithWorkers(n int) *Factory {
+	if n < 1 {
+		panic(fmt.Errorf("WithWorkers() requires a positive number of workers, got %d", n))
+	}
+	f.workers = n
+	return f
+}
+
+// WithCRDPrecondition defers the start of the controller until the CustomResourceDefinition with the given name
+// is established, as observed by the given CRD informer and its lister. The informers of the custom resources can't sync
+// before, so startInformers is called once the CRD is established, e.g. to start them, and the controller then waits for
+// its caches to sync and starts syncing as usual. The caches sync timeout only starts once the CRD is established.
+// The CRD informer must be started by the caller.
+func (f *Factory) WithCRDPrecondition(crdName string, crdInformer Informer, crdLister apiextensionslistersv1.CustomResourceDefinitionLister, startInformers func(ctx context.Context)) *Factory {
+	f.crdPreconditions = append(f.crdPreconditions, crdPrecondition{
+		crdName:        crdName,
+		crdSynced:      crdInformer.HasSynced,
+		crdLister:      crdLister,
+		startInformers: startInformers,
+	})
+	return f
+}
+
 // WithSyncContext allows to specify custom, existing sync context for this factory.
 // This is useful during unit testing where you can override the default event recorder or mock the runtime objects.
 // If this function not called, a SyncContext is created by the factory automatically.
@@ -280,6 +431,20 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 		}
 	}
 
+	var controllerClock clock.WithTicker = clock.RealClock{}
+	if f.clock != nil {
+		controllerClock = f.clock
+	}
+
+	var tracker *enqueueTracker
+	if f.stalenessThreshold > 0 {
+		tracker = newEnqueueTracker(controllerClock, f.stalenessThreshold)
+		if trackedCtx, ok := ctx.(syncContext); ok {
+			trackedCtx.enqueueTracker = tracker
+			ctx = trackedCtx
+		}
+	}
+
 	c := &baseController{
 		name:                   name,
 		controllerInstanceName: f.controllerInstanceName,
@@ -290,7 +455,45 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 		cachesToSync:           append([]cache.InformerSynced{}, f.cachesToSync...),
 		syncContext:            ctx,
 		postStartHooks:         f.postStartHooks,
+		postSyncHooks:          f.postSyncHooks,
 		cacheSyncTimeout:       defaultCacheSyncTimeout,
+		clock:                  controllerClock,
+		leadership:             f.leadership,
+		recoverSyncPanics:      f.recoverSyncPanics,
+		syncPanicDegradedFn:    f.syncPanicDegradedFn,
+		workers:                f.workers,
+		crdPreconditions:       append([]crdPrecondition{}, f.crdPreconditions...),
+		enqueueTracker:         tracker,
+		initialSyncJitter:      f.initialSyncJitter,
+		queueKeysOnly:          len(f.informerQueueKeys) > 0 && len(f.informers) == 0 && len(f.namespaceInformers) == 0 && f.resyncInterval == 0 && len(cronSchedules) == 0,
+	}
+
+	if f.queueDepthRegistry != nil {
+		queueDepth := metrics.NewGaugeFunc(&metrics.GaugeOpts{
+			Name:           "controller_queue_depth",
+			Help:           "Number of keys queued and not picked up by a worker of the controller yet.",
+			ConstLabels:    map[string]string{"name": name},
+			StabilityLevel: metrics.ALPHA,
+		}, func() float64 {
+			return float64(c.QueueDepth())
+		})
+		if err := f.queueDepthRegistry.Registerer().Register(queueDepth); err != nil {
+			panic(fmt.Errorf("failed to register the queue depth metric of %q: %v", name, err))
+		}
+	}
+
+	if tracker != nil && f.stalenessRegistry != nil {
+		staleKeys := metrics.NewGaugeFunc(&metrics.GaugeOpts{
+			Name:           "controller_stale_keys",
+			Help:           "Number of keys the informers of the controller did not queue for longer than the staleness threshold.",
+			ConstLabels:    map[string]string{"name": name},
+			StabilityLevel: metrics.ALPHA,
+		}, func() float64 {
+			return float64(len(c.StaleKeys()))
+		})
+		if err := f.stalenessRegistry.Registerer().Register(staleKeys); err != nil {
+			panic(fmt.Errorf("failed to register the stale keys metric of %q: %v", name, err))
+		}
 	}
 
 	// avoid adding an informer more than once
@@ -305,7 +508,8 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 			}
 			if !informerQueueKeySet.Has(tuple) {
 				sets.Insert(informerQueueKeySet, tuple)
-				informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(queueKeyFn, f.informerQueueKeys[i].filter))
+				registration, err := informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(queueKeyFn, f.informerQueueKeys[i].filter))
+				c.addHandlerToSync(registration, err)
 			}
 			c.cachesToSync = append(c.cachesToSync, informer.HasSynced)
 		}
@@ -322,7 +526,8 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 			}
 			if !informerSet.Has(tuple) {
 				sets.Insert(informerSet, tuple)
-				informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.informers[i].filter))
+				registration, err := informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.informers[i].filter))
+				c.addHandlerToSync(registration, err)
 			}
 			c.cachesToSync = append(c.cachesToSync, informer.HasSynced)
 		}
@@ -333,7 +538,8 @@ func (f *Factory) ToController(name string, eventRecorder events.Recorder) Contr
 	}
 
 	for i := range f.namespaceInformers {
-		f.namespaceInformers[i].informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.namespaceInformers[i].nsFilter))
+		registration, err := f.namespaceInformers[i].informer.AddEventHandler(c.syncContext.(syncContext).eventHandler(DefaultQueueKeysFunc, f.namespaceInformers[i].nsFilter))
+		c.addHandlerToSync(registration, err)
 		c.cachesToSync = append(c.cachesToSync, f.namespaceInformers[i].informer.HasSynced)
 	}
 
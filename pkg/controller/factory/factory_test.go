@@ -4,17 +4,29 @@ import (
 	"context"
 	"fmt"
 	clocktesting "k8s.io/utils/clock/testing"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionslistersv1 "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/events/eventstesting"
@@ -96,6 +108,118 @@ func makeFakeSecret() *v1.Secret {
 	}
 }
 
+func TestFactory_QueueDepth(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	c := New().
+		WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+			return nil
+		}).
+		WithQueueDepthMetric(registry).
+		ToController("test", eventstesting.NewTestingEventRecorder(t))
+	b := c.(*baseController)
+
+	expectDepth := func(expected int) {
+		t.Helper()
+		if depth := c.(QueueDepthGetter).QueueDepth(); depth != expected {
+			t.Errorf("expected queue depth %d, got %d", expected, depth)
+		}
+		expectedMetric := fmt.Sprintf(`
+# HELP controller_queue_depth [ALPHA] Number of keys queued and not picked up by a worker of the controller yet.
+# TYPE controller_queue_depth gauge
+controller_queue_depth{name="test"} %d
+`, expected)
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expectedMetric), "controller_queue_depth"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	expectDepth(0)
+	b.syncContext.Queue().Add("a")
+	b.syncContext.Queue().Add("b")
+	b.syncContext.Queue().Add("a")
+	expectDepth(2)
+
+	b.processNextWorkItem(context.TODO())
+	expectDepth(1)
+	b.processNextWorkItem(context.TODO())
+	expectDepth(0)
+
+	// the metric name is shared, a second controller with the same name can't register it
+	func() {
+		defer func() {
+			if panicVal := recover(); panicVal == nil {
+				t.Error("expected a duplicate controller name to panic")
+			}
+		}()
+		New().WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+			return nil
+		}).WithQueueDepthMetric(registry).ToController("test", eventstesting.NewTestingEventRecorder(t))
+	}()
+}
+
+func TestFactory_WithEnqueueStalenessDetection(t *testing.T) {
+	registry := metrics.NewKubeRegistry()
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	informer := &fakeInformer{}
+	c := New().
+		WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+			return nil
+		}).
+		WithClock(fakeClock).
+		WithInformersQueueKeysFunc(func(obj runtime.Object) []string {
+			metaObj, _ := apimeta.Accessor(obj)
+			return []string{metaObj.GetName()}
+		}, informer).
+		WithEnqueueStalenessDetection(time.Minute, registry).
+		ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+	expectStale := func(expected ...string) {
+		t.Helper()
+		if actual := c.(EnqueueStalenessChecker).StaleKeys(); strings.Join(actual, ",") != strings.Join(expected, ",") {
+			t.Errorf("expected stale keys %v, got %v", expected, actual)
+		}
+		expectedMetric := fmt.Sprintf(`
+# HELP controller_stale_keys [ALPHA] Number of keys the informers of the controller did not queue for longer than the staleness threshold.
+# TYPE controller_stale_keys gauge
+controller_stale_keys{name="test"} %d
+`, len(expected))
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expectedMetric), "controller_stale_keys"); err != nil {
+			t.Error(err)
+		}
+	}
+
+	informer.eventHandler.OnAdd(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "a"}}, false)
+	informer.eventHandler.OnAdd(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "b"}}, false)
+	informer.eventHandler.OnAdd(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "c"}}, false)
+	expectStale()
+
+	// the manually queued keys don't count
+	fakeClock.Step(40 * time.Second)
+	informer.eventHandler.OnUpdate(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "a"}}, &v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "a"}})
+	c.(*baseController).syncContext.Queue().Add("b")
+	expectStale()
+
+	// the keys not queued by an informer within the window become stale, the keys of deleted objects are forgotten
+	fakeClock.Step(40 * time.Second)
+	informer.eventHandler.OnDelete(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "c"}})
+	expectStale("b")
+
+	fakeClock.Step(40 * time.Second)
+	expectStale("a", "b")
+
+	// a new event clears the staleness
+	informer.eventHandler.OnUpdate(&v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "b"}}, &v1.Secret{ObjectMeta: meta.ObjectMeta{Name: "b"}})
+	expectStale("a")
+
+	// the detection is disabled by default
+	disabled := New().WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		return nil
+	}).ToController("disabled", eventstesting.NewTestingEventRecorder(t))
+	if staleKeys := disabled.(EnqueueStalenessChecker).StaleKeys(); len(staleKeys) != 0 {
+		t.Errorf("expected no stale keys without the detection, got %v", staleKeys)
+	}
+}
+
 func TestResyncController(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	factory := New().ResyncEvery(100 * time.Millisecond)
@@ -122,6 +246,61 @@ func TestResyncController(t *testing.T) {
 	}
 }
 
+func TestResyncControllerWithFakeClock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncs := make(chan string, 10)
+	controller := New().ResyncEvery(5*time.Minute).WithClock(fakeClock).WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		syncs <- controllerContext.QueueKey()
+		return nil
+	}).ToController("PeriodicController", events.NewInMemoryRecorder("periodic-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	go controller.Run(ctx, 1)
+
+	waitForSync := func() {
+		t.Helper()
+		select {
+		case key := <-syncs:
+			if key != DefaultQueueKey {
+				t.Errorf("expected periodic sync to use the %q key, got %q", DefaultQueueKey, key)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a periodic sync")
+		}
+	}
+
+	// the first sync happens right away
+	waitForSync()
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+		return fakeClock.HasWaiters(), nil
+	}); err != nil {
+		t.Fatalf("the periodic resync never started waiting on the clock: %v", err)
+	}
+
+	// nothing happens until the interval elapses
+	fakeClock.Step(4 * time.Minute)
+	select {
+	case <-syncs:
+		t.Fatal("unexpected sync before the resync interval elapsed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	syncCount := 1
+	fakeClock.Step(time.Minute)
+	waitForSync()
+	syncCount++
+	for i := 0; i < 3; i++ {
+		fakeClock.Step(5 * time.Minute)
+		waitForSync()
+		syncCount++
+	}
+	if syncCount != 5 {
+		t.Errorf("expected 5 syncs, got %d", syncCount)
+	}
+}
+
 func TestMultiWorkerControllerShutdown(t *testing.T) {
 	controllerCtx, shutdown := context.WithCancel(context.TODO())
 	factory := New().ResyncEvery(10 * time.Minute) // make sure we only call 1 sync manually
@@ -174,6 +353,120 @@ func TestMultiWorkerControllerShutdown(t *testing.T) {
 	workersShutdownMutex.Unlock()
 }
 
+func TestFactory_WithInitialSyncJitter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	syncs := make(chan string, 10)
+	controller := New().WithInitialSyncJitter(time.Minute).WithClock(fakeClock).WithSync(func(ctx context.Context, controllerContext SyncContext) error {
+		syncs <- controllerContext.QueueKey()
+		return nil
+	}).ToController("JitteredController", events.NewInMemoryRecorder("jittered-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	controller.(*baseController).syncContext.Queue().Add("key")
+	go controller.Run(ctx, 1)
+
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+		return fakeClock.HasWaiters(), nil
+	}); err != nil {
+		t.Fatalf("the controller never started waiting on the clock: %v", err)
+	}
+	select {
+	case <-syncs:
+		t.Fatal("unexpected sync before the initial delay elapsed")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the delay never exceeds the jitter
+	fakeClock.Step(time.Minute)
+	select {
+	case key := <-syncs:
+		if key != "key" {
+			t.Errorf("expected the queued key to be synced, got %q", key)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the first sync")
+	}
+}
+
+func TestFactory_WithWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	var lock sync.Mutex
+	inFlight := map[string]int{}
+	maxInFlight := map[string]int{}
+	syncCount := map[string]int{}
+	started := make(chan string, 10)
+	release := make(chan struct{})
+	secondSyncOfA := make(chan struct{})
+
+	controller := New().WithWorkers(2).WithSync(func(ctx context.Context, syncContext SyncContext) error {
+		key := syncContext.QueueKey()
+		lock.Lock()
+		inFlight[key]++
+		if inFlight[key] > maxInFlight[key] {
+			maxInFlight[key] = inFlight[key]
+		}
+		syncCount[key]++
+		count := syncCount[key]
+		lock.Unlock()
+
+		started <- key
+		<-release
+
+		lock.Lock()
+		inFlight[key]--
+		lock.Unlock()
+		if key == "a" && count == 2 {
+			close(secondSyncOfA)
+		}
+		return nil
+	}).ToController("WorkersController", events.NewInMemoryRecorder("workers-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	// the number of workers passed to Run is overridden
+	go controller.Run(ctx, 1)
+
+	queue := controller.(*baseController).syncContext.Queue()
+	queue.Add("a")
+	waitForKey := func(expected string) {
+		select {
+		case key := <-started:
+			if key != expected {
+				t.Fatalf("expected the sync of %q to start, got %q", expected, key)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatalf("timed out waiting for the sync of %q to start", expected)
+		}
+	}
+	waitForKey("a")
+
+	// "a" is queued again while it is being synced, it must wait for the running sync to finish
+	queue.Add("a")
+	queue.Add("b")
+	waitForKey("b")
+
+	select {
+	case key := <-started:
+		t.Fatalf("expected no other sync to start while both workers are busy, got %q", key)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	select {
+	case <-secondSyncOfA:
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the second sync of \"a\"")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if maxInFlight["a"] != 1 || maxInFlight["b"] != 1 {
+		t.Errorf("expected the syncs of the same key to be serialized, got at most %v syncs in flight", maxInFlight)
+	}
+}
+
 func testControllerWithInformer(t *testing.T, once bool) {
 	kubeClient := fake.NewSimpleClientset()
 
@@ -323,3 +616,208 @@ func TestControllerWithQueueFunction(t *testing.T) {
 		t.Fatal("test timeout")
 	}
 }
+
+func TestWithTypedSync(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "test", Name: "existing"},
+		Data:       map[string]string{"foo": "existing"},
+	})
+	kubeInformers := informers.NewSharedInformerFactoryWithOptions(kubeClient, 10*time.Minute, informers.WithNamespace("test"))
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	type typedSync struct {
+		key       string
+		configMap *v1.ConfigMap
+	}
+	synced := make(chan typedSync, 10)
+	controller := WithTypedSync(New(), kubeInformers.Core().V1().ConfigMaps().Informer(), func(ctx context.Context, syncCtx SyncContext, configMap *v1.ConfigMap) error {
+		synced <- typedSync{key: syncCtx.QueueKey(), configMap: configMap}
+		return nil
+	}).ToController("FakeController", events.NewInMemoryRecorder("fake-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	go kubeInformers.Start(ctx.Done())
+	go controller.Run(ctx, 1)
+
+	expectSync := func(expectedKey, expectedData string) {
+		t.Helper()
+		for {
+			select {
+			case s := <-synced:
+				// the informer events of the initial list may sync the existing objects once more
+				if s.key != expectedKey {
+					continue
+				}
+				if len(expectedData) == 0 {
+					if s.configMap != nil {
+						t.Fatalf("expected no configmap for %q, got %#v", s.key, s.configMap)
+					}
+					return
+				}
+				if s.configMap == nil || s.configMap.Data["foo"] != expectedData {
+					t.Fatalf("expected configmap with %q for %q, got %#v", expectedData, s.key, s.configMap)
+				}
+				return
+			case <-time.After(30 * time.Second):
+				t.Fatal("test timeout")
+			}
+		}
+	}
+
+	// the initial sync goes through all the cached objects
+	expectSync("test/existing", "existing")
+
+	if _, err := kubeClient.CoreV1().ConfigMaps("test").Create(ctx, &v1.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{Namespace: "test", Name: "new"},
+		Data:       map[string]string{"foo": "new"},
+	}, meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectSync("test/new", "new")
+
+	if err := kubeClient.CoreV1().ConfigMaps("test").Delete(ctx, "existing", meta.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectSync("test/existing", "")
+}
+
+func TestFactory_WithCRDPrecondition(t *testing.T) {
+	defer func(interval time.Duration) { crdPreconditionPollInterval = interval }(crdPreconditionPollInterval)
+	crdPreconditionPollInterval = 10 * time.Millisecond
+
+	crdIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	var informersStarted, synced int32
+	var lock sync.Mutex
+	counts := func() (int32, int32) {
+		lock.Lock()
+		defer lock.Unlock()
+		return informersStarted, synced
+	}
+
+	// the resync queues a sync right away once the controller started
+	controller := New().
+		ResyncEvery(time.Minute).
+		WithInformers(&fakeInformer{}).
+		WithCRDPrecondition("foos.example.com", &fakeInformer{}, apiextensionslistersv1.NewCustomResourceDefinitionLister(crdIndexer), func(ctx context.Context) {
+			lock.Lock()
+			defer lock.Unlock()
+			informersStarted++
+		}).
+		WithSync(func(ctx context.Context, syncContext SyncContext) error {
+			lock.Lock()
+			defer lock.Unlock()
+			synced++
+			return nil
+		}).
+		ToController("test", eventstesting.NewTestingEventRecorder(t))
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go controller.Run(ctx, 1)
+
+	expectNotStarted := func() {
+		t.Helper()
+		time.Sleep(200 * time.Millisecond)
+		if started, synced := counts(); started != 0 || synced != 0 {
+			t.Fatalf("expected the controller to wait for the CRD, got %d informer starts and %d syncs", started, synced)
+		}
+	}
+
+	// the CRD doesn't exist
+	expectNotStarted()
+
+	// the CRD exists but is not established yet
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: meta.ObjectMeta{Name: "foos.example.com"},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.NamesAccepted, Status: apiextensionsv1.ConditionTrue},
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionFalse},
+			},
+		},
+	}
+	if err := crdIndexer.Add(crd); err != nil {
+		t.Fatal(err)
+	}
+	expectNotStarted()
+
+	// the controller starts once the CRD is established
+	crd = crd.DeepCopy()
+	crd.Status.Conditions[1].Status = apiextensionsv1.ConditionTrue
+	if err := crdIndexer.Update(crd); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+		_, synced := counts()
+		return synced > 0, nil
+	}); err != nil {
+		t.Fatalf("expected the controller to sync once the CRD is established: %v", err)
+	}
+	if started, _ := counts(); started != 1 {
+		t.Errorf("expected the informers to be started once, got %d", started)
+	}
+}
+
+func TestFactory_WithDynamicInformers(t *testing.T) {
+	widgetsGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	newWidget := func(name string) *unstructured.Unstructured {
+		widget := &unstructured.Unstructured{}
+		widget.SetAPIVersion("example.com/v1")
+		widget.SetKind("Widget")
+		widget.SetNamespace("test")
+		widget.SetName(name)
+		return widget
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{widgetsGVR: "WidgetList"}, newWidget("existing"))
+	dynamicInformers := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 10*time.Minute)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	synced := make(chan []string, 10)
+	controller := New().
+		WithDynamicInformers(dynamicInformers, widgetsGVR).
+		WithSync(func(ctx context.Context, syncCtx SyncContext) error {
+			widgets, err := dynamicInformers.ForResource(widgetsGVR).Lister().List(labels.Everything())
+			if err != nil {
+				return err
+			}
+			var names []string
+			for _, widget := range widgets {
+				names = append(names, widget.(*unstructured.Unstructured).GetName())
+			}
+			sort.Strings(names)
+			synced <- names
+			return nil
+		}).ToController("FakeController", events.NewInMemoryRecorder("fake-controller", clocktesting.NewFakePassiveClock(time.Now())))
+
+	// the informers are requested when the controller is built, before the informer factory is started
+	dynamicInformers.Start(ctx.Done())
+	go controller.Run(ctx, 1)
+
+	expectSync := func(expectedNames ...string) {
+		t.Helper()
+		for {
+			select {
+			case names := <-synced:
+				// the informer events of the initial list may sync once more
+				if strings.Join(names, ",") == strings.Join(expectedNames, ",") {
+					return
+				}
+			case <-time.After(30 * time.Second):
+				t.Fatalf("test timeout waiting for a sync with widgets %v", expectedNames)
+			}
+		}
+	}
+
+	expectSync("existing")
+
+	if _, err := dynamicClient.Resource(widgetsGVR).Namespace("test").Create(ctx, newWidget("new"), meta.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectSync("existing", "new")
+
+	if err := dynamicClient.Resource(widgetsGVR).Namespace("test").Delete(ctx, "existing", meta.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectSync("new")
+}
@@ -27,6 +27,32 @@ type Controller interface {
 	Name() string
 }
 
+// OnceRunner is implemented by the controllers produced by the Factory and allows them to be run to completion,
+// e.g. in CLI commands that want to reconcile once and exit rather than run continuously.
+type OnceRunner interface {
+	// RunOnce waits for the caches to sync and for the event handlers of the informers to queue the keys of
+	// the cached objects, then syncs every key queued at that point exactly once and returns the aggregated
+	// sync errors. If no key is queued, the DefaultQueueKey is synced, unless the controller only queues keys
+	// with the queue keys funcs of its informers. Failed keys are not retried.
+	RunOnce(ctx context.Context) error
+}
+
+// QueueDepthGetter is implemented by the controllers produced by the Factory and exposes the backlog of their queue,
+// e.g. to alert when a controller doesn't keep up with its events.
+type QueueDepthGetter interface {
+	// QueueDepth returns the number of keys queued and not picked up by a worker yet.
+	// Keys that are being synced or waiting for their rate limited requeue are not counted.
+	QueueDepth() int
+}
+
+// EnqueueStalenessChecker is implemented by the controllers produced by the Factory and reports the keys the informers
+// stopped queueing, e.g. to detect an informer that doesn't receive events anymore. See Factory.WithEnqueueStalenessDetection.
+type EnqueueStalenessChecker interface {
+	// StaleKeys returns the sorted keys last queued by an informer longer than the staleness threshold ago.
+	// It returns nothing when the staleness detection is not enabled.
+	StaleKeys() []string
+}
+
 // SyncContext interface represents a context given to the Sync() function where the main controller logic happen.
 // SyncContext exposes controller name and give user access to the queue (for manual requeue).
 // SyncContext also provides metadata about object that informers observed as changed.
@@ -0,0 +1,64 @@
+package factory
+
+import (
+	"context"
+	"sync"
+)
+
+// LeadershipStatus tracks whether the current process holds the leader lease.
+// It is meant to be updated from the leader election callbacks and shared by all
+// controllers that must not sync while another instance is leading.
+//
+// The zero value is not usable, use NewLeadershipStatus.
+type LeadershipStatus struct {
+	lock sync.Mutex
+	// leadingCh is closed while leading and replaced by an open channel when the leadership is lost.
+	leadingCh chan struct{}
+	leading   bool
+}
+
+// NewLeadershipStatus returns a LeadershipStatus with the given initial state.
+func NewLeadershipStatus(leading bool) *LeadershipStatus {
+	s := &LeadershipStatus{leadingCh: make(chan struct{})}
+	s.SetLeading(leading)
+	return s
+}
+
+// SetLeading records whether the leadership is held. Controllers using this status
+// pause their queue processing when it is set to false and resume when it is set back to true.
+func (s *LeadershipStatus) SetLeading(leading bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.leading == leading {
+		return
+	}
+	s.leading = leading
+	if leading {
+		close(s.leadingCh)
+	} else {
+		s.leadingCh = make(chan struct{})
+	}
+}
+
+// IsLeading returns true when the leadership is held.
+func (s *LeadershipStatus) IsLeading() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.leading
+}
+
+// waitForLeadership blocks until the leadership is held or the context is cancelled.
+// It returns false when the context was cancelled first.
+func (s *LeadershipStatus) waitForLeadership(ctx context.Context) bool {
+	s.lock.Lock()
+	leadingCh := s.leadingCh
+	s.lock.Unlock()
+
+	select {
+	case <-leadingCh:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
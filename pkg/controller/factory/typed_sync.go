@@ -0,0 +1,98 @@
+package factory
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TypedSyncFunc is a SyncFunc that receives the object referred to by the synced queue key.
+// The object is the zero value of T (nil for pointer types) when the object is not in the informer cache,
+// e.g. because it was deleted. Its queue key is still available via syncCtx.QueueKey().
+type TypedSyncFunc[T runtime.Object] func(ctx context.Context, syncCtx SyncContext, obj T) error
+
+// TypedInformer is an Informer giving access to the cache of the objects it observed.
+// Any SharedInformer will comply.
+type TypedInformer interface {
+	Informer
+	GetStore() cache.Store
+}
+
+// WithTypedSync sets the sync function of the factory to syncFn and registers the informer, queueing the
+// "namespace/name" key of every object it observes. The object referred to by the synced queue key is read
+// from the informer cache and passed to syncFn, so that it doesn't have to be fetched from a lister.
+// The DefaultQueueKey, queued on resyncs and on the controller start, syncs every object in the informer cache.
+//
+// WithTypedSync is a function rather than a Factory method, because methods can't have type parameters.
+func WithTypedSync[T runtime.Object](f *Factory, informer TypedInformer, syncFn TypedSyncFunc[T]) *Factory {
+	return f.
+		WithInformersQueueKeysFunc(metaNamespaceQueueKeys, informer).
+		WithSync(typedSync(informer.GetStore(), syncFn))
+}
+
+func metaNamespaceQueueKeys(obj runtime.Object) []string {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil
+	}
+	return []string{key}
+}
+
+func typedSync[T runtime.Object](store cache.Store, syncFn TypedSyncFunc[T]) SyncFunc {
+	return func(ctx context.Context, syncCtx SyncContext) error {
+		if syncCtx.QueueKey() != DefaultQueueKey {
+			obj, exists, err := store.GetByKey(syncCtx.QueueKey())
+			if err != nil {
+				return err
+			}
+			if !exists {
+				var zero T
+				return syncFn(ctx, syncCtx, zero)
+			}
+			typedObj, err := toTyped[T](obj)
+			if err != nil {
+				return err
+			}
+			return syncFn(ctx, syncCtx, typedObj)
+		}
+
+		var errs []error
+		for _, obj := range store.List() {
+			typedObj, err := toTyped[T](obj)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			key, err := cache.MetaNamespaceKeyFunc(typedObj)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := syncFn(ctx, keyedSyncContext{SyncContext: syncCtx, queueKey: key}, typedObj); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errorutil.NewAggregate(errs)
+	}
+}
+
+func toTyped[T runtime.Object](obj interface{}) (T, error) {
+	typedObj, ok := obj.(T)
+	if !ok {
+		return typedObj, fmt.Errorf("unexpected object of type %T in the informer cache", obj)
+	}
+	return typedObj, nil
+}
+
+// keyedSyncContext is a SyncContext reporting the key of the object synced during a resync.
+type keyedSyncContext struct {
+	SyncContext
+	queueKey string
+}
+
+func (c keyedSyncContext) QueueKey() string {
+	return c.queueKey
+}
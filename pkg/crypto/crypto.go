@@ -10,6 +10,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -30,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/client-go/util/cert"
+	"k8s.io/client-go/util/keyutil"
 )
 
 // TLS versions that are known to golang. Go 1.13 adds support for
@@ -535,6 +537,29 @@ func (s *RandomSerialGenerator) Next(template *x509.Certificate) (int64, error)
 	return randomSerialNumber(), nil
 }
 
+// CounterSerialGenerator returns monotonically increasing serial numbers kept in memory.
+// Unlike a RandomSerialGenerator, it makes the serial numbers reproducible, which is meant for
+// test fixtures only: a serial number must not be reused by a CA that outlives the process.
+type CounterSerialGenerator struct {
+	// lock guards access to the Serial field
+	lock   sync.Mutex
+	Serial int64
+}
+
+// NewCounterSerialGenerator returns a generator whose first serial number is the one following last.
+func NewCounterSerialGenerator(last int64) *CounterSerialGenerator {
+	return &CounterSerialGenerator{Serial: last}
+}
+
+// Next returns the serial number following the previously returned one.
+func (s *CounterSerialGenerator) Next(template *x509.Certificate) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.Serial++
+	return s.Serial, nil
+}
+
 // randomSerialNumber returns a random int64 serial number based on
 // time.Now. It is defined separately from the generator interface so
 // that the caller doesn't have to worry about an input template or
@@ -589,6 +614,52 @@ func GetCAFromBytes(certBytes, keyBytes []byte) (*CA, error) {
 	}, nil
 }
 
+// LoadCA reads an existing CA keypair from the given files so that it can be used for signing.
+// It fails unless the first certificate is a CA certificate and the key matches it.
+// A RandomSerialGenerator is used.
+func LoadCA(certPath, keyPath string) (*CA, error) {
+	if len(certPath) == 0 {
+		return nil, errors.New("certPath missing")
+	}
+	if len(keyPath) == 0 {
+		return nil, errors.New("keyPath missing")
+	}
+
+	certPEMBlock, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA certificate: %w", err)
+	}
+	certs, err := cert.ParseCertsPEM(certPEMBlock)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CA certificate %s: %w", certPath, err)
+	}
+	if !certs[0].IsCA {
+		return nil, fmt.Errorf("certificate %s is not a CA certificate", certPath)
+	}
+
+	keyPEMBlock, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read CA key: %w", err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEMBlock)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CA key %s: %w", keyPath, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA key %s of type %T can't be used for signing", keyPath, key)
+	}
+	publicKey, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !publicKey.Equal(certs[0].PublicKey) {
+		return nil, fmt.Errorf("CA key %s doesn't match the certificate %s", keyPath, certPath)
+	}
+
+	return &CA{
+		SerialGenerator: &RandomSerialGenerator{},
+		Config:          &TLSCertificateConfig{Certs: certs, Key: key},
+	}, nil
+}
+
 // if serialFile is empty, a RandomSerialGenerator will be used
 func MakeSelfSignedCA(certFile, keyFile, serialFile, name string, lifetime time.Duration) (*CA, error) {
 	klog.V(2).Infof("Generating new CA for %s cert, and key in %s, %s", name, certFile, keyFile)
@@ -635,20 +706,29 @@ func MakeSelfSignedCAConfigForSubject(subject pkix.Name, lifetime time.Duration)
 	if lifetime > DefaultCACertificateLifetimeDuration {
 		warnAboutCertificateLifeTime(subject.CommonName, DefaultCACertificateLifetimeDuration)
 	}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, lifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, lifetime, nil)
 }
 
 func MakeSelfSignedCAConfigForDuration(name string, caLifetime time.Duration) (*TLSCertificateConfig, error) {
 	subject := pkix.Name{CommonName: name}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, caLifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, time.Now, caLifetime, nil)
 }
 
 func UnsafeMakeSelfSignedCAConfigForDurationAtTime(name string, currentTime func() time.Time, caLifetime time.Duration) (*TLSCertificateConfig, error) {
 	subject := pkix.Name{CommonName: name}
-	return makeSelfSignedCAConfigForSubjectAndDuration(subject, currentTime, caLifetime)
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, currentTime, caLifetime, nil)
+}
+
+// UnsafeMakeSelfSignedCAConfigWithSerialGenerator is like UnsafeMakeSelfSignedCAConfigForDurationAtTime but the serial
+// number of the CA certificate is taken from the given generator rather than picked randomly, e.g. a CounterSerialGenerator
+// to get reproducible test fixtures. It must not be used outside of tests, see newSigningCertificateTemplateForDuration.
+func UnsafeMakeSelfSignedCAConfigWithSerialGenerator(name string, currentTime func() time.Time, caLifetime time.Duration, serialGenerator SerialGenerator) (*TLSCertificateConfig, error) {
+	subject := pkix.Name{CommonName: name}
+	return makeSelfSignedCAConfigForSubjectAndDuration(subject, currentTime, caLifetime, serialGenerator)
 }
 
-func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime func() time.Time, caLifetime time.Duration) (*TLSCertificateConfig, error) {
+// makeSelfSignedCAConfigForSubjectAndDuration uses a random serial number unless a serialGenerator is given.
+func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime func() time.Time, caLifetime time.Duration, serialGenerator SerialGenerator) (*TLSCertificateConfig, error) {
 	// Create CA cert
 	rootcaPublicKey, rootcaPrivateKey, publicKeyHash, err := newKeyPairWithHash()
 	if err != nil {
@@ -658,6 +738,13 @@ func makeSelfSignedCAConfigForSubjectAndDuration(subject pkix.Name, currentTime
 	authorityKeyId := publicKeyHash
 	subjectKeyId := publicKeyHash
 	rootcaTemplate := newSigningCertificateTemplateForDuration(subject, caLifetime, currentTime, authorityKeyId, subjectKeyId)
+	if serialGenerator != nil {
+		serial, err := serialGenerator.Next(rootcaTemplate)
+		if err != nil {
+			return nil, err
+		}
+		rootcaTemplate.SerialNumber = big.NewInt(serial)
+	}
 	rootcaCert, err := signCertificate(rootcaTemplate, rootcaPublicKey, rootcaTemplate, rootcaPrivateKey)
 	if err != nil {
 		return nil, err
@@ -784,6 +871,19 @@ func (ca *CA) MakeAndWriteServerCert(certFile, keyFile string, hostnames sets.Se
 // if the extension attempt failed.
 type CertificateExtensionFunc func(*x509.Certificate) error
 
+// WithExtKeyUsages returns a CertificateExtensionFunc replacing the extended key usages of the certificate with the given
+// ones, plus the given custom usages identified by their OID, e.g. to issue a client certificate also valid for serving.
+func WithExtKeyUsages(usages []x509.ExtKeyUsage, customUsages ...asn1.ObjectIdentifier) CertificateExtensionFunc {
+	return func(cert *x509.Certificate) error {
+		if len(usages) == 0 && len(customUsages) == 0 {
+			return fmt.Errorf("at least one extended key usage is required")
+		}
+		cert.ExtKeyUsage = append([]x509.ExtKeyUsage{}, usages...)
+		cert.UnknownExtKeyUsage = append([]asn1.ObjectIdentifier{}, customUsages...)
+		return nil
+	}
+}
+
 func (ca *CA) MakeServerCert(hostnames sets.Set[string], lifetime time.Duration, fns ...CertificateExtensionFunc) (*TLSCertificateConfig, error) {
 	serverPublicKey, serverPrivateKey, publicKeyHash, _ := newKeyPairWithHash()
 	authorityKeyId := ca.Config.Certs[0].SubjectKeyId
@@ -894,9 +994,16 @@ func (ca *CA) MakeClientCertificate(certFile, keyFile string, u user.Info, lifet
 	return GetTLSCertificateConfig(certFile, keyFile)
 }
 
-func (ca *CA) MakeClientCertificateForDuration(u user.Info, lifetime time.Duration) (*TLSCertificateConfig, error) {
+// MakeClientCertificateForDuration issues a client certificate for the given user. The certificate is only valid
+// for client authentication unless fns change its extended key usages, e.g. WithExtKeyUsages.
+func (ca *CA) MakeClientCertificateForDuration(u user.Info, lifetime time.Duration, fns ...CertificateExtensionFunc) (*TLSCertificateConfig, error) {
 	clientPublicKey, clientPrivateKey, _ := NewKeyPair()
 	clientTemplate := NewClientCertificateTemplateForDuration(UserToSubject(u), lifetime, time.Now)
+	for _, fn := range fns {
+		if err := fn(clientTemplate); err != nil {
+			return nil, err
+		}
+	}
 	clientCrt, err := ca.SignCertificate(clientTemplate, clientPublicKey)
 	if err != nil {
 		return nil, err
@@ -1101,6 +1208,95 @@ func CertsFromPEM(pemCerts []byte) ([]*x509.Certificate, error) {
 	return certs, nil
 }
 
+// MergeCABundles returns a PEM bundle of all the certificates of the given PEM bundles, so that it can be used as
+// a trust bundle for all of them. Certificates present in several bundles are only included once and the certificates
+// are sorted by subject, then by the start of their validity, so that the result doesn't depend on the order of the bundles.
+// An error is returned for a bundle with malformed PEM data, a block that is not a certificate or a certificate that
+// can't be parsed.
+func MergeCABundles(bundles ...[]byte) ([]byte, error) {
+	seen := sets.New[string]()
+	certs := []*x509.Certificate{}
+	for i, bundle := range bundles {
+		rest := bundle
+		for len(bytes.TrimSpace(rest)) > 0 {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				return nil, fmt.Errorf("bundle %d: malformed PEM data", i)
+			}
+			if block.Type != "CERTIFICATE" {
+				return nil, fmt.Errorf("bundle %d: unexpected PEM block of type %q", i, block.Type)
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %d: %w", i, err)
+			}
+			if seen.Has(string(cert.Raw)) {
+				continue
+			}
+			seen.Insert(string(cert.Raw))
+			certs = append(certs, cert)
+		}
+	}
+
+	sort.SliceStable(certs, func(i, j int) bool {
+		if subjectI, subjectJ := certs[i].Subject.String(), certs[j].Subject.String(); subjectI != subjectJ {
+			return subjectI < subjectJ
+		}
+		if !certs[i].NotBefore.Equal(certs[j].NotBefore) {
+			return certs[i].NotBefore.Before(certs[j].NotBefore)
+		}
+		return bytes.Compare(certs[i].Raw, certs[j].Raw) < 0
+	})
+	return EncodeCertificates(certs...)
+}
+
+// SignatureAlgorithmWarnings returns a warning for every certificate of the given PEM bundle which is signed with
+// a deprecated algorithm based on SHA-1 or MD5, e.g. to warn about externally provided certificates that clients
+// may reject. A bundle that can't be parsed yields a single warning saying so.
+func SignatureAlgorithmWarnings(certPEM []byte) []string {
+	certs, err := CertsFromPEM(certPEM)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to check the signature algorithms of the certificates: %v", err)}
+	}
+
+	var warnings []string
+	for i, cert := range certs {
+		switch cert.SignatureAlgorithm {
+		case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+			warnings = append(warnings, fmt.Sprintf("certificate %d %q is signed with the deprecated signature algorithm %s", i, cert.Subject.String(), cert.SignatureAlgorithm))
+		}
+	}
+	return warnings
+}
+
+// KeyMatchesCert returns an error unless the given PEM encoded RSA or ECDSA private key is the key of the first
+// certificate of the given PEM bundle, e.g. to check a key pair before writing it into a TLS secret.
+func KeyMatchesCert(keyPEM, certPEM []byte) error {
+	certs, err := CertsFromPEM(certPEM)
+	if err != nil {
+		return fmt.Errorf("unable to parse the certificate: %w", err)
+	}
+	key, err := keyutil.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return fmt.Errorf("unable to parse the private key: %w", err)
+	}
+
+	var matches bool
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		matches = key.PublicKey.Equal(certs[0].PublicKey)
+	case *ecdsa.PrivateKey:
+		matches = key.PublicKey.Equal(certs[0].PublicKey)
+	default:
+		return fmt.Errorf("unsupported private key type %T", key)
+	}
+	if !matches {
+		return fmt.Errorf("the private key doesn't match the public key of the certificate %q", certs[0].Subject.String())
+	}
+	return nil
+}
+
 // Can be used as a certificate in http.Transport TLSClientConfig
 func NewClientCertificateTemplate(subject pkix.Name, lifetime time.Duration, currentTime func() time.Time) *x509.Certificate {
 	if lifetime <= 0 {
@@ -1139,6 +1335,15 @@ func warnAboutCertificateLifeTime(name string, defaultLifetimeDuration time.Dura
 }
 
 func signCertificate(template *x509.Certificate, requestKey crypto.PublicKey, issuer *x509.Certificate, issuerKey crypto.PrivateKey) (*x509.Certificate, error) {
+	if err := validateFIPSSignatureAlgorithm(template.SignatureAlgorithm); err != nil {
+		return nil, err
+	}
+	if err := validateFIPSPublicKey(requestKey); err != nil {
+		return nil, err
+	}
+	if err := validateFIPSPrivateKey(issuerKey); err != nil {
+		return nil, fmt.Errorf("issuer key: %w", err)
+	}
 	derBytes, err := x509.CreateCertificate(rand.Reader, template, issuer, requestKey, issuerKey)
 	if err != nil {
 		return nil, err
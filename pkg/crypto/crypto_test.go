@@ -1,11 +1,18 @@
 package crypto
 
 import (
+	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
 	"fmt"
 	"go/importer"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sort"
@@ -241,6 +248,147 @@ func TestRandomSerialGenerator(t *testing.T) {
 	}
 }
 
+func TestCounterSerialGenerator(t *testing.T) {
+	fixedTime := func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	// every run issues the same serials in the same order
+	for run := 0; run < 2; run++ {
+		generator := NewCounterSerialGenerator(41)
+		caConfig, err := UnsafeMakeSelfSignedCAConfigWithSerialGenerator("test-ca", fixedTime, certificateLifetime, generator)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if serial := caConfig.Certs[0].SerialNumber.Int64(); serial != 42 {
+			t.Errorf("expected the CA serial 42, got %d", serial)
+		}
+
+		ca := &CA{Config: caConfig, SerialGenerator: generator}
+		serverConfig, err := ca.MakeServerCert(sets.New("foo"), certificateLifetime)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if serial := serverConfig.Certs[0].SerialNumber.Int64(); serial != 43 {
+			t.Errorf("expected the server serial 43, got %d", serial)
+		}
+		clientConfig, err := ca.MakeClientCertificateForDuration(&user.DefaultInfo{Name: "bar"}, certificateLifetime)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if serial := clientConfig.Certs[0].SerialNumber.Int64(); serial != 44 {
+			t.Errorf("expected the client serial 44, got %d", serial)
+		}
+	}
+}
+
+func TestMakeClientCertificateExtKeyUsages(t *testing.T) {
+	caConfig, err := MakeSelfSignedCAConfig("ca", certificateLifetime)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+	customUsage := asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+
+	scenarios := []struct {
+		name                  string
+		fns                   []CertificateExtensionFunc
+		expectedUsages        []x509.ExtKeyUsage
+		expectedUnknownUsages []asn1.ObjectIdentifier
+		expectedError         string
+	}{
+		{
+			name:           "client authentication by default",
+			expectedUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		},
+		{
+			name:           "client and server authentication",
+			fns:            []CertificateExtensionFunc{WithExtKeyUsages([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth})},
+			expectedUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		},
+		{
+			name:                  "custom usage",
+			fns:                   []CertificateExtensionFunc{WithExtKeyUsages([]x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, customUsage)},
+			expectedUsages:        []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			expectedUnknownUsages: []asn1.ObjectIdentifier{customUsage},
+		},
+		{
+			name:          "no usage",
+			fns:           []CertificateExtensionFunc{WithExtKeyUsages(nil)},
+			expectedError: "at least one extended key usage is required",
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			clientConfig, err := ca.MakeClientCertificateForDuration(&user.DefaultInfo{Name: "bar"}, certificateLifetime, scenario.fns...)
+			if len(scenario.expectedError) > 0 {
+				require.EqualError(t, err, scenario.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			// the usages must survive the encoding of the issued certificate
+			certPEM, _, err := clientConfig.GetPEMBytes()
+			require.NoError(t, err)
+			certs, err := CertsFromPEM(certPEM)
+			require.NoError(t, err)
+			require.Equal(t, scenario.expectedUsages, certs[0].ExtKeyUsage)
+			require.Equal(t, scenario.expectedUnknownUsages, certs[0].UnknownExtKeyUsage)
+		})
+	}
+}
+
+func TestMergeCABundles(t *testing.T) {
+	newCA := func(name string) []byte {
+		caConfig, err := MakeSelfSignedCAConfig(name, certificateLifetime)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		caPEM, _, err := caConfig.GetPEMBytes()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return caPEM
+	}
+	a, b, c := newCA("a"), newCA("b"), newCA("c")
+	concat := func(bundles ...[]byte) []byte {
+		return bytes.Join(bundles, []byte("\n"))
+	}
+
+	merged, err := MergeCABundles(concat(b, a), concat(c, b), nil, a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certs, err := CertsFromPEM(merged)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, cert := range certs {
+		names = append(names, cert.Subject.CommonName)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("expected the deduplicated certificates a,b,c, got %v", names)
+	}
+
+	reordered, err := MergeCABundles(c, concat(a, b))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(merged, reordered) {
+		t.Errorf("expected the same bundle regardless of the order of the input")
+	}
+
+	for name, malformed := range map[string][]byte{
+		"truncated":       a[:len(a)-20],
+		"garbage":         concat(a, []byte("garbage")),
+		"not certificate": concat(a, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("key")})),
+		"invalid":         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("cert")}),
+	} {
+		if _, err := MergeCABundles(b, malformed); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
 func TestValidityPeriodOfClientCertificate(t *testing.T) {
 	currentTime := time.Now()
 
@@ -549,3 +697,232 @@ func TestServerCertRegeneration(t *testing.T) {
 	require.NotNil(t, serverCert)
 	require.True(t, created)
 }
+
+func TestLoadCA(t *testing.T) {
+	testDir := t.TempDir()
+	certFile := filepath.Join(testDir, "ca.crt")
+	keyFile := filepath.Join(testDir, "ca.key")
+	otherCertFile := filepath.Join(testDir, "other.crt")
+	otherKeyFile := filepath.Join(testDir, "other.key")
+	serverCertFile := filepath.Join(testDir, "server.crt")
+	serverKeyFile := filepath.Join(testDir, "server.key")
+
+	ca, err := MakeSelfSignedCA(certFile, keyFile, "", "testca", DefaultCACertificateLifetimeDuration)
+	require.NoError(t, err)
+	_, err = MakeSelfSignedCA(otherCertFile, otherKeyFile, "", "otherca", DefaultCACertificateLifetimeDuration)
+	require.NoError(t, err)
+	_, err = ca.MakeAndWriteServerCert(serverCertFile, serverKeyFile, sets.New("myserver.local"), DefaultCertificateLifetimeDuration)
+	require.NoError(t, err)
+
+	t.Run("matching keypair", func(t *testing.T) {
+		loaded, err := LoadCA(certFile, keyFile)
+		require.NoError(t, err)
+		require.Equal(t, ca.Config.Certs[0].Raw, loaded.Config.Certs[0].Raw)
+
+		serverCert, err := loaded.MakeServerCert(sets.New("myserver.local"), DefaultCertificateLifetimeDuration)
+		require.NoError(t, err)
+		roots := x509.NewCertPool()
+		roots.AddCert(ca.Config.Certs[0])
+		_, err = serverCert.Certs[0].Verify(x509.VerifyOptions{Roots: roots, DNSName: "myserver.local"})
+		require.NoError(t, err)
+	})
+
+	scenarios := []struct {
+		name          string
+		certFile      string
+		keyFile       string
+		expectedError string
+	}{
+		{
+			name:          "mismatched keypair",
+			certFile:      certFile,
+			keyFile:       otherKeyFile,
+			expectedError: fmt.Sprintf("CA key %s doesn't match the certificate %s", otherKeyFile, certFile),
+		},
+		{
+			name:          "not a CA certificate",
+			certFile:      serverCertFile,
+			keyFile:       serverKeyFile,
+			expectedError: fmt.Sprintf("certificate %s is not a CA certificate", serverCertFile),
+		},
+		{
+			name:          "unreadable certificate",
+			certFile:      filepath.Join(testDir, "missing.crt"),
+			keyFile:       keyFile,
+			expectedError: "unable to read CA certificate",
+		},
+		{
+			name:          "unreadable key",
+			certFile:      certFile,
+			keyFile:       filepath.Join(testDir, "missing.key"),
+			expectedError: "unable to read CA key",
+		},
+		{
+			name:          "key is not a key",
+			certFile:      certFile,
+			keyFile:       otherCertFile,
+			expectedError: fmt.Sprintf("unable to parse CA key %s", otherCertFile),
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			_, err := LoadCA(scenario.certFile, scenario.keyFile)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), scenario.expectedError)
+		})
+	}
+}
+
+func TestSignatureAlgorithmWarnings(t *testing.T) {
+	readFile := func(path string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	scenarios := []struct {
+		name             string
+		certPEM          []byte
+		expectedWarnings []string
+	}{
+		{
+			name:    "SHA-256 signed certificate",
+			certPEM: readFile("./testfiles/tls.crt"),
+		},
+		{
+			name:    "SHA-1 signed certificate",
+			certPEM: readFile("./testfiles/tls-sha1.crt"),
+			expectedWarnings: []string{
+				`certificate 0 "CN=sha1-signed" is signed with the deprecated signature algorithm SHA1-RSA`,
+			},
+		},
+		{
+			name:    "bundle with a SHA-1 signed certificate",
+			certPEM: append(readFile("./testfiles/tls-multiple.crt"), readFile("./testfiles/tls-sha1.crt")...),
+			expectedWarnings: []string{
+				`certificate 3 "CN=sha1-signed" is signed with the deprecated signature algorithm SHA1-RSA`,
+			},
+		},
+		{
+			name:    "no certificate",
+			certPEM: readFile("./testfiles/tls.key"),
+			expectedWarnings: []string{
+				"unable to check the signature algorithms of the certificates: could not read any certificates",
+			},
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			require.Equal(t, scenario.expectedWarnings, SignatureAlgorithmWarnings(scenario.certPEM))
+		})
+	}
+}
+
+func TestKeyMatchesCert(t *testing.T) {
+	encode := func(config *TLSCertificateConfig) ([]byte, []byte) {
+		t.Helper()
+		certPEM, keyPEM, err := config.GetPEMBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return certPEM, keyPEM
+	}
+	newRSAConfig := func(name string) *TLSCertificateConfig {
+		t.Helper()
+		config, err := MakeSelfSignedCAConfig(name, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config
+	}
+	newECDSAConfig := func(name string) *TLSCertificateConfig {
+		t.Helper()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: name}, NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &TLSCertificateConfig{Certs: []*x509.Certificate{cert}, Key: key}
+	}
+
+	rsaCertPEM, rsaKeyPEM := encode(newRSAConfig("rsa"))
+	_, otherRSAKeyPEM := encode(newRSAConfig("other-rsa"))
+	ecdsaCertPEM, ecdsaKeyPEM := encode(newECDSAConfig("ecdsa"))
+	_, otherECDSAKeyPEM := encode(newECDSAConfig("other-ecdsa"))
+
+	scenarios := []struct {
+		name          string
+		keyPEM        []byte
+		certPEM       []byte
+		expectedError string
+	}{
+		{
+			name:    "matching RSA pair",
+			keyPEM:  rsaKeyPEM,
+			certPEM: rsaCertPEM,
+		},
+		{
+			name:    "matching ECDSA pair",
+			keyPEM:  ecdsaKeyPEM,
+			certPEM: ecdsaCertPEM,
+		},
+		{
+			name:          "mismatched RSA pair",
+			keyPEM:        otherRSAKeyPEM,
+			certPEM:       rsaCertPEM,
+			expectedError: `the private key doesn't match the public key of the certificate "CN=rsa"`,
+		},
+		{
+			name:          "mismatched ECDSA pair",
+			keyPEM:        otherECDSAKeyPEM,
+			certPEM:       ecdsaCertPEM,
+			expectedError: `the private key doesn't match the public key of the certificate "CN=ecdsa"`,
+		},
+		{
+			name:          "ECDSA key with an RSA certificate",
+			keyPEM:        ecdsaKeyPEM,
+			certPEM:       rsaCertPEM,
+			expectedError: `the private key doesn't match the public key of the certificate "CN=rsa"`,
+		},
+		{
+			name:          "RSA key with an ECDSA certificate",
+			keyPEM:        rsaKeyPEM,
+			certPEM:       ecdsaCertPEM,
+			expectedError: `the private key doesn't match the public key of the certificate "CN=ecdsa"`,
+		},
+		{
+			name:          "no certificate",
+			keyPEM:        rsaKeyPEM,
+			certPEM:       rsaKeyPEM,
+			expectedError: "unable to parse the certificate: could not read any certificates",
+		},
+		{
+			name:          "no private key",
+			keyPEM:        rsaCertPEM,
+			certPEM:       rsaCertPEM,
+			expectedError: "unable to parse the private key",
+		},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			err := KeyMatchesCert(scenario.keyPEM, scenario.certPEM)
+			if len(scenario.expectedError) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), scenario.expectedError)
+		})
+	}
+}
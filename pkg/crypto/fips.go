@@ -0,0 +1,81 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"sync/atomic"
+)
+
+// minFIPSRSAKeyBits is the smallest RSA modulus approved for generating signatures in FIPS mode.
+const minFIPSRSAKeyBits = 2048
+
+var fipsMode atomic.Bool
+
+// SetFIPSMode enables or disables the FIPS mode enforcement of this package.
+// When enabled, certificates and OCSP responses are only signed if all the keys and the signature algorithm
+// involved are FIPS approved: RSA keys of at least 2048 bits, ECDSA keys on the P-256, P-384 or P-521 curves,
+// and SHA-2 based signatures. Anything else is refused with an error.
+// The FIPS mode is disabled by default.
+func SetFIPSMode(enabled bool) {
+	fipsMode.Store(enabled)
+}
+
+// FIPSModeEnabled returns true when the FIPS mode enforcement is enabled, see SetFIPSMode.
+func FIPSModeEnabled() bool {
+	return fipsMode.Load()
+}
+
+// validateFIPSPublicKey returns an error if the FIPS mode is enabled and the given key is not FIPS approved.
+func validateFIPSPublicKey(key crypto.PublicKey) error {
+	if !FIPSModeEnabled() {
+		return nil
+	}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if bits := key.N.BitLen(); bits < minFIPSRSAKeyBits {
+			return fmt.Errorf("FIPS mode: RSA key size %d is below the minimum of %d bits", bits, minFIPSRSAKeyBits)
+		}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return fmt.Errorf("FIPS mode: ECDSA curve %s is not approved", key.Curve.Params().Name)
+		}
+	default:
+		return fmt.Errorf("FIPS mode: key type %T is not approved", key)
+	}
+	return nil
+}
+
+// validateFIPSPrivateKey returns an error if the FIPS mode is enabled and the given key is not FIPS approved.
+func validateFIPSPrivateKey(key crypto.PrivateKey) error {
+	if !FIPSModeEnabled() {
+		return nil
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("FIPS mode: key type %T is not approved", key)
+	}
+	return validateFIPSPublicKey(signer.Public())
+}
+
+// validateFIPSSignatureAlgorithm returns an error if the FIPS mode is enabled and the given algorithm is not FIPS approved.
+// The unknown algorithm is accepted, it makes x509 pick a SHA-2 based one matching the key.
+func validateFIPSSignatureAlgorithm(algorithm x509.SignatureAlgorithm) error {
+	if !FIPSModeEnabled() {
+		return nil
+	}
+	switch algorithm {
+	case x509.UnknownSignatureAlgorithm,
+		x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS,
+		x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		return nil
+	default:
+		return fmt.Errorf("FIPS mode: signature algorithm %s is not approved", algorithm)
+	}
+}
@@ -0,0 +1,146 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestFIPSMode(t *testing.T) {
+	caConfig, err := MakeSelfSignedCAConfigForDuration("fips-ca", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519PublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientTemplate := func(algorithm x509.SignatureAlgorithm) *x509.Certificate {
+		template := NewClientCertificateTemplate(pkix.Name{CommonName: "client"}, time.Hour, time.Now)
+		template.SignatureAlgorithm = algorithm
+		return template
+	}
+
+	tests := []struct {
+		name          string
+		template      *x509.Certificate
+		requestKey    crypto.PublicKey
+		expectedError string
+	}{
+		{
+			name:       "approved RSA key",
+			template:   clientTemplate(x509.UnknownSignatureAlgorithm),
+			requestKey: &rsaKey.PublicKey,
+		},
+		{
+			name:       "approved ECDSA key with an explicit signature algorithm",
+			template:   clientTemplate(x509.SHA384WithRSA),
+			requestKey: &p256Key.PublicKey,
+		},
+		{
+			name:          "small RSA key",
+			template:      clientTemplate(x509.UnknownSignatureAlgorithm),
+			requestKey:    &smallRSAKey.PublicKey,
+			expectedError: "FIPS mode: RSA key size 1024 is below the minimum of 2048 bits",
+		},
+		{
+			name:          "non-approved curve",
+			template:      clientTemplate(x509.UnknownSignatureAlgorithm),
+			requestKey:    &p224Key.PublicKey,
+			expectedError: "FIPS mode: ECDSA curve P-224 is not approved",
+		},
+		{
+			name:          "non-approved key type",
+			template:      clientTemplate(x509.UnknownSignatureAlgorithm),
+			requestKey:    ed25519PublicKey,
+			expectedError: "FIPS mode: key type ed25519.PublicKey is not approved",
+		},
+		{
+			name:          "non-approved signature algorithm",
+			template:      clientTemplate(x509.SHA1WithRSA),
+			requestKey:    &p256Key.PublicKey,
+			expectedError: "FIPS mode: signature algorithm SHA1-RSA is not approved",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			SetFIPSMode(true)
+			t.Cleanup(func() { SetFIPSMode(false) })
+
+			_, err := ca.SignCertificate(test.template, test.requestKey)
+			switch {
+			case len(test.expectedError) == 0 && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case len(test.expectedError) > 0 && (err == nil || err.Error() != test.expectedError):
+				t.Fatalf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+
+	t.Run("non-approved keys are accepted when disabled", func(t *testing.T) {
+		if FIPSModeEnabled() {
+			t.Fatal("expected the FIPS mode to be disabled by default")
+		}
+		if _, err := ca.SignCertificate(clientTemplate(x509.UnknownSignatureAlgorithm), &p224Key.PublicKey); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("non-approved issuer key", func(t *testing.T) {
+		smallCA, err := makeCAWithKey(smallRSAKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetFIPSMode(true)
+		t.Cleanup(func() { SetFIPSMode(false) })
+
+		if _, err := smallCA.MakeServerCert(sets.New("example.com"), time.Hour); err == nil || !strings.Contains(err.Error(), "issuer key: FIPS mode: RSA key size 1024") {
+			t.Fatalf("expected the issuer key to be rejected, got %v", err)
+		}
+		if _, err := smallCA.MakeOCSPResponse(smallCA.Config.Certs[0], nil, time.Hour); err == nil || !strings.Contains(err.Error(), "CA key: FIPS mode: RSA key size 1024") {
+			t.Fatalf("expected the CA key to be rejected, got %v", err)
+		}
+	})
+}
+
+// makeCAWithKey returns a self-signed CA using the given key, it must be called with the FIPS mode disabled.
+func makeCAWithKey(key *rsa.PrivateKey) (*CA, error) {
+	template := newSigningCertificateTemplateForDuration(pkix.Name{CommonName: "small-ca"}, time.Hour, time.Now, nil, nil)
+	cert, err := signCertificate(template, &key.PublicKey, template, key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{
+		Config:          &TLSCertificateConfig{Certs: []*x509.Certificate{cert}, Key: key},
+		SerialGenerator: &RandomSerialGenerator{},
+	}, nil
+}
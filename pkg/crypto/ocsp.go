@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// MakeOCSPResponse returns a DER encoded OCSP response, signed by the CA, that can be stapled by a server
+// serving the given certificate. The certificate must have been issued by the CA.
+// The response reports the certificate as revoked if revokedAt is set and as good otherwise,
+// and is valid for the given duration.
+func (ca *CA) MakeOCSPResponse(cert *x509.Certificate, revokedAt *time.Time, validity time.Duration) ([]byte, error) {
+	return ca.makeOCSPResponse(cert, revokedAt, validity, time.Now)
+}
+
+func (ca *CA) makeOCSPResponse(cert *x509.Certificate, revokedAt *time.Time, validity time.Duration, currentTime func() time.Time) ([]byte, error) {
+	if validity <= 0 {
+		return nil, fmt.Errorf("invalid OCSP response validity: %v", validity)
+	}
+	issuer := ca.Config.Certs[0]
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("certificate %q was not issued by CA %q: %w", cert.Subject.CommonName, issuer.Subject.CommonName, err)
+	}
+	signer, ok := ca.Config.Key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA key of type %T can't be used for signing", ca.Config.Key)
+	}
+	if err := validateFIPSPublicKey(signer.Public()); err != nil {
+		return nil, fmt.Errorf("CA key: %w", err)
+	}
+
+	now := currentTime()
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: cert.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(validity),
+	}
+	if revokedAt != nil {
+		template.Status = ocsp.Revoked
+		template.RevokedAt = *revokedAt
+		template.RevocationReason = ocsp.Unspecified
+	}
+	return ocsp.CreateResponse(issuer, issuer, template, signer)
+}
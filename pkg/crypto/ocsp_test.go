@@ -0,0 +1,59 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestMakeOCSPResponse(t *testing.T) {
+	caConfig, err := MakeSelfSignedCAConfig("testca", DefaultCACertificateLifetimeDuration)
+	require.NoError(t, err)
+	ca := &CA{Config: caConfig, SerialGenerator: &RandomSerialGenerator{}}
+	serverCert, err := ca.MakeServerCert(sets.New("myserver.local"), DefaultCertificateLifetimeDuration)
+	require.NoError(t, err)
+
+	otherCAConfig, err := MakeSelfSignedCAConfig("otherca", DefaultCACertificateLifetimeDuration)
+	require.NoError(t, err)
+	otherCA := &CA{Config: otherCAConfig, SerialGenerator: &RandomSerialGenerator{}}
+
+	now := time.Now().Truncate(time.Second).UTC()
+	currentTime := func() time.Time { return now }
+	revokedAt := now.Add(-time.Hour)
+
+	t.Run("good", func(t *testing.T) {
+		der, err := ca.makeOCSPResponse(serverCert.Certs[0], nil, 4*time.Hour, currentTime)
+		require.NoError(t, err)
+
+		response, err := ocsp.ParseResponseForCert(der, serverCert.Certs[0], ca.Config.Certs[0])
+		require.NoError(t, err)
+		require.Equal(t, ocsp.Good, response.Status)
+		require.Equal(t, serverCert.Certs[0].SerialNumber, response.SerialNumber)
+		require.Equal(t, now, response.ThisUpdate)
+		require.Equal(t, now.Add(4*time.Hour), response.NextUpdate)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		der, err := ca.makeOCSPResponse(serverCert.Certs[0], &revokedAt, time.Hour, currentTime)
+		require.NoError(t, err)
+
+		response, err := ocsp.ParseResponseForCert(der, serverCert.Certs[0], ca.Config.Certs[0])
+		require.NoError(t, err)
+		require.Equal(t, ocsp.Revoked, response.Status)
+		require.Equal(t, revokedAt, response.RevokedAt)
+		require.Equal(t, now.Add(time.Hour), response.NextUpdate)
+	})
+
+	t.Run("certificate issued by another CA", func(t *testing.T) {
+		_, err := otherCA.MakeOCSPResponse(serverCert.Certs[0], nil, time.Hour)
+		require.ErrorContains(t, err, "was not issued by CA")
+	})
+
+	t.Run("invalid validity", func(t *testing.T) {
+		_, err := ca.MakeOCSPResponse(serverCert.Certs[0], nil, 0)
+		require.ErrorContains(t, err, "invalid OCSP response validity")
+	})
+}
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+
+	"k8s.io/client-go/util/cert"
+)
+
+// SPKIPin returns the pin of the first certificate of the given PEM bundle, i.e. the base64 encoded SHA-256
+// digest of its DER encoded subject public key info, as used by the pin-sha256 directive of RFC 7469.
+// The pin only depends on the public key, so it survives the renewal of a certificate reusing the same key.
+func SPKIPin(certPEM []byte) (string, error) {
+	certs, err := cert.ParseCertsPEM(certPEM)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse the certificate: %w", err)
+	}
+	return spkiPin(certs[0]), nil
+}
+
+func spkiPin(certificate *x509.Certificate) string {
+	digest := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSPKIPin(t *testing.T) {
+	readFile := func(path string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	// the expected pins were computed with:
+	// openssl x509 -in <file> -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+	tests := []struct {
+		name        string
+		certPEM     []byte
+		expectedPin string
+		expectedErr string
+	}{
+		{
+			name:        "certificate",
+			certPEM:     readFile("./testfiles/tls.crt"),
+			expectedPin: "KF8kUXed7MGWrlgRB2hm25oPSBgCU/kx+z18c0xtSp4=",
+		},
+		{
+			name:        "expired certificate",
+			certPEM:     readFile("./testfiles/tls-expired.crt"),
+			expectedPin: "vLop/X4UnSzayMI/Z03EN1QIWTp1XvNGQsUqBOOJAeM=",
+		},
+		{
+			name:        "bundle pins its first certificate",
+			certPEM:     readFile("./testfiles/tls-multiple.crt"),
+			expectedPin: "k0qxbA2ud+qD2bPgs1sWxgVyEXLwDIgwLC9mikx5BhQ=",
+		},
+		{
+			name:        "private key",
+			certPEM:     readFile("./testfiles/tls.key"),
+			expectedErr: "unable to parse the certificate: data does not contain any valid RSA or ECDSA certificates",
+		},
+		{
+			name:        "empty",
+			expectedErr: "unable to parse the certificate: data does not contain any valid RSA or ECDSA certificates",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pin, err := SPKIPin(test.certPEM)
+			switch {
+			case len(test.expectedErr) > 0 && (err == nil || err.Error() != test.expectedErr):
+				t.Fatalf("expected error %q, got %v", test.expectedErr, err)
+			case len(test.expectedErr) == 0 && err != nil:
+				t.Fatal(err)
+			}
+			if pin != test.expectedPin {
+				t.Errorf("expected pin %q, got %q", test.expectedPin, pin)
+			}
+		})
+	}
+}
@@ -1,8 +1,14 @@
 package crypto
 
 import (
+	"crypto"
 	"crypto/x509"
+	"fmt"
 	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
 )
 
 // FilterExpiredCerts checks are all certificates in the bundle valid, i.e. they have not expired.
@@ -18,3 +24,90 @@ func FilterExpiredCerts(certs ...*x509.Certificate) []*x509.Certificate {
 
 	return validCerts
 }
+
+// ExpiringCert identifies a certificate stored in a secret that is about to expire.
+type ExpiringCert struct {
+	Namespace string
+	Name      string
+	// Subject is the common name of the expiring certificate, useful when the secret holds a chain.
+	Subject  string
+	NotAfter time.Time
+}
+
+// CertsExpiringWithin returns every certificate found in the tls.crt key of the given secrets
+// whose remaining validity at now is shorter than d. Already expired certificates are included.
+// Secrets without certificate data, or whose data can't be parsed, are skipped.
+func CertsExpiringWithin(secrets []*corev1.Secret, d time.Duration, now time.Time) []ExpiringCert {
+	var expiring []ExpiringCert
+	for _, secret := range secrets {
+		if secret == nil || len(secret.Data[corev1.TLSCertKey]) == 0 {
+			continue
+		}
+		certs, err := cert.ParseCertsPEM(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			klog.V(4).Infof("skipping secret %s/%s with unparseable certificate data: %v", secret.Namespace, secret.Name, err)
+			continue
+		}
+		for _, c := range certs {
+			if c.NotAfter.Sub(now) < d {
+				expiring = append(expiring, ExpiringCert{
+					Namespace: secret.Namespace,
+					Name:      secret.Name,
+					Subject:   c.Subject.CommonName,
+					NotAfter:  c.NotAfter,
+				})
+			}
+		}
+	}
+	return expiring
+}
+
+// CrossSignedCA is a new CA along with its certificate cross-signed by the CA it replaces.
+type CrossSignedCA struct {
+	// CA is the new self-signed CA, used to sign the new certificates.
+	CA *CA
+	// CrossSigned has the subject and key of the new CA but is signed by the old one. Served along with the certificates
+	// signed by the new CA, it lets the clients trusting only the old CA verify them.
+	CrossSigned *x509.Certificate
+	// TrustBundle holds the new and the old CA certificates in PEM, to be trusted during the rollout.
+	TrustBundle []byte
+}
+
+// MakeCrossSignedCA generates a new self-signed CA to replace ca, and cross-signs its certificate with ca, so that there is
+// no trust gap during the rotation: the clients trusting the old CA verify the certificates of the new CA through the
+// cross-signed certificate, until they trust the TrustBundle or the new CA.
+// The cross-signed certificate doesn't outlive ca.
+func (ca *CA) MakeCrossSignedCA(name string, lifetime time.Duration) (*CrossSignedCA, error) {
+	newCAConfig, err := MakeSelfSignedCAConfigForDuration(name, lifetime)
+	if err != nil {
+		return nil, err
+	}
+	newCACert := newCAConfig.Certs[0]
+	signer, ok := newCAConfig.Key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", newCAConfig.Key)
+	}
+
+	oldCACert := ca.Config.Certs[0]
+	template := newSigningCertificateTemplateForDuration(newCACert.Subject, lifetime, time.Now, oldCACert.SubjectKeyId, newCACert.SubjectKeyId)
+	if template.NotAfter.After(oldCACert.NotAfter) {
+		template.NotAfter = oldCACert.NotAfter
+	}
+	crossSigned, err := ca.SignCertificate(template, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	trustBundle, err := EncodeCertificates(newCACert, oldCACert)
+	if err != nil {
+		return nil, err
+	}
+	return &CrossSignedCA{
+		CA: &CA{
+			SerialGenerator: &RandomSerialGenerator{},
+			Config:          newCAConfig,
+		},
+		CrossSigned: crossSigned,
+		TrustBundle: trustBundle,
+	}, nil
+}
@@ -3,13 +3,17 @@ package crypto
 import (
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"fmt"
 	"math/big"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/cert"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,6 +62,60 @@ func TestValidateCertificatesExpired(t *testing.T) {
 	}
 }
 
+func TestCertsExpiringWithin(t *testing.T) {
+	now := time.Now()
+	newSecret := func(name string, validities ...time.Duration) *corev1.Secret {
+		var certBytes []byte
+		for i, validity := range validities {
+			c, err := newTestCACertificate(pkix.Name{CommonName: fmt.Sprintf("%s-%d", name, i)}, int64(1), metav1.Duration{Duration: validity}, func() time.Time { return now })
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := EncodeCertificates(c.Config.Certs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			certBytes = append(certBytes, b...)
+		}
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: name},
+			Data:       map[string][]byte{corev1.TLSCertKey: certBytes},
+		}
+	}
+
+	secrets := []*corev1.Secret{
+		newSecret("long-lived", 30*24*time.Hour),
+		newSecret("short-lived", time.Hour),
+		newSecret("expired", -time.Hour),
+		newSecret("chain", 30*24*time.Hour, 2*time.Hour),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "no-data"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "other-data"}, Data: map[string][]byte{"foo": []byte("bar")}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "garbage"}, Data: map[string][]byte{corev1.TLSCertKey: []byte("garbage")}},
+		nil,
+	}
+
+	expiring := CertsExpiringWithin(secrets, 24*time.Hour, now)
+
+	var got []string
+	for _, e := range expiring {
+		if e.Namespace != "ns" {
+			t.Errorf("unexpected namespace %q", e.Namespace)
+		}
+		got = append(got, fmt.Sprintf("%s/%s", e.Name, e.Subject))
+	}
+	expected := []string{"short-lived/short-lived-0", "expired/expired-0", "chain/chain-1"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	if !expiring[0].NotAfter.Equal(now.Add(time.Hour).Truncate(time.Second)) {
+		t.Errorf("unexpected notAfter %v", expiring[0].NotAfter)
+	}
+
+	if expiring := CertsExpiringWithin(secrets, 0, now); len(expiring) != 1 || expiring[0].Name != "expired" {
+		t.Errorf("expected only the expired cert with a zero duration, got %v", expiring)
+	}
+}
+
 // NewCACertificate generates and signs new CA certificate and key.
 func newTestCACertificate(subject pkix.Name, serialNumber int64, validity metav1.Duration, currentTime func() time.Time) (*CA, error) {
 	caPublicKey, caPrivateKey, err := NewKeyPair()
@@ -91,3 +149,75 @@ func newTestCACertificate(subject pkix.Name, serialNumber int64, validity metav1
 		},
 	}, nil
 }
+
+func TestMakeCrossSignedCA(t *testing.T) {
+	oldCAConfig, err := MakeSelfSignedCAConfig("old-ca", certificateLifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldCA := &CA{Config: oldCAConfig, SerialGenerator: &RandomSerialGenerator{}}
+
+	rotated, err := oldCA.MakeCrossSignedCA("new-ca", 2*certificateLifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCACert := rotated.CA.Config.Certs[0]
+	if rotated.CrossSigned.Subject.String() != newCACert.Subject.String() || !reflect.DeepEqual(rotated.CrossSigned.SubjectKeyId, newCACert.SubjectKeyId) {
+		t.Errorf("expected the cross-signed certificate to have the subject and key of the new CA")
+	}
+	if rotated.CrossSigned.NotAfter.After(oldCAConfig.Certs[0].NotAfter) {
+		t.Errorf("expected the cross-signed certificate not to outlive the old CA, got %v", rotated.CrossSigned.NotAfter)
+	}
+
+	oldServer, err := oldCA.MakeServerCert(sets.New("foo"), certificateLifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newServer, err := rotated.CA.MakeServerCert(sets.New("foo"), certificateLifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := func(certs ...*x509.Certificate) *x509.CertPool {
+		p := x509.NewCertPool()
+		for _, c := range certs {
+			p.AddCert(c)
+		}
+		return p
+	}
+	trustBundle, err := cert.ParseCertsPEM(rotated.TrustBundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := []struct {
+		name          string
+		roots         *x509.CertPool
+		leaf          *x509.Certificate
+		intermediates *x509.CertPool
+		expectValid   bool
+	}{
+		{name: "old client, old server", roots: pool(oldCAConfig.Certs[0]), leaf: oldServer.Certs[0], expectValid: true},
+		{name: "old client, new server with the cross-signed certificate", roots: pool(oldCAConfig.Certs[0]), leaf: newServer.Certs[0], intermediates: pool(rotated.CrossSigned), expectValid: true},
+		{name: "old client, new server without the cross-signed certificate", roots: pool(oldCAConfig.Certs[0]), leaf: newServer.Certs[0], expectValid: false},
+		{name: "new client, new server with the cross-signed certificate", roots: pool(newCACert), leaf: newServer.Certs[0], intermediates: pool(rotated.CrossSigned), expectValid: true},
+		{name: "new client, old server", roots: pool(newCACert), leaf: oldServer.Certs[0], expectValid: false},
+		{name: "trust bundle, old server", roots: pool(trustBundle...), leaf: oldServer.Certs[0], expectValid: true},
+		{name: "trust bundle, new server", roots: pool(trustBundle...), leaf: newServer.Certs[0], expectValid: true},
+	}
+	for _, scenario := range scenarios {
+		t.Run(scenario.name, func(t *testing.T) {
+			_, err := scenario.leaf.Verify(x509.VerifyOptions{
+				DNSName:       "foo",
+				Roots:         scenario.roots,
+				Intermediates: scenario.intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			if scenario.expectValid && err != nil {
+				t.Errorf("expected the certificate to be valid, got: %v", err)
+			}
+			if !scenario.expectValid && err == nil {
+				t.Errorf("expected the certificate to be invalid")
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package certrotation
 
 import (
+	"fmt"
+
 	"github.com/google/go-cmp/cmp"
 	"github.com/openshift/api/annotations"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,6 +25,10 @@ const (
 	CertificateAutoRegenerateAfterOfflineExpiryAnnotation string = "certificates.openshift.io/auto-regenerate-after-offline-expiry"
 	// CertificateRefreshPeriodAnnotation is the interval at which the certificate should be refreshed.
 	CertificateRefreshPeriodAnnotation string = "certificates.openshift.io/refresh-period"
+	// CertificateForceRotationAnnotation can be set on a TLS artifact secret, e.g. after a key leak, to force the
+	// rotation of the cert/key pair regardless of its validity. Its value is the reason reported in the rotation event.
+	// The annotation is removed once the new cert/key pair is stored in the secret.
+	CertificateForceRotationAnnotation string = "certificates.openshift.io/force-rotation"
 )
 
 type AdditionalAnnotations struct {
@@ -92,6 +98,18 @@ func (a AdditionalAnnotations) EnsureTLSMetadataUpdate(meta *metav1.ObjectMeta)
 	return modified
 }
 
+// forcedRotationReason returns a non-empty reason if the CertificateForceRotationAnnotation is set on the given meta.
+func forcedRotationReason(meta metav1.ObjectMeta) string {
+	reason, ok := meta.Annotations[CertificateForceRotationAnnotation]
+	if !ok {
+		return ""
+	}
+	if len(reason) == 0 {
+		return "forced rotation requested"
+	}
+	return fmt.Sprintf("forced rotation requested: %s", reason)
+}
+
 func NewTLSArtifactObjectMeta(name, namespace string, annotations AdditionalAnnotations) metav1.ObjectMeta {
 	meta := metav1.ObjectMeta{
 		Namespace: namespace,
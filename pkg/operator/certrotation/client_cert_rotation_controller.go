@@ -10,6 +10,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/crypto"
 	"github.com/openshift/library-go/pkg/operator/condition"
 	"github.com/openshift/library-go/pkg/operator/events"
 	"github.com/openshift/library-go/pkg/operator/v1helpers"
@@ -51,6 +52,9 @@ func (s *StaticPodConditionStatusReporter) Report(ctx context.Context, controlle
 // 1) continuously create a self-signed signing CA (via RotatedSigningCASecret) and store it in a secret.
 // 2) maintain a CA bundle ConfigMap with all not yet expired CA certs.
 // 3) continuously create a target cert and key signed by the latest signing CA and store it in a secret.
+//
+// Optionally, the target cert is signed by an intermediate CA which is in turn signed by the signing CA
+// (via RotatedIntermediateCASecret). The full chain of the target cert is then written to its tls.crt.
 type CertRotationController struct {
 	// controller name
 	Name string
@@ -58,6 +62,10 @@ type CertRotationController struct {
 	RotatedSigningCASecret RotatedSigningCASecret
 	// CABundleConfigMap maintains a CA bundle config map, by adding new CA certs coming from rotatedSigningCASecret, and by removing expired old ones.
 	CABundleConfigMap CABundleConfigMap
+	// RotatedIntermediateCASecret optionally rotates an intermediate CA signed by the signing CA and stores it in a secret.
+	// When set, the target cert is signed by the intermediate CA instead of the signing CA. Its CertCreator must create
+	// CA certs, e.g. SignerRotation. Serving certs created by ServingRotation then carry the intermediate CA in their chain.
+	RotatedIntermediateCASecret *RotatedSelfSignedCertKeySecret
 	// RotatedSelfSignedCertKeySecret rotates a key and cert signed by a signing CA and stores it in a secret.
 	RotatedSelfSignedCertKeySecret RotatedSelfSignedCertKeySecret
 
@@ -72,14 +80,49 @@ func NewCertRotationController(
 	rotatedSelfSignedCertKeySecret RotatedSelfSignedCertKeySecret,
 	recorder events.Recorder,
 	reporter StatusReporter,
+) factory.Controller {
+	return newCertRotationController(name, rotatedSigningCASecret, caBundleConfigMap, nil, rotatedSelfSignedCertKeySecret, recorder, reporter)
+}
+
+// NewCertRotationControllerWithIntermediateCA returns a controller that signs the target cert with an intermediate CA,
+// rotated by rotatedIntermediateCASecret and signed by the signing CA.
+func NewCertRotationControllerWithIntermediateCA(
+	name string,
+	rotatedSigningCASecret RotatedSigningCASecret,
+	caBundleConfigMap CABundleConfigMap,
+	rotatedIntermediateCASecret RotatedSelfSignedCertKeySecret,
+	rotatedSelfSignedCertKeySecret RotatedSelfSignedCertKeySecret,
+	recorder events.Recorder,
+	reporter StatusReporter,
+) factory.Controller {
+	return newCertRotationController(name, rotatedSigningCASecret, caBundleConfigMap, &rotatedIntermediateCASecret, rotatedSelfSignedCertKeySecret, recorder, reporter)
+}
+
+func newCertRotationController(
+	name string,
+	rotatedSigningCASecret RotatedSigningCASecret,
+	caBundleConfigMap CABundleConfigMap,
+	rotatedIntermediateCASecret *RotatedSelfSignedCertKeySecret,
+	rotatedSelfSignedCertKeySecret RotatedSelfSignedCertKeySecret,
+	recorder events.Recorder,
+	reporter StatusReporter,
 ) factory.Controller {
 	c := &CertRotationController{
 		Name:                           name,
 		RotatedSigningCASecret:         rotatedSigningCASecret,
 		CABundleConfigMap:              caBundleConfigMap,
+		RotatedIntermediateCASecret:    rotatedIntermediateCASecret,
 		RotatedSelfSignedCertKeySecret: rotatedSelfSignedCertKeySecret,
 		StatusReporter:                 reporter,
 	}
+	informers := []factory.Informer{
+		rotatedSigningCASecret.Informer.Informer(),
+		caBundleConfigMap.Informer.Informer(),
+		rotatedSelfSignedCertKeySecret.Informer.Informer(),
+	}
+	if rotatedIntermediateCASecret != nil {
+		informers = append(informers, rotatedIntermediateCASecret.Informer.Informer())
+	}
 	return factory.New().
 		ResyncEvery(time.Minute).
 		WithSync(c.Sync).
@@ -92,6 +135,9 @@ func NewCertRotationController(
 					if secret.Namespace == rotatedSigningCASecret.Namespace && secret.Name == rotatedSigningCASecret.Name {
 						return true
 					}
+					if rotatedIntermediateCASecret != nil && secret.Namespace == rotatedIntermediateCASecret.Namespace && secret.Name == rotatedIntermediateCASecret.Name {
+						return true
+					}
 					if secret.Namespace == rotatedSelfSignedCertKeySecret.Namespace && secret.Name == rotatedSelfSignedCertKeySecret.Name {
 						return true
 					}
@@ -99,9 +145,7 @@ func NewCertRotationController(
 				}
 				return true
 			},
-			rotatedSigningCASecret.Informer.Informer(),
-			caBundleConfigMap.Informer.Informer(),
-			rotatedSelfSignedCertKeySecret.Informer.Informer(),
+			informers...,
 		).
 		WithPostStartHooks(
 			c.targetCertRecheckerPostRunHook,
@@ -155,7 +199,26 @@ func (c CertRotationController) SyncWorker(ctx context.Context) error {
 		return fmt.Errorf("cabundleCerts is nil")
 	}
 
-	if _, err := c.RotatedSelfSignedCertKeySecret.EnsureTargetCertKeyPair(ctx, signingCertKeyPair, cabundleCerts); err != nil {
+	targetSigner, targetCABundleCerts := signingCertKeyPair, cabundleCerts
+	if c.RotatedIntermediateCASecret != nil {
+		intermediateCASecret, err := c.RotatedIntermediateCASecret.EnsureTargetCertKeyPair(ctx, signingCertKeyPair, cabundleCerts)
+		if err != nil {
+			return err
+		}
+		// If no intermediate CA returned due to update conflict or otherwise, return an error
+		if intermediateCASecret == nil {
+			return fmt.Errorf("intermediateCASecret is nil")
+		}
+		intermediateCA, err := crypto.GetCAFromBytes(intermediateCASecret.Data["tls.crt"], intermediateCASecret.Data["tls.key"])
+		if err != nil {
+			return fmt.Errorf("unable to read intermediate CA from %s/%s: %w", intermediateCASecret.Namespace, intermediateCASecret.Name, err)
+		}
+		// the intermediate CA signs the target cert, its chain up to the signing CA is appended to the target cert.
+		// The target cert is refreshed as soon as it is no longer issued by the current intermediate CA.
+		targetSigner, targetCABundleCerts = intermediateCA, intermediateCA.Config.Certs
+	}
+
+	if _, err := c.RotatedSelfSignedCertKeySecret.EnsureTargetCertKeyPair(ctx, targetSigner, targetCABundleCerts); err != nil {
 		return err
 	}
 
@@ -479,3 +479,128 @@ func createValidTargetCertSecret(namespace, name string, serverCert *crypto.TLSC
 		},
 	}
 }
+
+func TestCertRotationController_SyncWorkerWithIntermediateCA(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(fakeClient, 0)
+	secretInformer := informerFactory.Core().V1().Secrets()
+	configMapInformer := informerFactory.Core().V1().ConfigMaps()
+
+	controller := &CertRotationController{
+		Name: "test-controller",
+		RotatedSigningCASecret: RotatedSigningCASecret{
+			Namespace:     "test-namespace",
+			Name:          "test-signer-cert",
+			Validity:      24 * time.Hour,
+			Refresh:       12 * time.Hour,
+			Informer:      secretInformer,
+			Lister:        secretInformer.Lister(),
+			Client:        fakeClient.CoreV1(),
+			EventRecorder: events.NewInMemoryRecorder("test", clock.RealClock{}),
+		},
+		CABundleConfigMap: CABundleConfigMap{
+			Namespace:     "test-namespace",
+			Name:          "test-ca-bundle",
+			Informer:      configMapInformer,
+			Lister:        configMapInformer.Lister(),
+			Client:        fakeClient.CoreV1(),
+			EventRecorder: events.NewInMemoryRecorder("test", clock.RealClock{}),
+		},
+		RotatedIntermediateCASecret: &RotatedSelfSignedCertKeySecret{
+			Namespace:     "test-namespace",
+			Name:          "test-intermediate-cert",
+			Validity:      12 * time.Hour,
+			Refresh:       6 * time.Hour,
+			CertCreator:   &SignerRotation{SignerName: "test-intermediate"},
+			Informer:      secretInformer,
+			Lister:        secretInformer.Lister(),
+			Client:        fakeClient.CoreV1(),
+			EventRecorder: events.NewInMemoryRecorder("test", clock.RealClock{}),
+		},
+		RotatedSelfSignedCertKeySecret: RotatedSelfSignedCertKeySecret{
+			Namespace: "test-namespace",
+			Name:      "test-target-cert",
+			Validity:  6 * time.Hour,
+			Refresh:   3 * time.Hour,
+			CertCreator: &ServingRotation{
+				Hostnames: func() []string { return []string{"test.example.com"} },
+			},
+			Informer:      secretInformer,
+			Lister:        secretInformer.Lister(),
+			Client:        fakeClient.CoreV1(),
+			EventRecorder: events.NewInMemoryRecorder("test", clock.RealClock{}),
+		},
+		StatusReporter: &testStatusReporter{},
+	}
+
+	if err := controller.SyncWorker(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	created := map[string]runtime.Object{}
+	for _, action := range fakeClient.Actions() {
+		if action.GetVerb() != "create" {
+			continue
+		}
+		obj := action.(clienttesting.CreateAction).GetObject()
+		created[obj.(metav1.Object).GetName()] = obj
+		// make the created objects visible to the listers for the next sync
+		switch o := obj.(type) {
+		case *corev1.Secret:
+			secretInformer.Informer().GetStore().Add(o)
+		case *corev1.ConfigMap:
+			configMapInformer.Informer().GetStore().Add(o)
+		}
+	}
+	for _, name := range []string{"test-signer-cert", "test-ca-bundle", "test-intermediate-cert", "test-target-cert"} {
+		if _, ok := created[name]; !ok {
+			t.Fatalf("expected %q to be created, got %v", name, sets.KeySet(created).UnsortedList())
+		}
+	}
+
+	targetChain, err := crypto.CertsFromPEM(created["test-target-cert"].(*corev1.Secret).Data["tls.crt"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targetChain) != 3 {
+		t.Fatalf("expected the target cert chain to hold the target, intermediate and signing certs, got %d certs", len(targetChain))
+	}
+	if issuer := targetChain[0].Issuer.CommonName; !strings.HasPrefix(issuer, "test-intermediate") {
+		t.Errorf("expected the target cert to be issued by the intermediate CA, got %q", issuer)
+	}
+	if subject := targetChain[1].Subject.CommonName; !strings.HasPrefix(subject, "test-intermediate") {
+		t.Errorf("expected the intermediate CA to follow the target cert, got %q", subject)
+	}
+
+	// the chain must verify against the published CA bundle, which only holds the signing CA
+	bundleCerts, err := crypto.CertsFromPEM([]byte(created["test-ca-bundle"].(*corev1.ConfigMap).Data["ca-bundle.crt"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range bundleCerts {
+		roots.AddCert(cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range targetChain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := targetChain[0].Verify(x509.VerifyOptions{
+		DNSName:       "test.example.com",
+		Roots:         roots,
+		Intermediates: intermediates,
+	}); err != nil {
+		t.Errorf("expected the target cert to verify against the CA bundle: %v", err)
+	}
+
+	// a second sync against the created objects must not rotate anything
+	fakeClient.ClearActions()
+	if err := controller.SyncWorker(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, action := range fakeClient.Actions() {
+		if action.GetVerb() == "create" || action.GetVerb() == "update" {
+			t.Errorf("unexpected %s action: %v", action.GetVerb(), action)
+		}
+	}
+}
@@ -19,7 +19,7 @@ import (
 )
 
 // RotatedSigningCASecret rotates a self-signed signing CA stored in a secret. It creates a new one when
-// - refresh duration is over
+// - refresh duration or refresh percentage of validity is over
 // - or 80% of validity is over (if RefreshOnlyWhenExpired is false)
 // - or the CA is expired.
 type RotatedSigningCASecret struct {
@@ -33,6 +33,11 @@ type RotatedSigningCASecret struct {
 	// Refresh is the duration after signing CA creation when it is rotated at the latest. It is ignored
 	// if RefreshOnlyWhenExpired is true, or if Refresh > Validity.
 	Refresh time.Duration
+	// RefreshPercentage, if set, rotates the signing CA once the given percentage of its lifetime, from its notBefore
+	// to its notAfter, is over, so that the rotation adapts to the validity of the CA. The earlier of Refresh and
+	// RefreshPercentage applies, a zero Refresh is ignored. The CA is still rotated when 80% of validity is reached,
+	// whatever the percentage. It is ignored if RefreshOnlyWhenExpired is true.
+	RefreshPercentage int
 	// RefreshOnlyWhenExpired set to true means to ignore 80% of validity and the Refresh duration for rotation,
 	// but only rotate when the signing CA expires. This is useful for auto-recovery when we want to enforce
 	// rotation on expiration only, but not interfere with the ordinary rotation controller.
@@ -87,14 +92,19 @@ func (c RotatedSigningCASecret) EnsureSigningCertKeyPair(ctx context.Context) (*
 
 	// run Update if signer content needs changing
 	signerUpdated := false
-	if needed, reason := needNewSigningCertKeyPair(signingCertKeyPairSecret, c.Refresh, c.RefreshOnlyWhenExpired); needed || creationRequired {
+	needed, reason := needNewSigningCertKeyPair(signingCertKeyPairSecret, refreshFromAnnotations(signingCertKeyPairSecret.Annotations, c.Refresh, c.RefreshPercentage), c.RefreshOnlyWhenExpired)
+	if forcedReason := forcedRotationReason(signingCertKeyPairSecret.ObjectMeta); len(forcedReason) > 0 {
+		needed, reason = true, forcedReason
+	}
+	if needed || creationRequired {
 		if creationRequired {
 			reason = "secret doesn't exist"
 		}
 		c.EventRecorder.Eventf("SignerUpdateRequired", "%q in %q requires a new signing cert/key pair: %v", c.Name, c.Namespace, reason)
-		if err = setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret, c.Validity, c.Refresh, c.AdditionalAnnotations); err != nil {
+		if err = setSigningCertKeyPairSecretAndTLSAnnotations(signingCertKeyPairSecret, c.Validity, refreshForLifetime(c.Refresh, c.RefreshPercentage, c.Validity), c.AdditionalAnnotations); err != nil {
 			return nil, false, err
 		}
+		delete(signingCertKeyPairSecret.Annotations, CertificateForceRotationAnnotation)
 
 		LabelAsManagedSecret(signingCertKeyPairSecret, CertificateTypeSigner)
 
@@ -178,6 +188,33 @@ func needNewSigningCertKeyPair(secret *corev1.Secret, refresh time.Duration, ref
 	return false, ""
 }
 
+// refreshForLifetime returns the refresh duration of a certificate with the given lifetime: the given refresh
+// percentage of the lifetime, or refresh if it is earlier. Without a refresh percentage, it is refresh.
+func refreshForLifetime(refresh time.Duration, refreshPercentage int, lifetime time.Duration) time.Duration {
+	if refreshPercentage <= 0 {
+		return refresh
+	}
+	// divide first, a lifetime of years times the percentage overflows
+	percentageRefresh := lifetime / 100 * time.Duration(refreshPercentage)
+	if refresh > 0 && refresh < percentageRefresh {
+		return refresh
+	}
+	return percentageRefresh
+}
+
+// refreshFromAnnotations returns the refresh duration of the certificate whose validity is described by the given
+// annotations, see refreshForLifetime. It is refresh if the validity is unknown, the certificate is rotated anyway.
+func refreshFromAnnotations(annotations map[string]string, refresh time.Duration, refreshPercentage int) time.Duration {
+	if refreshPercentage <= 0 {
+		return refresh
+	}
+	notBefore, notAfter, reason := getValidityFromAnnotations(annotations)
+	if len(reason) > 0 {
+		return refresh
+	}
+	return refreshForLifetime(refresh, refreshPercentage, notAfter.Sub(notBefore))
+}
+
 func getValidityFromAnnotations(annotations map[string]string) (notBefore time.Time, notAfter time.Time, reason string) {
 	notAfterString := annotations[CertificateNotAfterAnnotation]
 	if len(notAfterString) == 0 {
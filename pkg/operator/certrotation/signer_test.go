@@ -169,6 +169,52 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 			},
 			expectedError: "certFile missing", // this means we tried to read the cert from the existing secret.  If we created one, we fail in the client check
 		},
+		{
+			name: "forced rotation",
+			initialSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "signer",
+					ResourceVersion: "10",
+					Annotations: map[string]string{
+						"auth.openshift.io/certificate-not-after":  "2108-09-08T22:47:31-07:00",
+						"auth.openshift.io/certificate-not-before": "2108-09-08T20:47:31-07:00",
+						annotations.OpenShiftComponent:             "test",
+						CertificateForceRotationAnnotation:         "key leaked",
+					},
+					OwnerReferences: []metav1.OwnerReference{{
+						Name: "operator",
+					}},
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": {}, "tls.key": {}},
+			},
+			RefreshOnlyWhenExpired: false,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset, controllerUpdatedSecret bool) {
+				t.Helper()
+				verifyForcedSignerRotation(t, client, controllerUpdatedSecret)
+			},
+		},
+		{
+			name: "forced rotation with RefreshOnlyWhenExpired set",
+			initialSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:       "ns",
+					Name:            "signer",
+					ResourceVersion: "10",
+					Annotations: map[string]string{
+						"auth.openshift.io/certificate-not-after":  "2108-09-08T22:47:31-07:00",
+						"auth.openshift.io/certificate-not-before": "2108-09-08T20:47:31-07:00",
+						CertificateForceRotationAnnotation:         "",
+					},
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{"tls.crt": {}, "tls.key": {}},
+			},
+			RefreshOnlyWhenExpired: true,
+			verifyActions: func(t *testing.T, client *kubefake.Clientset, controllerUpdatedSecret bool) {
+				t.Helper()
+				verifyForcedSignerRotation(t, client, controllerUpdatedSecret)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -212,3 +258,103 @@ func TestEnsureSigningCertKeyPair(t *testing.T) {
 		})
 	}
 }
+
+func verifyForcedSignerRotation(t *testing.T, client *kubefake.Clientset, controllerUpdatedSecret bool) {
+	t.Helper()
+	actions := client.Actions()
+	if len(actions) != 1 {
+		t.Fatal(spew.Sdump(actions))
+	}
+	if !actions[0].Matches("update", "secrets") {
+		t.Error(actions[0])
+	}
+	if !controllerUpdatedSecret {
+		t.Errorf("expected controller to update secret")
+	}
+
+	actual := actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+	if len(actual.Data["tls.crt"]) == 0 || len(actual.Data["tls.key"]) == 0 {
+		t.Error(actual.Data)
+	}
+	if actual.Annotations["auth.openshift.io/certificate-not-before"] == "2108-09-08T20:47:31-07:00" {
+		t.Errorf("expected a new signing cert, got annotations: %v", actual.Annotations)
+	}
+	if _, found := actual.Annotations[CertificateForceRotationAnnotation]; found {
+		t.Errorf("expected the %q annotation to be removed, got: %v", CertificateForceRotationAnnotation, actual.Annotations)
+	}
+}
+
+func TestNeedNewSigningCertKeyPairWithRefreshPercentage(t *testing.T) {
+	tests := []struct {
+		name              string
+		lifetime          time.Duration
+		elapsed           time.Duration
+		refresh           time.Duration
+		refreshPercentage int
+		expected          string
+	}{
+		{
+			name:              "short lifetime before the refresh percentage",
+			lifetime:          time.Hour,
+			elapsed:           30 * time.Minute,
+			refreshPercentage: 60,
+		},
+		{
+			name:              "short lifetime past the refresh percentage",
+			lifetime:          time.Hour,
+			elapsed:           40 * time.Minute,
+			refreshPercentage: 60,
+			expected:          "past its refresh time",
+		},
+		{
+			name:              "long lifetime before the refresh percentage",
+			lifetime:          30 * 24 * time.Hour,
+			elapsed:           15 * 24 * time.Hour,
+			refreshPercentage: 60,
+		},
+		{
+			name:              "long lifetime past the refresh percentage",
+			lifetime:          30 * 24 * time.Hour,
+			elapsed:           20 * 24 * time.Hour,
+			refreshPercentage: 60,
+			expected:          "past its refresh time",
+		},
+		{
+			name:              "refresh earlier than the refresh percentage",
+			lifetime:          time.Hour,
+			elapsed:           20 * time.Minute,
+			refresh:           10 * time.Minute,
+			refreshPercentage: 60,
+			expected:          "past its refresh time",
+		},
+		{
+			name:              "refresh percentage later than 80% of validity",
+			lifetime:          time.Hour,
+			elapsed:           50 * time.Minute,
+			refreshPercentage: 90,
+			expected:          "past refresh time (80% of validity)",
+		},
+		{
+			name:     "no refresh percentage",
+			lifetime: time.Hour,
+			elapsed:  40 * time.Minute,
+			refresh:  45 * time.Minute,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notBefore := time.Now().Add(-test.elapsed)
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				CertificateNotBeforeAnnotation: notBefore.Format(time.RFC3339),
+				CertificateNotAfterAnnotation:  notBefore.Add(test.lifetime).Format(time.RFC3339),
+			}}}
+
+			refresh := refreshFromAnnotations(secret.Annotations, test.refresh, test.refreshPercentage)
+			needed, reason := needNewSigningCertKeyPair(secret, refresh, false)
+			if needed != (len(test.expected) > 0) || !strings.HasPrefix(reason, test.expected) {
+				t.Errorf("expected %q, got %v %q", test.expected, needed, reason)
+			}
+		})
+	}
+}
@@ -26,7 +26,7 @@ import (
 // RotatedSelfSignedCertKeySecret rotates a key and cert signed by a signing CA and stores it in a secret.
 //
 // It creates a new one when
-// - refresh duration is over
+// - refresh duration or refresh percentage of validity is over
 // - or 80% of validity is over (if RefreshOnlyWhenExpired is false)
 // - or the cert is expired.
 // - or the signing CA changes.
@@ -43,6 +43,11 @@ type RotatedSelfSignedCertKeySecret struct {
 	// Refresh is ignored until the signing CA at least 10% in its life-time to ensure it is deployed
 	// through-out the cluster.
 	Refresh time.Duration
+	// RefreshPercentage, if set, rotates the key and certificate once the given percentage of the certificate lifetime,
+	// from its notBefore to its notAfter, is over, so that the rotation adapts to the validity of the certificate.
+	// The earlier of Refresh and RefreshPercentage applies, a zero Refresh is ignored. The certificate is still rotated
+	// when 80% of validity is reached, whatever the percentage. It is ignored if RefreshOnlyWhenExpired is true.
+	RefreshPercentage int
 	// RefreshOnlyWhenExpired allows rotating only certs that are already expired. (for autorecovery)
 	// If false (regular flow) it rotates at the refresh interval but no later then 4/5 of the cert lifetime.
 
@@ -119,11 +124,18 @@ func (c RotatedSelfSignedCertKeySecret) EnsureTargetCertKeyPair(ctx context.Cont
 		updateRequired = needsMetadataUpdate || needsTypeChange
 	}
 
-	if reason := c.CertCreator.NeedNewTargetCertKeyPair(targetCertKeyPairSecret, signingCertKeyPair, caBundleCerts, c.Refresh, c.RefreshOnlyWhenExpired, creationRequired); len(reason) > 0 {
+	// a forced rotation doesn't depend on the CertCreator
+	reason := forcedRotationReason(targetCertKeyPairSecret.ObjectMeta)
+	if len(reason) == 0 {
+		refresh := refreshFromAnnotations(targetCertKeyPairSecret.Annotations, c.Refresh, c.RefreshPercentage)
+		reason = c.CertCreator.NeedNewTargetCertKeyPair(targetCertKeyPairSecret, signingCertKeyPair, caBundleCerts, refresh, c.RefreshOnlyWhenExpired, creationRequired)
+	}
+	if len(reason) > 0 {
 		c.EventRecorder.Eventf("TargetUpdateRequired", "%q in %q requires a new target cert/key pair: %v", c.Name, c.Namespace, reason)
-		if err = setTargetCertKeyPairSecretAndTLSAnnotations(targetCertKeyPairSecret, c.Validity, c.Refresh, signingCertKeyPair, c.CertCreator, c.AdditionalAnnotations); err != nil {
+		if err = setTargetCertKeyPairSecretAndTLSAnnotations(targetCertKeyPairSecret, c.Validity, refreshForLifetime(c.Refresh, c.RefreshPercentage, c.Validity), signingCertKeyPair, c.CertCreator, c.AdditionalAnnotations); err != nil {
 			return nil, err
 		}
+		delete(targetCertKeyPairSecret.Annotations, CertificateForceRotationAnnotation)
 
 		LabelAsManagedSecret(targetCertKeyPairSecret, CertificateTypeTarget)
 
@@ -295,10 +307,13 @@ func setTLSAnnotationsOnTargetCertKeyPairSecret(targetCertKeyPairSecret *corev1.
 
 type ClientRotation struct {
 	UserInfo user.Info
+	// CertificateExtensionFn allows to customize the issued certificates, e.g. their extended key usages
+	// by crypto.WithExtKeyUsages. They are only valid for client authentication by default.
+	CertificateExtensionFn []crypto.CertificateExtensionFunc
 }
 
 func (r *ClientRotation) NewCertificate(signer *crypto.CA, validity time.Duration) (*crypto.TLSCertificateConfig, error) {
-	return signer.MakeClientCertificateForDuration(r.UserInfo, validity)
+	return signer.MakeClientCertificateForDuration(r.UserInfo, validity, r.CertificateExtensionFn...)
 }
 
 func (r *ClientRotation) NeedNewTargetCertKeyPair(currentCertSecret *corev1.Secret, signer *crypto.CA, caBundleCerts []*x509.Certificate, refresh time.Duration, refreshOnlyWhenExpired, exists bool) string {
@@ -264,6 +264,52 @@ func TestEnsureTargetCertKeyPair(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "forced rotation when RefreshOnlyWhenExpired set",
+			caFn: func() (*crypto.CA, error) {
+				return newTestCACertificate(pkix.Name{CommonName: "signer-tests"}, int64(1), metav1.Duration{Duration: time.Hour * 24 * 60}, time.Now)
+			},
+			RefreshOnlyWhenExpired: true,
+			initialSecretFn: func() *corev1.Secret {
+				caBundleSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace:       "ns",
+						Name:            "target-secret",
+						ResourceVersion: "10",
+						Annotations: map[string]string{
+							"auth.openshift.io/certificate-not-after":  "2108-09-08T22:47:31-07:00",
+							"auth.openshift.io/certificate-not-before": "2108-09-08T20:47:31-07:00",
+							"auth.openshift.io/certificate-issuer":     "signer-tests",
+							"auth.openshift.io/certificate-hostnames":  "foo,bar",
+							CertificateForceRotationAnnotation:         "key leaked",
+						},
+					},
+					Data: map[string][]byte{},
+					Type: corev1.SecretTypeTLS,
+				}
+				return caBundleSecret
+			},
+			verifyActions: func(t *testing.T, client *kubefake.Clientset) {
+				actions := client.Actions()
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("update", "secrets") {
+					t.Error(actions[0])
+				}
+
+				actual := actions[0].(clienttesting.UpdateAction).GetObject().(*corev1.Secret)
+				if len(actual.Data["tls.crt"]) == 0 || len(actual.Data["tls.key"]) == 0 {
+					t.Error(actual.Data)
+				}
+				if actual.Annotations["auth.openshift.io/certificate-not-before"] == "2108-09-08T20:47:31-07:00" {
+					t.Errorf("expected a new target cert, got annotations: %v", actual.Annotations)
+				}
+				if _, found := actual.Annotations[CertificateForceRotationAnnotation]; found {
+					t.Errorf("expected the %q annotation to be removed, got: %v", CertificateForceRotationAnnotation, actual.Annotations)
+				}
+			},
+		},
 	}
 
 	for _, test := range tests {
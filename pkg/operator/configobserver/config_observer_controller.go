@@ -300,3 +300,30 @@ func WithPrefix(observer ObserveConfigFunc, prefix ...string) ObserveConfigFunc
 
 	}
 }
+
+// SourceExistsFunc reports whether the source an observer projects its config from is present.
+type SourceExistsFunc func(listers Listers) (bool, error)
+
+// WithRevertToDefault wraps the input observer and makes it revert its owned keys to defaultConfig
+// once its source is absent (e.g. the upstream config object has been deleted), instead of keeping
+// the last observed value around.
+//
+// When the presence of the source cannot be determined, the input observer is called and the error is
+// added to the returned errors.
+func WithRevertToDefault(observer ObserveConfigFunc, sourceExists SourceExistsFunc, defaultConfig map[string]interface{}) ObserveConfigFunc {
+	return func(listers Listers, recorder events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+		exists, err := sourceExists(listers)
+		if err != nil {
+			observedConfig, errs := observer(listers, recorder, existingConfig)
+			return observedConfig, append(errs, fmt.Errorf("unable to determine whether the observed source exists: %w", err))
+		}
+		if exists {
+			return observer(listers, recorder, existingConfig)
+		}
+
+		if defaultConfig == nil {
+			return map[string]interface{}{}, nil
+		}
+		return runtime.DeepCopyJSON(defaultConfig), nil
+	}
+}
@@ -899,3 +899,85 @@ func addPrefixToInterface(i interface{}, prefix ...string) map[string]interface{
 	unstructured.SetNestedField(ret, i, prefix...)
 	return ret
 }
+
+func TestWithRevertToDefault(t *testing.T) {
+	defaultConfig := map[string]interface{}{
+		"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS12"},
+	}
+	observedConfig := map[string]interface{}{
+		"servingInfo": map[string]interface{}{"minTLSVersion": "VersionTLS13"},
+	}
+	testErr := fmt.Errorf("lister failure")
+
+	var sourceExists bool
+	var sourceExistsErr error
+	observer := WithRevertToDefault(
+		func(_ Listers, _ events.Recorder, existingConfig map[string]interface{}) (map[string]interface{}, []error) {
+			if !sourceExists {
+				// mimic observers that keep the previously observed value when their source is missing
+				return existingConfig, nil
+			}
+			return runtime.DeepCopyJSON(observedConfig), nil
+		},
+		func(Listers) (bool, error) {
+			return sourceExists, sourceExistsErr
+		},
+		defaultConfig,
+	)
+
+	steps := []struct {
+		name            string
+		sourceExists    bool
+		sourceExistsErr error
+		expectedConfig  map[string]interface{}
+		expectedErrors  []error
+	}{
+		{
+			name:           "source absent from the start reverts to defaults",
+			sourceExists:   false,
+			expectedConfig: defaultConfig,
+		},
+		{
+			name:           "source present is observed",
+			sourceExists:   true,
+			expectedConfig: observedConfig,
+		},
+		{
+			name:            "unknown presence falls back to the observer",
+			sourceExists:    true,
+			sourceExistsErr: testErr,
+			expectedConfig:  observedConfig,
+			expectedErrors:  []error{fmt.Errorf("unable to determine whether the observed source exists: %w", testErr)},
+		},
+		{
+			name:           "source present to absent reverts to defaults",
+			sourceExists:   false,
+			expectedConfig: defaultConfig,
+		},
+	}
+
+	existingConfig := map[string]interface{}{}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			sourceExists = step.sourceExists
+			sourceExistsErr = step.sourceExistsErr
+
+			gotConfig, errs := observer(&fakeLister{}, events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now())), existingConfig)
+			if !reflect.DeepEqual(gotConfig, step.expectedConfig) {
+				t.Errorf("expected config %v, got %v", step.expectedConfig, gotConfig)
+			}
+			if !reflect.DeepEqual(errs, step.expectedErrors) {
+				t.Errorf("expected errors %v, got %v", step.expectedErrors, errs)
+			}
+			existingConfig = gotConfig
+		})
+	}
+
+	// make sure callers can't mutate the defaults through the returned config
+	sourceExists, sourceExistsErr = false, nil
+	gotConfig, _ := observer(&fakeLister{}, events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now())), nil)
+	unstructured.SetNestedField(gotConfig, "mutated", "servingInfo", "minTLSVersion")
+	if v, _, _ := unstructured.NestedString(defaultConfig, "servingInfo", "minTLSVersion"); v != "VersionTLS12" {
+		t.Errorf("expected the default config to remain untouched, got %q", v)
+	}
+}
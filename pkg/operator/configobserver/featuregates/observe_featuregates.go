@@ -14,7 +14,8 @@ import (
 )
 
 // NewObserveFeatureFlagsFunc produces a configobserver for feature gates.  If non-nil, the featureWhitelist filters
-// feature gates to a known subset (instead of everything).  The featureBlacklist will stop certain features from making
+// feature gates to a known subset (instead of everything), known feature gates that the cluster doesn't report for the
+// current version are ignored.  The featureBlacklist will stop certain features from making
 // it through the list.  The featureBlacklist should be empty, but for a brief time, some featuregates may need to skipped.
 // @smarterclayton will live forever in shame for being the first to require this for "IPv6DualStack".
 func NewObserveFeatureFlagsFunc(featureWhitelist sets.Set[configv1.FeatureGateName], featureBlacklist sets.Set[configv1.FeatureGateName], configPath []string, featureGateAccess FeatureGateAccess) configobserver.ObserveConfigFunc {
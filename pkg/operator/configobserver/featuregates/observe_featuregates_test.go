@@ -114,6 +114,18 @@ func TestObserveFeatureFlags(t *testing.T) {
 			},
 			knownFeatures: sets.New[configv1.FeatureGateName]("CustomFeatureEnabled"),
 		},
+		{
+			name: "known features missing from the cluster are ignored",
+			accessor: NewHardcodedFeatureGateAccess(
+				[]configv1.FeatureGateName{"CustomFeatureEnabled"},
+				[]configv1.FeatureGateName{"CustomFeatureDisabled"},
+			),
+			expectedResult: []string{
+				"CustomFeatureDisabled=false",
+				"CustomFeatureEnabled=true",
+			},
+			knownFeatures: sets.New[configv1.FeatureGateName]("CustomFeatureEnabled", "CustomFeatureDisabled", "UnknownFeature"),
+		},
 		{
 			name: "custom no upgrade and blacklisted features",
 			accessor: NewHardcodedFeatureGateAccess(
@@ -178,3 +190,103 @@ func TestObserveFeatureFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestObserveFeatureFlagsFromFeatureGate(t *testing.T) {
+	configPath := []string{"foo", "bar"}
+
+	featureGateWith := func(featureSet configv1.FeatureSet, enabled, disabled []configv1.FeatureGateName) *configv1.FeatureGate {
+		toAttributes := func(names []configv1.FeatureGateName) []configv1.FeatureGateAttributes {
+			attributes := []configv1.FeatureGateAttributes{}
+			for _, name := range names {
+				attributes = append(attributes, configv1.FeatureGateAttributes{Name: name})
+			}
+			return attributes
+		}
+		return &configv1.FeatureGate{
+			Spec: configv1.FeatureGateSpec{
+				FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: featureSet},
+			},
+			Status: configv1.FeatureGateStatus{
+				FeatureGates: []configv1.FeatureGateDetails{
+					{
+						Version:  "4.16.0",
+						Enabled:  toAttributes([]configv1.FeatureGateName{"PreviousVersionFeature"}),
+						Disabled: toAttributes(nil),
+					},
+					{
+						Version:  "4.17.0",
+						Enabled:  toAttributes(enabled),
+						Disabled: toAttributes(disabled),
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		featureGate    *configv1.FeatureGate
+		knownFeatures  sets.Set[configv1.FeatureGateName]
+		expectedResult []string
+	}{
+		{
+			name: "default feature set",
+			featureGate: featureGateWith(configv1.Default,
+				[]configv1.FeatureGateName{"OpenShiftPodSecurityAdmission"},
+				[]configv1.FeatureGateName{"RetroactiveDefaultStorageClass"},
+			),
+			expectedResult: []string{
+				"OpenShiftPodSecurityAdmission=true",
+				"RetroactiveDefaultStorageClass=false",
+			},
+		},
+		{
+			name: "custom feature set",
+			featureGate: featureGateWith(configv1.CustomNoUpgrade,
+				[]configv1.FeatureGateName{"OpenShiftPodSecurityAdmission", "CustomFeatureEnabled"},
+				[]configv1.FeatureGateName{"RetroactiveDefaultStorageClass", "CustomFeatureDisabled"},
+			),
+			expectedResult: []string{
+				"CustomFeatureDisabled=false",
+				"CustomFeatureEnabled=true",
+				"OpenShiftPodSecurityAdmission=true",
+				"RetroactiveDefaultStorageClass=false",
+			},
+		},
+		{
+			name: "custom feature set with known features",
+			featureGate: featureGateWith(configv1.CustomNoUpgrade,
+				[]configv1.FeatureGateName{"OpenShiftPodSecurityAdmission", "CustomFeatureEnabled"},
+				[]configv1.FeatureGateName{"RetroactiveDefaultStorageClass", "CustomFeatureDisabled"},
+			),
+			knownFeatures: sets.New[configv1.FeatureGateName]("CustomFeatureEnabled", "PreviousVersionFeature", "UnknownFeature"),
+			expectedResult: []string{
+				"CustomFeatureEnabled=true",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			accessor, err := NewHardcodedFeatureGateAccessFromFeatureGate(tc.featureGate, "4.17.0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			eventRecorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+			observeFn := NewObserveFeatureFlagsFunc(tc.knownFeatures, nil, configPath, accessor)
+
+			observed, errs := observeFn(nil, eventRecorder, map[string]interface{}{})
+			if len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			actual, _, err := unstructured.NestedStringSlice(observed, configPath...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			slices.Sort(actual)
+			if !reflect.DeepEqual(tc.expectedResult, actual) {
+				t.Errorf("Unexpected features gates\n  got:      %v\n  expected: %v", actual, tc.expectedResult)
+			}
+		})
+	}
+}
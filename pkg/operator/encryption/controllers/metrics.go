@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8smetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	namespace = "apiserver"
+	subsystem = "encryption"
+)
+
+// metrics provides access to all encryption controller metrics.
+var metrics *encryptionMetrics
+
+func init() {
+	metrics = newEncryptionMetrics(legacyregistry.Register)
+}
+
+// encryptionMetrics instruments the encryption controllers with prometheus metrics.
+type encryptionMetrics struct {
+	lastKeyMigration *k8smetrics.GaugeVec
+}
+
+// newEncryptionMetrics create a new encryptionMetrics, configured with default metric names.
+func newEncryptionMetrics(registerFunc func(k8smetrics.Registerable) error) *encryptionMetrics {
+	// lastKeyMigration allows to alert on stalled migrations, i.e. on resources
+	// not migrated to the current write key for a long time, via time() - value.
+	lastKeyMigration := k8smetrics.NewGaugeVec(
+		&k8smetrics.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "last_key_migration_timestamp_seconds",
+			Help:      "The unix timestamp of the last successful migration of a resource to the current write key, labeled with the full resource name",
+		}, []string{"resource"})
+	registerFunc(lastKeyMigration)
+
+	return &encryptionMetrics{
+		lastKeyMigration: lastKeyMigration,
+	}
+}
+
+func (m *encryptionMetrics) Reset() {
+	m.lastKeyMigration.Reset()
+}
+
+// ObserveKeyMigration records the time a resource was successfully migrated to the current write key
+func (m *encryptionMetrics) ObserveKeyMigration(gr schema.GroupResource, when time.Time) {
+	m.lastKeyMigration.WithLabelValues(gr.String()).Set(float64(when.Unix()))
+}
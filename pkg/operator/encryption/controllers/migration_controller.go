@@ -220,6 +220,10 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 		}
 
 		if alreadyMigrated, _, _ := state.MigratedFor([]schema.GroupResource{gr}, grActualKeys.WriteKey); alreadyMigrated {
+			// report migrations which completed before this process started too
+			if migrated := grActualKeys.WriteKey.Migrated.Timestamp; !migrated.IsZero() {
+				metrics.ObserveKeyMigration(gr, migrated)
+			}
 			continue
 		}
 
@@ -274,6 +278,7 @@ func (c *migrationController) migrateKeysIfNeededAndRevisionStable(ctx context.C
 			errs = append(errs, err)
 			continue
 		}
+		metrics.ObserveKeyMigration(gr, time.Now())
 	}
 
 	return migratingResources, errors.NewAggregate(errs)
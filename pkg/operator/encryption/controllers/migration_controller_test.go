@@ -16,10 +16,12 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	apiserverconfigv1 "k8s.io/apiserver/pkg/apis/apiserver/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	clientgotesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/testutil"
 
 	operatorv1 "github.com/openshift/api/operator/v1"
 
@@ -48,6 +50,8 @@ func TestMigrationController(t *testing.T) {
 		expectedMigratorCalls []string
 		migratorEnsureReplies map[schema.GroupResource]map[string]finishedResultErr
 		migratorPruneReplies  map[schema.GroupResource]error
+		// expectedKeyMigrations holds the resources expected to be reported as migrated by the metrics
+		expectedKeyMigrations []schema.GroupResource
 
 		validateFunc               func(ts *testing.T, actionsKube []clientgotesting.Action, initialSecrets []*corev1.Secret, targetGRs []schema.GroupResource, unstructuredObjs []runtime.Object)
 		validateOperatorClientFunc func(ts *testing.T, operatorClient v1helpers.OperatorClient)
@@ -240,6 +244,9 @@ func TestMigrationController(t *testing.T) {
 				{Group: "", Resource: "secrets"}:    {"1": {finished: false}},
 				{Group: "", Resource: "configmaps"}: {"1": {finished: true}},
 			},
+			expectedKeyMigrations: []schema.GroupResource{
+				{Group: "", Resource: "configmaps"},
+			},
 			expectedActions: []string{
 				"list:pods:kms",
 				"get:secrets:kms",
@@ -337,6 +344,10 @@ func TestMigrationController(t *testing.T) {
 				{Group: "", Resource: "secrets"}:    {"1": {finished: true}},
 				{Group: "", Resource: "configmaps"}: {"1": {finished: true}},
 			},
+			expectedKeyMigrations: []schema.GroupResource{
+				{Group: "", Resource: "secrets"},
+				{Group: "", Resource: "configmaps"},
+			},
 			expectedActions: []string{
 				"list:pods:kms",
 				"get:secrets:kms",
@@ -662,6 +673,8 @@ func TestMigrationController(t *testing.T) {
 				scenario.encryptionSecretSelector,
 				eventRecorder,
 			)
+			metrics.Reset()
+			syncStart := time.Now().Truncate(time.Second)
 			err = target.Sync(context.TODO(), factory.NewSyncContext("test", eventRecorder))
 
 			// validate
@@ -690,10 +703,29 @@ func TestMigrationController(t *testing.T) {
 			if scenario.validateOperatorClientFunc != nil {
 				scenario.validateOperatorClientFunc(t, fakeOperatorClient)
 			}
+			validateKeyMigrationMetrics(t, scenario.targetGRs, scenario.expectedKeyMigrations, syncStart)
 		})
 	}
 }
 
+func validateKeyMigrationMetrics(ts *testing.T, grs []schema.GroupResource, expectedMigrated []schema.GroupResource, after time.Time) {
+	ts.Helper()
+
+	expected := sets.New[schema.GroupResource](expectedMigrated...)
+	for _, gr := range grs {
+		value, err := testutil.GetGaugeMetricValue(metrics.lastKeyMigration.WithLabelValues(gr.String()))
+		if err != nil {
+			ts.Fatal(err)
+		}
+		switch {
+		case expected.Has(gr) && time.Unix(int64(value), 0).Before(after):
+			ts.Errorf("expected the last key migration of %s to be reported after %v, got %v", gr, after, time.Unix(int64(value), 0))
+		case !expected.Has(gr) && value != 0:
+			ts.Errorf("expected no key migration of %s to be reported, got %v", gr, time.Unix(int64(value), 0))
+		}
+	}
+}
+
 func validateSecretsWereAnnotated(ts *testing.T, grs []schema.GroupResource, actions []clientgotesting.Action, expectedSecrets []*corev1.Secret, notExpectedSecrets []*corev1.Secret) {
 	ts.Helper()
 
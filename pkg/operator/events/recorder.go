@@ -17,8 +17,10 @@ import (
 
 // Recorder is a simple event recording interface.
 type Recorder interface {
+	// Event and Eventf record an informational event of the Normal type.
 	Event(reason, message string)
 	Eventf(reason, messageFmt string, args ...interface{})
+	// Warning and Warningf record an event of the Warning type.
 	Warning(reason, message string)
 	Warningf(reason, messageFmt string, args ...interface{})
 
@@ -160,6 +162,7 @@ type recorder struct {
 	involvedObjectRef *corev1.ObjectReference
 	sourceComponent   string
 	clock             clock.PassiveClock
+	annotations       map[string]string
 
 	// TODO: This is not the right way to pass the context, but there is no other way without breaking event interface
 	ctx context.Context
@@ -177,6 +180,18 @@ func (r *recorder) ForComponent(componentName string) Recorder {
 	return &newRecorderForComponent
 }
 
+func (r *recorder) withAnnotations(annotations map[string]string) Recorder {
+	newRecorderWithAnnotations := *r
+	newRecorderWithAnnotations.annotations = mergeAnnotations(r.annotations, annotations)
+	return &newRecorderWithAnnotations
+}
+
+func (r *recorder) forObject(involvedObjectRef *corev1.ObjectReference) Recorder {
+	newRecorderForObject := *r
+	newRecorderForObject.involvedObjectRef = involvedObjectRef
+	return &newRecorderForObject
+}
+
 func (r *recorder) WithContext(ctx context.Context) Recorder {
 	r.ctx = ctx
 	return r
@@ -199,6 +214,7 @@ func (r *recorder) Warningf(reason, messageFmt string, args ...interface{}) {
 // Event emits the normal type event.
 func (r *recorder) Event(reason, message string) {
 	event := makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeNormal, reason, message)
+	event.Annotations = copyAnnotations(r.annotations)
 	ctx := context.Background()
 	if r.ctx != nil {
 		ctx = r.ctx
@@ -211,6 +227,7 @@ func (r *recorder) Event(reason, message string) {
 // Warning emits the warning type event.
 func (r *recorder) Warning(reason, message string) {
 	event := makeEvent(r.clock, r.involvedObjectRef, r.sourceComponent, corev1.EventTypeWarning, reason, message)
+	event.Annotations = copyAnnotations(r.annotations)
 	ctx := context.Background()
 	if r.ctx != nil {
 		ctx = r.ctx
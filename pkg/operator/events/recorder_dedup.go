@@ -0,0 +1,184 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+// EventDedupStore remembers which events were emitted recently.
+type EventDedupStore interface {
+	// EmittedRecently returns true if an event with the given key was recorded as emitted within the TTL of the store.
+	EmittedRecently(key string) bool
+	// RecordEmitted records that an event with the given key was emitted now.
+	RecordEmitted(key string) error
+}
+
+// FileEventDedupStore is an EventDedupStore persisted in a file, so that it survives restarts of the process.
+// The file holds the time every key was last emitted at, the keys older than the TTL are dropped.
+type FileEventDedupStore struct {
+	path  string
+	ttl   time.Duration
+	clock clock.PassiveClock
+
+	lock      sync.Mutex
+	lastEmits map[string]time.Time
+}
+
+var _ EventDedupStore = &FileEventDedupStore{}
+
+// NewFileEventDedupStore returns a store persisted at path, usually on a volume that outlives the container.
+// The content of an existing file is loaded, a missing file is created on the first recorded event.
+func NewFileEventDedupStore(path string, ttl time.Duration, clock clock.PassiveClock) (*FileEventDedupStore, error) {
+	s := &FileEventDedupStore{
+		path:      path,
+		ttl:       ttl,
+		clock:     clock,
+		lastEmits: map[string]time.Time{},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the event dedup store %q: %w", path, err)
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.lastEmits); err != nil {
+		return nil, fmt.Errorf("unable to decode the event dedup store %q: %w", path, err)
+	}
+	s.pruneLocked()
+	return s, nil
+}
+
+func (s *FileEventDedupStore) EmittedRecently(key string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	lastEmit, ok := s.lastEmits[key]
+	return ok && s.clock.Since(lastEmit) < s.ttl
+}
+
+func (s *FileEventDedupStore) RecordEmitted(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.lastEmits[key] = s.clock.Now()
+	s.pruneLocked()
+	return s.persistLocked()
+}
+
+// pruneLocked drops the keys emitted longer than the TTL ago.
+func (s *FileEventDedupStore) pruneLocked() {
+	for key, lastEmit := range s.lastEmits {
+		if s.clock.Since(lastEmit) >= s.ttl {
+			delete(s.lastEmits, key)
+		}
+	}
+}
+
+// persistLocked writes the store to a temporary file renamed over the previous one,
+// so that a restart in the middle of the write doesn't leave a truncated file behind.
+func (s *FileEventDedupStore) persistLocked() error {
+	data, err := json.Marshal(s.lastEmits)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("unable to write the event dedup store %q: %w", s.path, err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("unable to write the event dedup store %q: %w", s.path, err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("unable to write the event dedup store %q: %w", s.path, err)
+	}
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return fmt.Errorf("unable to write the event dedup store %q: %w", s.path, err)
+	}
+	return nil
+}
+
+// NewDeduplicatingEventClient returns an event client that drops the creation of an event with the same reason,
+// message and involved object as an event recorded in the store, typically one emitted right before the process
+// restarted. It is meant to be passed to the recorder constructors, e.g. NewKubeRecorder, in place of the client.
+//
+// The event is still returned when its creation is dropped, as if it was created. A failure to record the event
+// in the store doesn't prevent the event from being created.
+func NewDeduplicatingEventClient(delegate corev1client.EventInterface, store EventDedupStore) corev1client.EventInterface {
+	return &deduplicatingEventClient{
+		EventInterface: delegate,
+		store:          store,
+	}
+}
+
+type deduplicatingEventClient struct {
+	corev1client.EventInterface
+	store EventDedupStore
+}
+
+func (c *deduplicatingEventClient) Create(ctx context.Context, event *corev1.Event, opts metav1.CreateOptions) (*corev1.Event, error) {
+	return c.create(event, func() (*corev1.Event, error) {
+		return c.EventInterface.Create(ctx, event, opts)
+	})
+}
+
+// CreateWithEventNamespace is used by the event sink of the upstream recorder.
+func (c *deduplicatingEventClient) CreateWithEventNamespace(event *corev1.Event) (*corev1.Event, error) {
+	return c.create(event, func() (*corev1.Event, error) {
+		return c.EventInterface.CreateWithEventNamespace(event)
+	})
+}
+
+func (c *deduplicatingEventClient) CreateWithEventNamespaceWithContext(ctx context.Context, event *corev1.Event) (*corev1.Event, error) {
+	return c.create(event, func() (*corev1.Event, error) {
+		return c.EventInterface.CreateWithEventNamespaceWithContext(ctx, event)
+	})
+}
+
+func (c *deduplicatingEventClient) create(event *corev1.Event, createFn func() (*corev1.Event, error)) (*corev1.Event, error) {
+	key := eventDedupKey(event)
+	if c.store.EmittedRecently(key) {
+		klog.V(4).Infof("Skipping event %s/%s: %q, it was emitted recently", event.Namespace, event.Reason, event.Message)
+		return event, nil
+	}
+
+	created, err := createFn()
+	if err != nil {
+		return created, err
+	}
+	if err := c.store.RecordEmitted(key); err != nil {
+		klog.Warningf("Unable to record event %s/%s in the dedup store: %v", event.Namespace, event.Reason, err)
+	}
+	return created, nil
+}
+
+// eventDedupKey identifies an event by its reason, message and involved object.
+func eventDedupKey(event *corev1.Event) string {
+	obj := event.InvolvedObject
+	hash := sha256.New()
+	for _, curr := range []string{obj.APIVersion, obj.Kind, obj.Namespace, obj.Name, string(obj.UID), event.Reason, event.Message} {
+		hash.Write([]byte(curr))
+		// separate the fields so that they can't bleed into each other
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
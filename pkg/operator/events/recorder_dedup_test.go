@@ -0,0 +1,105 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestDeduplicatingEventClientAcrossRestarts(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "events.json")
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	ref := fakeControllerRef(t)
+
+	// start returns a recorder backed by a store loaded from disk, as after a restart of the operator
+	start := func(t *testing.T) (Recorder, *fake.Clientset) {
+		store, err := NewFileEventDedupStore(storePath, time.Hour, fakeClock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := fake.NewSimpleClientset()
+		return NewRecorder(NewDeduplicatingEventClient(client.CoreV1().Events("test-namespace"), store), "test-operator", ref, fakeClock), client
+	}
+	countCreated := func(client *fake.Clientset) int {
+		count := 0
+		for _, action := range client.Actions() {
+			if action.Matches("create", "events") {
+				count++
+			}
+		}
+		return count
+	}
+
+	recorder, client := start(t)
+	recorder.Event("TestReason", "foo")
+	recorder.Event("TestReason", "foo")
+	if created := countCreated(client); created != 1 {
+		t.Fatalf("expected the repeated event to be created once, got %d", created)
+	}
+
+	recorder, client = start(t)
+	recorder.Event("TestReason", "foo")
+	if created := countCreated(client); created != 0 {
+		t.Errorf("expected the event emitted before the restart not to be created again, got %d", created)
+	}
+	recorder.Event("TestReason", "bar")
+	recorder.Warning("OtherReason", "foo")
+	ForObject(recorder, &corev1.ObjectReference{Kind: "Secret", Namespace: "test-namespace", Name: "other", APIVersion: "v1"}).Event("TestReason", "foo")
+	if created := countCreated(client); created != 3 {
+		t.Errorf("expected the events with a different message, reason or object to be created, got %d", created)
+	}
+
+	fakeClock.Step(2 * time.Hour)
+	recorder, client = start(t)
+	recorder.Event("TestReason", "foo")
+	if created := countCreated(client); created != 1 {
+		t.Errorf("expected the event to be created again after the TTL, got %d", created)
+	}
+}
+
+func TestFileEventDedupStore(t *testing.T) {
+	t.Run("corrupted", func(t *testing.T) {
+		storePath := filepath.Join(t.TempDir(), "events.json")
+		if err := os.WriteFile(storePath, []byte("{"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewFileEventDedupStore(storePath, time.Hour, clocktesting.NewFakeClock(time.Now())); err == nil {
+			t.Error("expected an error for a corrupted store")
+		}
+	})
+
+	t.Run("expired keys are pruned", func(t *testing.T) {
+		storePath := filepath.Join(t.TempDir(), "events.json")
+		fakeClock := clocktesting.NewFakeClock(time.Now())
+		store, err := NewFileEventDedupStore(storePath, time.Hour, fakeClock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.RecordEmitted("old"); err != nil {
+			t.Fatal(err)
+		}
+		fakeClock.Step(2 * time.Hour)
+		if err := store.RecordEmitted("new"); err != nil {
+			t.Fatal(err)
+		}
+
+		restarted, err := NewFileEventDedupStore(storePath, time.Hour, fakeClock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restarted.EmittedRecently("old") {
+			t.Error("expected the expired key to be pruned")
+		}
+		if !restarted.EmittedRecently("new") {
+			t.Error("expected the recent key to be persisted")
+		}
+		if len(restarted.lastEmits) != 1 {
+			t.Errorf("expected a single key to be persisted, got %v", restarted.lastEmits)
+		}
+	})
+}
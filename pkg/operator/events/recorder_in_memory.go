@@ -11,10 +11,14 @@ import (
 )
 
 type inMemoryEventRecorder struct {
-	events []*corev1.Event
-	source string
-	clock  clock.PassiveClock
-	ctx    context.Context
+	events            []*corev1.Event
+	source            string
+	clock             clock.PassiveClock
+	ctx               context.Context
+	annotations       map[string]string
+	involvedObjectRef *corev1.ObjectReference
+	// parent is the recorder storing the events of a derived recorder, nil for the recorder returned by NewInMemoryRecorder
+	parent *inMemoryEventRecorder
 	sync.Mutex
 }
 
@@ -54,6 +58,55 @@ func (r *inMemoryEventRecorder) ForComponent(component string) Recorder {
 	return r
 }
 
+// withAnnotations returns a recorder setting the given annotations on the events, in addition to the annotations
+// of the current recorder. The events are still stored in the current recorder and available via its Events() method.
+func (r *inMemoryEventRecorder) withAnnotations(annotations map[string]string) Recorder {
+	r.Lock()
+	defer r.Unlock()
+	derived := r.derive()
+	derived.annotations = mergeAnnotations(r.annotations, annotations)
+	return derived
+}
+
+// derive returns a copy of the recorder, which stores its events in the same recorder.
+// The caller must hold the lock of the receiver.
+func (r *inMemoryEventRecorder) derive() *inMemoryEventRecorder {
+	return &inMemoryEventRecorder{
+		source:            r.source,
+		clock:             r.clock,
+		ctx:               r.ctx,
+		annotations:       r.annotations,
+		involvedObjectRef: r.involvedObjectRef,
+		parent:            r.store(),
+	}
+}
+
+// store returns the recorder storing the events of the receiver.
+func (r *inMemoryEventRecorder) store() *inMemoryEventRecorder {
+	if r.parent != nil {
+		return r.parent
+	}
+	return r
+}
+
+// forObject returns a recorder referencing the given object in the events instead of the involved object of the
+// current recorder. The events are still stored in the current recorder and available via its Events() method.
+func (r *inMemoryEventRecorder) forObject(involvedObjectRef *corev1.ObjectReference) Recorder {
+	r.Lock()
+	defer r.Unlock()
+	derived := r.derive()
+	derived.involvedObjectRef = involvedObjectRef
+	return derived
+}
+
+// involvedObject returns the reference set by forObject, or the dummy one.
+func (r *inMemoryEventRecorder) involvedObject() *corev1.ObjectReference {
+	if r.involvedObjectRef != nil {
+		return r.involvedObjectRef
+	}
+	return &inMemoryDummyObjectReference
+}
+
 func (r *inMemoryEventRecorder) WithContext(ctx context.Context) Recorder {
 	r.ctx = ctx
 	return r
@@ -65,14 +118,26 @@ func (r *inMemoryEventRecorder) WithComponentSuffix(suffix string) Recorder {
 
 // Events returns list of recorded events
 func (r *inMemoryEventRecorder) Events() []*corev1.Event {
-	return r.events
+	return r.store().events
+}
+
+// record stores the event, the caller must hold the lock of the receiver.
+func (r *inMemoryEventRecorder) record(event *corev1.Event) {
+	if r.parent == nil {
+		r.events = append(r.events, event)
+		return
+	}
+	r.parent.Lock()
+	defer r.parent.Unlock()
+	r.parent.events = append(r.parent.events, event)
 }
 
 func (r *inMemoryEventRecorder) Event(reason, message string) {
 	r.Lock()
 	defer r.Unlock()
-	event := makeEvent(r.clock, &inMemoryDummyObjectReference, r.source, corev1.EventTypeNormal, reason, message)
-	r.events = append(r.events, event)
+	event := makeEvent(r.clock, r.involvedObject(), r.source, corev1.EventTypeNormal, reason, message)
+	event.Annotations = copyAnnotations(r.annotations)
+	r.record(event)
 }
 
 func (r *inMemoryEventRecorder) Eventf(reason, messageFmt string, args ...interface{}) {
@@ -82,9 +147,10 @@ func (r *inMemoryEventRecorder) Eventf(reason, messageFmt string, args ...interf
 func (r *inMemoryEventRecorder) Warning(reason, message string) {
 	r.Lock()
 	defer r.Unlock()
-	event := makeEvent(r.clock, &inMemoryDummyObjectReference, r.source, corev1.EventTypeWarning, reason, message)
+	event := makeEvent(r.clock, r.involvedObject(), r.source, corev1.EventTypeWarning, reason, message)
+	event.Annotations = copyAnnotations(r.annotations)
 	klog.Info(event.String())
-	r.events = append(r.events, event)
+	r.record(event)
 }
 
 func (r *inMemoryEventRecorder) Warningf(reason, messageFmt string, args ...interface{}) {
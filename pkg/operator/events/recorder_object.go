@@ -0,0 +1,28 @@
+package events
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// objectReferencingRecorder is implemented by recorders that are able to emit events about a given object.
+type objectReferencingRecorder interface {
+	// forObject returns a recorder that sets the given reference as the involved object of every emitted event.
+	forObject(involvedObjectRef *corev1.ObjectReference) Recorder
+}
+
+// ForObject returns a recorder that emits events about the given object rather than the object the delegate
+// was created for, usually the operator deployment, so that they show up in `kubectl describe` of the object
+// that triggered them. The object must either be cluster scoped or live in the namespace the delegate emits
+// events to; the source component and annotations of the delegate are kept.
+//
+// The recorders provided by this package, except the logging one, all support this. Delegates that don't
+// are returned as-is.
+func ForObject(delegate Recorder, involvedObjectRef *corev1.ObjectReference) Recorder {
+	referencing, ok := delegate.(objectReferencingRecorder)
+	if !ok {
+		klog.V(4).Infof("Event recorder %T does not support setting the involved object, events will not reference %s/%s", delegate, involvedObjectRef.Namespace, involvedObjectRef.Name)
+		return delegate
+	}
+	return referencing.forObject(involvedObjectRef.DeepCopy())
+}
@@ -0,0 +1,108 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestForObject(t *testing.T) {
+	objectRef := &corev1.ObjectReference{
+		Kind:       "ConfigMap",
+		Namespace:  "test-namespace",
+		Name:       "trigger",
+		UID:        "0c2de1b1-7c74-4fb5-a8b6-7f7a3c8c1cb5",
+		APIVersion: "v1",
+	}
+
+	client := fake.NewSimpleClientset()
+	operatorRecorder := NewVersionedRecorder(NewRecorder(client.CoreV1().Events("test-namespace"), "test-operator", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now())), "4.16.0")
+	r := ForObject(operatorRecorder, objectRef)
+
+	r.Warning("TestReason", "foo")
+	operatorRecorder.Event("OtherReason", "bar")
+
+	var createdEvents []*corev1.Event
+	for _, action := range client.Actions() {
+		if action.Matches("create", "events") {
+			createdEvents = append(createdEvents, action.(clientgotesting.CreateAction).GetObject().(*corev1.Event))
+		}
+	}
+	if len(createdEvents) != 2 {
+		t.Fatalf("expected 2 events to be created, got %d", len(createdEvents))
+	}
+
+	objectEvent := createdEvents[0]
+	if objectEvent.InvolvedObject != *objectRef {
+		t.Errorf("expected the involved object to be %#v, got %#v", *objectRef, objectEvent.InvolvedObject)
+	}
+	if objectEvent.Namespace != "test-namespace" {
+		t.Errorf("expected the event in test-namespace, got %q", objectEvent.Namespace)
+	}
+	if objectEvent.Source.Component != "test-operator" {
+		t.Errorf("expected the source component to be kept, got %q", objectEvent.Source.Component)
+	}
+	if version := objectEvent.Annotations[OperatorVersionAnnotation]; version != "4.16.0" {
+		t.Errorf("expected the annotations to be kept, got %v", objectEvent.Annotations)
+	}
+
+	if operatorEvent := createdEvents[1]; operatorEvent.InvolvedObject.Kind != "Deployment" || operatorEvent.InvolvedObject.Name != "test" {
+		t.Errorf("expected the original recorder to keep referencing the operator deployment, got %#v", operatorEvent.InvolvedObject)
+	}
+}
+
+func TestForObjectInMemoryRecorder(t *testing.T) {
+	objectRef := &corev1.ObjectReference{Kind: "Secret", Namespace: "test-namespace", Name: "trigger", UID: "uid", APIVersion: "v1"}
+	inMemoryRecorder := NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	inMemoryRecorder.Event("BeforeReason", "foo")
+	ForObject(inMemoryRecorder, objectRef).Warning("TestReason", "bar")
+
+	recordedEvents := inMemoryRecorder.Events()
+	if len(recordedEvents) != 2 {
+		t.Fatalf("expected 2 events to be recorded, got %d", len(recordedEvents))
+	}
+	if recordedEvents[0].InvolvedObject != inMemoryDummyObjectReference {
+		t.Errorf("expected the dummy involved object, got %#v", recordedEvents[0].InvolvedObject)
+	}
+	if recordedEvents[1].InvolvedObject != *objectRef {
+		t.Errorf("expected the involved object to be %#v, got %#v", *objectRef, recordedEvents[1].InvolvedObject)
+	}
+}
+
+func TestForObjectInMemoryRecorderKeepsBaseObject(t *testing.T) {
+	objectRef := &corev1.ObjectReference{Kind: "Secret", Namespace: "test-namespace", Name: "trigger", UID: "uid", APIVersion: "v1"}
+	otherObjectRef := &corev1.ObjectReference{Kind: "ConfigMap", Namespace: "test-namespace", Name: "other", UID: "other-uid", APIVersion: "v1"}
+	inMemoryRecorder := NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	objectRecorder := ForObject(inMemoryRecorder, objectRef)
+	otherObjectRecorder := ForObject(inMemoryRecorder, otherObjectRef)
+	inMemoryRecorder.Event("BaseReason", "foo")
+	objectRecorder.Event("ObjectReason", "bar")
+	otherObjectRecorder.Event("OtherObjectReason", "baz")
+
+	recordedEvents := inMemoryRecorder.Events()
+	if len(recordedEvents) != 3 {
+		t.Fatalf("expected 3 events to be recorded, got %d", len(recordedEvents))
+	}
+	if recordedEvents[0].InvolvedObject != inMemoryDummyObjectReference {
+		t.Errorf("expected the base recorder to keep the dummy involved object, got %#v", recordedEvents[0].InvolvedObject)
+	}
+	if recordedEvents[1].InvolvedObject != *objectRef {
+		t.Errorf("expected the involved object to be %#v, got %#v", *objectRef, recordedEvents[1].InvolvedObject)
+	}
+	if recordedEvents[2].InvolvedObject != *otherObjectRef {
+		t.Errorf("expected the involved object to be %#v, got %#v", *otherObjectRef, recordedEvents[2].InvolvedObject)
+	}
+}
+
+func TestForObjectUnsupportedDelegate(t *testing.T) {
+	delegate := NewLoggingEventRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	if r := ForObject(delegate, &corev1.ObjectReference{Kind: "Secret", Name: "trigger"}); r != delegate {
+		t.Errorf("expected the delegate to be returned as-is, got %T", r)
+	}
+}
@@ -187,3 +187,46 @@ func TestGetControllerReferenceForCurrentPodFallbackNamespace(t *testing.T) {
 		t.Errorf("expected objectReference to be Namespace, got %q", objectReference.GroupVersionKind().String())
 	}
 }
+
+func TestRecorderEventTypes(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	recorders := map[string]Recorder{
+		"recorder":           NewRecorder(client.CoreV1().Events("test-namespace"), "test-operator", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now())),
+		"in-memory recorder": NewInMemoryRecorder("test-operator", clocktesting.NewFakePassiveClock(time.Now())),
+	}
+	for name, r := range recorders {
+		t.Run(name, func(t *testing.T) {
+			client.ClearActions()
+			r.Event("Event", "foo")
+			r.Eventf("Eventf", "foo %d", 1)
+			r.Warning("Warning", "foo")
+			r.Warningf("Warningf", "foo %d", 1)
+
+			var recordedEvents []*corev1.Event
+			if inMemory, ok := r.(InMemoryRecorder); ok {
+				recordedEvents = inMemory.Events()
+			} else {
+				for _, action := range client.Actions() {
+					if action.Matches("create", "events") {
+						recordedEvents = append(recordedEvents, action.(clientgotesting.CreateAction).GetObject().(*corev1.Event))
+					}
+				}
+			}
+
+			expectedTypes := map[string]string{
+				"Event":    corev1.EventTypeNormal,
+				"Eventf":   corev1.EventTypeNormal,
+				"Warning":  corev1.EventTypeWarning,
+				"Warningf": corev1.EventTypeWarning,
+			}
+			if len(recordedEvents) != len(expectedTypes) {
+				t.Fatalf("expected %d events, got %d", len(expectedTypes), len(recordedEvents))
+			}
+			for _, event := range recordedEvents {
+				if expectedType := expectedTypes[event.Reason]; event.Type != expectedType {
+					t.Errorf("expected event %q to be of type %q, got %q", event.Reason, expectedType, event.Type)
+				}
+			}
+		})
+	}
+}
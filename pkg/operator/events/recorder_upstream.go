@@ -41,6 +41,7 @@ type upstreamRecorder struct {
 	eventRecorder     record.EventRecorder
 	involvedObjectRef *corev1.ObjectReference
 	options           record.CorrelatorOptions
+	annotations       map[string]string
 
 	// shuttingDown indicates that the broadcaster for this recorder is being shut down
 	shuttingDown  bool
@@ -99,6 +100,7 @@ func (r *upstreamRecorder) ForComponent(componentName string) Recorder {
 		fallbackRecorder:  r.fallbackRecorder.WithComponentSuffix(componentName),
 		options:           r.options,
 		involvedObjectRef: r.involvedObjectRef,
+		annotations:       r.annotations,
 		shuttingDown:      r.shuttingDown,
 	}
 
@@ -114,6 +116,40 @@ func (r *upstreamRecorder) ForComponent(componentName string) Recorder {
 	return &newRecorderForComponent
 }
 
+func (r *upstreamRecorder) withAnnotations(annotations map[string]string) Recorder {
+	r.shutdownMutex.RLock()
+	defer r.shutdownMutex.RUnlock()
+	return &upstreamRecorder{
+		client:            r.client,
+		clientCtx:         r.clientCtx,
+		component:         r.component,
+		broadcaster:       r.broadcaster,
+		eventRecorder:     r.eventRecorder,
+		involvedObjectRef: r.involvedObjectRef,
+		options:           r.options,
+		annotations:       mergeAnnotations(r.annotations, annotations),
+		shuttingDown:      r.shuttingDown,
+		fallbackRecorder:  annotateRecorder(r.fallbackRecorder, annotations),
+	}
+}
+
+func (r *upstreamRecorder) forObject(involvedObjectRef *corev1.ObjectReference) Recorder {
+	r.shutdownMutex.RLock()
+	defer r.shutdownMutex.RUnlock()
+	return &upstreamRecorder{
+		client:            r.client,
+		clientCtx:         r.clientCtx,
+		component:         r.component,
+		broadcaster:       r.broadcaster,
+		eventRecorder:     r.eventRecorder,
+		involvedObjectRef: involvedObjectRef,
+		options:           r.options,
+		annotations:       r.annotations,
+		shuttingDown:      r.shuttingDown,
+		fallbackRecorder:  ForObject(r.fallbackRecorder, involvedObjectRef),
+	}
+}
+
 func (r *upstreamRecorder) Shutdown() {
 	r.shutdownMutex.Lock()
 	r.shuttingDown = true
@@ -158,7 +194,7 @@ func (r *upstreamRecorder) Event(reason, message string) {
 		r.fallbackRecorder.Event(reason, message)
 		return
 	}
-	r.eventRecorder.Event(r.involvedObjectRef, corev1.EventTypeNormal, reason, message)
+	r.emit(corev1.EventTypeNormal, reason, message)
 }
 
 // Warning emits the warning type event.
@@ -170,5 +206,13 @@ func (r *upstreamRecorder) Warning(reason, message string) {
 		r.fallbackRecorder.Warning(reason, message)
 		return
 	}
-	r.eventRecorder.Event(r.involvedObjectRef, corev1.EventTypeWarning, reason, message)
+	r.emit(corev1.EventTypeWarning, reason, message)
+}
+
+func (r *upstreamRecorder) emit(eventType, reason, message string) {
+	if len(r.annotations) == 0 {
+		r.eventRecorder.Event(r.involvedObjectRef, eventType, reason, message)
+		return
+	}
+	r.eventRecorder.AnnotatedEventf(r.involvedObjectRef, copyAnnotations(r.annotations), eventType, reason, "%s", message)
 }
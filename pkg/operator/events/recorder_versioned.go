@@ -0,0 +1,54 @@
+package events
+
+import (
+	"k8s.io/klog/v2"
+)
+
+// OperatorVersionAnnotation is set on events emitted by a recorder returned by NewVersionedRecorder.
+// It holds the version of the operator that emitted the event.
+const OperatorVersionAnnotation = "operator.openshift.io/version"
+
+// annotatingRecorder is implemented by recorders that are able to set annotations on the events they emit.
+type annotatingRecorder interface {
+	// withAnnotations returns a recorder that sets the given annotations on every emitted event,
+	// in addition to the annotations already set by the current recorder.
+	withAnnotations(annotations map[string]string) Recorder
+}
+
+// NewVersionedRecorder returns a recorder that annotates every event emitted through the delegate
+// with the given operator version. The event reason and message are passed through unchanged.
+//
+// The recorders provided by this package all support annotations. Delegates that don't are
+// returned as-is.
+func NewVersionedRecorder(delegate Recorder, version string) Recorder {
+	return annotateRecorder(delegate, map[string]string{OperatorVersionAnnotation: version})
+}
+
+func annotateRecorder(delegate Recorder, annotations map[string]string) Recorder {
+	annotating, ok := delegate.(annotatingRecorder)
+	if !ok {
+		klog.V(4).Infof("Event recorder %T does not support annotations, events will not carry %v", delegate, annotations)
+		return delegate
+	}
+	return annotating.withAnnotations(annotations)
+}
+
+// mergeAnnotations returns a new map holding the existing annotations overridden by the additional ones.
+func mergeAnnotations(existing, additional map[string]string) map[string]string {
+	ret := make(map[string]string, len(existing)+len(additional))
+	for k, v := range existing {
+		ret[k] = v
+	}
+	for k, v := range additional {
+		ret[k] = v
+	}
+	return ret
+}
+
+// copyAnnotations returns a copy of the given annotations, or nil if there are none.
+func copyAnnotations(annotations map[string]string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	return mergeAnnotations(annotations, nil)
+}
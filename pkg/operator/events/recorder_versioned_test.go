@@ -0,0 +1,74 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestVersionedRecorder(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r := NewVersionedRecorder(NewRecorder(client.CoreV1().Events("test-namespace"), "test-operator", fakeControllerRef(t), clocktesting.NewFakePassiveClock(time.Now())), "4.16.0")
+
+	r.Warningf("TestReason", "foo %s", "bar")
+	r.WithComponentSuffix("sub").Event("OtherReason", "baz")
+
+	var createdEvents []*corev1.Event
+	for _, action := range client.Actions() {
+		if action.Matches("create", "events") {
+			createdEvents = append(createdEvents, action.(clientgotesting.CreateAction).GetObject().(*corev1.Event))
+		}
+	}
+	if len(createdEvents) != 2 {
+		t.Fatalf("expected 2 events to be created, got %d", len(createdEvents))
+	}
+	for _, event := range createdEvents {
+		if version := event.Annotations[OperatorVersionAnnotation]; version != "4.16.0" {
+			t.Errorf("expected event %q to be annotated with version 4.16.0, got %q", event.Reason, version)
+		}
+	}
+	if createdEvents[0].Message != "foo bar" {
+		t.Errorf("expected message to be unchanged, got %q", createdEvents[0].Message)
+	}
+	if createdEvents[1].Source.Component != "test-operator-sub" {
+		t.Errorf("expected event source to be test-operator-sub, got %q", createdEvents[1].Source.Component)
+	}
+}
+
+func TestVersionedInMemoryRecorder(t *testing.T) {
+	inMemoryRecorder := NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	r := NewVersionedRecorder(inMemoryRecorder, "4.16.0")
+
+	r.Event("TestReason", "foo")
+
+	recordedEvents := inMemoryRecorder.Events()
+	if len(recordedEvents) != 1 {
+		t.Fatalf("expected 1 event to be recorded, got %d", len(recordedEvents))
+	}
+	if version := recordedEvents[0].Annotations[OperatorVersionAnnotation]; version != "4.16.0" {
+		t.Errorf("expected event to be annotated with version 4.16.0, got %q", version)
+	}
+	if recordedEvents[0].Message != "foo" {
+		t.Errorf("expected message to be unchanged, got %q", recordedEvents[0].Message)
+	}
+
+	inMemoryRecorder.Event("OtherReason", "bar")
+	recordedEvents = inMemoryRecorder.Events()
+	if len(recordedEvents) != 2 {
+		t.Fatalf("expected 2 events to be recorded, got %d", len(recordedEvents))
+	}
+	if version, ok := recordedEvents[1].Annotations[OperatorVersionAnnotation]; ok {
+		t.Errorf("expected the wrapped recorder not to be annotated with the version, got %q", version)
+	}
+}
+
+func TestVersionedRecorderUnsupportedDelegate(t *testing.T) {
+	delegate := NewLoggingEventRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+	if r := NewVersionedRecorder(delegate, "4.16.0"); r != delegate {
+		t.Errorf("expected the delegate to be returned as-is, got %T", r)
+	}
+}
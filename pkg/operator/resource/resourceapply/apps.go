@@ -163,8 +163,13 @@ func ApplyDeploymentWithForce(ctx context.Context, client appsclientv1.Deploymen
 // ApplyDaemonSet ensures the form of the specified daemonset is present in the API. If it
 // does not exist, it will be created. If it does exist, the metadata of the required
 // daemonset will be merged with the existing daemonset and an update performed if the
-// daemonset spec and metadata differ from the previously required spec and metadata. For
-// further detail, check the top-level comment.
+// daemonset spec and metadata differ from the previously required spec and metadata,
+// as detected by the spec hash annotation, or if the generation of the existing daemonset
+// differs from expectedGeneration. Fields defaulted by the server, e.g. the updateStrategy,
+// therefore don't trigger an update.
+//
+// See ApplyDeployment for how callers are expected to track the generation and to trigger
+// rollouts in response to changes external to the daemonset.
 //
 // NOTE: The previous implementation of this method was renamed to ApplyDaemonSetWithForce. If
 // are reading this in response to a compile error due to the change in signature, you have
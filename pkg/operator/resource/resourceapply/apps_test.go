@@ -11,6 +11,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/utils/ptr"
 
@@ -234,6 +235,104 @@ func TestDeleteDaemonSet(t *testing.T) {
 	}
 }
 
+func TestApplyDaemonSet(t *testing.T) {
+	withSpecHash := func(ds *appsv1.DaemonSet) *appsv1.DaemonSet {
+		if err := resourceapply.SetSpecHashAnnotation(&ds.ObjectMeta, ds.Spec); err != nil {
+			t.Fatal(err)
+		}
+		return ds
+	}
+	withServerDefaults := func(ds *appsv1.DaemonSet) *appsv1.DaemonSet {
+		ds.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{
+			Type: appsv1.RollingUpdateDaemonSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+				MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+				MaxSurge:       ptr.To(intstr.FromInt32(0)),
+			},
+		}
+		ds.Spec.RevisionHistoryLimit = ptr.To[int32](10)
+		return ds
+	}
+	withImage := func(ds *appsv1.DaemonSet, image string) *appsv1.DaemonSet {
+		ds.Spec.Template.Spec.Containers[0].Image = image
+		return ds
+	}
+
+	tests := []struct {
+		name               string
+		desiredDaemonSet   *appsv1.DaemonSet
+		expectedGeneration int64
+		actualDaemonSet    *appsv1.DaemonSet
+
+		expectedUpdate    bool
+		expectedDaemonSet *appsv1.DaemonSet
+	}{
+		{
+			name:              "the daemonset is created because it doesn't exist",
+			desiredDaemonSet:  daemonSet(),
+			expectedDaemonSet: withSpecHash(daemonSet()),
+			expectedUpdate:    true,
+		},
+		{
+			name:              "the daemonset already exists and it's up to date",
+			desiredDaemonSet:  daemonSet(),
+			actualDaemonSet:   withSpecHash(daemonSet()),
+			expectedDaemonSet: withSpecHash(daemonSet()),
+		},
+		{
+			name:              "server defaulted fields don't cause an update",
+			desiredDaemonSet:  daemonSet(),
+			actualDaemonSet:   withServerDefaults(withSpecHash(daemonSet())),
+			expectedDaemonSet: withServerDefaults(withSpecHash(daemonSet())),
+		},
+		{
+			name:             "the actual daemonset was modified by a user and must be updated",
+			desiredDaemonSet: daemonSet(),
+			actualDaemonSet: func() *appsv1.DaemonSet {
+				ds := withSpecHash(daemonSet())
+				ds.Generation = 1
+				return ds
+			}(),
+			expectedDaemonSet: func() *appsv1.DaemonSet {
+				ds := withSpecHash(daemonSet())
+				ds.Generation = 1 // on a real cluster it would be increased by the server
+				return ds
+			}(),
+			expectedUpdate: true,
+		},
+		{
+			name:              "the daemonset is updated due to a change of the image",
+			desiredDaemonSet:  withImage(daemonSet(), "docker-registry/img:new"),
+			actualDaemonSet:   withServerDefaults(withSpecHash(daemonSet())),
+			expectedDaemonSet: withSpecHash(withImage(daemonSet(), "docker-registry/img:new")),
+			expectedUpdate:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventRecorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+			fakeKubeClient := fake.NewSimpleClientset()
+			if tt.actualDaemonSet != nil {
+				fakeKubeClient = fake.NewSimpleClientset(tt.actualDaemonSet)
+			}
+
+			actualDaemonSet, updated, err := resourceapply.ApplyDaemonSet(context.TODO(), fakeKubeClient.AppsV1(), eventRecorder, tt.desiredDaemonSet, tt.expectedGeneration)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.expectedUpdate != updated {
+				t.Fatalf("expected ApplyDaemonSet to report updated=%v", tt.expectedUpdate)
+			}
+			if !equality.Semantic.DeepEqual(actualDaemonSet, tt.expectedDaemonSet) {
+				t.Errorf("DaemonSet is different from the expected one: %s", diff.ObjectDiff(actualDaemonSet, tt.expectedDaemonSet))
+			}
+			if !tt.expectedUpdate && len(fakeKubeClient.Actions()) != 1 {
+				t.Errorf("expected only a get, got %v", fakeKubeClient.Actions())
+			}
+		})
+	}
+}
+
 func TestApplyDeploymentWithForce(t *testing.T) {
 	tests := []struct {
 		name               string
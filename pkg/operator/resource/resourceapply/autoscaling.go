@@ -0,0 +1,134 @@
+package resourceapply
+
+import (
+	"context"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	autoscalingclientv2 "k8s.io/client-go/kubernetes/typed/autoscaling/v2"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// ApplyHorizontalPodAutoscaler merges objectmeta and requires the scale target, the min and max replicas and the metrics
+// to match. The status is populated by the server and ignored. The min replicas, the metrics and the scaling rules of the
+// behavior defaulted by the server are not considered a difference, neither is the behavior when the required spec
+// doesn't set it.
+func ApplyHorizontalPodAutoscaler(ctx context.Context, client autoscalingclientv2.HorizontalPodAutoscalersGetter, recorder events.Recorder, required *autoscalingv2.HorizontalPodAutoscaler) (*autoscalingv2.HorizontalPodAutoscaler, bool, error) {
+	existing, err := client.HorizontalPodAutoscalers(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		requiredCopy.Status = autoscalingv2.HorizontalPodAutoscalerStatus{}
+		actual, err := client.HorizontalPodAutoscalers(required.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*autoscalingv2.HorizontalPodAutoscaler), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	requiredSpec := required.Spec
+	if requiredSpec.Behavior == nil {
+		// the behavior is defaulted and tuned by the server when not set
+		requiredSpec.Behavior = existingCopy.Spec.Behavior
+	}
+	requiredSpec = defaultHorizontalPodAutoscalerSpec(requiredSpec)
+	contentSame := equality.Semantic.DeepEqual(defaultHorizontalPodAutoscalerSpec(existingCopy.Spec), requiredSpec)
+	if contentSame && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Spec = requiredSpec
+
+	if klog.V(2).Enabled() {
+		klog.Infof("HorizontalPodAutoscaler %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.HorizontalPodAutoscalers(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+// defaultHorizontalPodAutoscalerSpec returns a copy of the given spec with the min replicas, the metrics and the behavior
+// defaulted the way the server does: a single replica, a target of 80% of CPU utilization and, when the behavior is set,
+// the scaling rules it leaves out.
+func defaultHorizontalPodAutoscalerSpec(spec autoscalingv2.HorizontalPodAutoscalerSpec) autoscalingv2.HorizontalPodAutoscalerSpec {
+	spec = *spec.DeepCopy()
+	if spec.MinReplicas == nil {
+		spec.MinReplicas = ptr.To[int32](1)
+	}
+	if len(spec.Metrics) == 0 {
+		spec.Metrics = []autoscalingv2.MetricSpec{
+			{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: ptr.To[int32](80),
+					},
+				},
+			},
+		}
+	}
+	if spec.Behavior != nil {
+		spec.Behavior.ScaleUp = defaultHPAScalingRules(spec.Behavior.ScaleUp, autoscalingv2.HPAScalingRules{
+			StabilizationWindowSeconds: ptr.To[int32](0),
+			SelectPolicy:               ptr.To(autoscalingv2.MaxChangePolicySelect),
+			Policies: []autoscalingv2.HPAScalingPolicy{
+				{Type: autoscalingv2.PodsScalingPolicy, Value: 4, PeriodSeconds: 15},
+				{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+			},
+		})
+		spec.Behavior.ScaleDown = defaultHPAScalingRules(spec.Behavior.ScaleDown, autoscalingv2.HPAScalingRules{
+			SelectPolicy: ptr.To(autoscalingv2.MaxChangePolicySelect),
+			Policies: []autoscalingv2.HPAScalingPolicy{
+				{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+			},
+		})
+	}
+	return spec
+}
+
+// defaultHPAScalingRules returns the given defaults overridden by the fields the given rules set.
+// The stabilization window of scaling down isn't defaulted by the server, the controller applies its own.
+func defaultHPAScalingRules(rules *autoscalingv2.HPAScalingRules, defaults autoscalingv2.HPAScalingRules) *autoscalingv2.HPAScalingRules {
+	if rules == nil {
+		return &defaults
+	}
+	if rules.StabilizationWindowSeconds != nil {
+		defaults.StabilizationWindowSeconds = rules.StabilizationWindowSeconds
+	}
+	if rules.SelectPolicy != nil {
+		defaults.SelectPolicy = rules.SelectPolicy
+	}
+	if rules.Policies != nil {
+		defaults.Policies = rules.Policies
+	}
+	defaults.Tolerance = rules.Tolerance
+	return &defaults
+}
+
+func DeleteHorizontalPodAutoscaler(ctx context.Context, client autoscalingclientv2.HorizontalPodAutoscalersGetter, recorder events.Recorder, required *autoscalingv2.HorizontalPodAutoscaler) (*autoscalingv2.HorizontalPodAutoscaler, bool, error) {
+	err := client.HorizontalPodAutoscalers(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
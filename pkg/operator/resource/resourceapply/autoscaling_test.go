@@ -0,0 +1,291 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyHorizontalPodAutoscaler(t *testing.T) {
+	scaleTargetRef := autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "foo"}
+	memoryMetric := func(utilization int32) autoscalingv2.MetricSpec {
+		return autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name:   corev1.ResourceMemory,
+				Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: ptr.To(utilization)},
+			},
+		}
+	}
+	cpuMetric := autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name:   corev1.ResourceCPU,
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: ptr.To[int32](80)},
+		},
+	}
+	scaleUpDefaults := &autoscalingv2.HPAScalingRules{
+		StabilizationWindowSeconds: ptr.To[int32](0),
+		SelectPolicy:               ptr.To(autoscalingv2.MaxChangePolicySelect),
+		Policies: []autoscalingv2.HPAScalingPolicy{
+			{Type: autoscalingv2.PodsScalingPolicy, Value: 4, PeriodSeconds: 15},
+			{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15},
+		},
+	}
+	serverBehavior := &autoscalingv2.HorizontalPodAutoscalerBehavior{
+		ScaleUp: scaleUpDefaults,
+		ScaleDown: &autoscalingv2.HPAScalingRules{
+			StabilizationWindowSeconds: ptr.To[int32](300),
+			SelectPolicy:               ptr.To(autoscalingv2.MaxChangePolicySelect),
+			Policies:                   []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15}},
+		},
+	}
+	status := autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 3, DesiredReplicas: 4}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *autoscalingv2.HorizontalPodAutoscaler
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: scaleTargetRef,
+					MaxReplicas:    5,
+					Metrics:        []autoscalingv2.MetricSpec{memoryMetric(70)},
+				},
+				Status: status,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "horizontalpodautoscalers") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "horizontalpodautoscalers") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[1].(clienttesting.CreateAction).GetObject().(*autoscalingv2.HorizontalPodAutoscaler)
+				if !equality.Semantic.DeepEqual(actual.Status, autoscalingv2.HorizontalPodAutoscalerStatus{}) {
+					t.Errorf("expected the status not to be created, got %s", spew.Sdump(actual.Status))
+				}
+			},
+		},
+		{
+			name: "skip on server defaults and status",
+			existing: []runtime.Object{
+				&autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](1),
+						MaxReplicas:    5,
+						Metrics:        []autoscalingv2.MetricSpec{cpuMetric},
+						Behavior:       serverBehavior,
+					},
+					Status: status,
+				},
+			},
+			input: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: scaleTargetRef,
+					MaxReplicas:    5,
+				},
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "horizontalpodautoscalers") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "skip on server defaults of a partially set behavior",
+			existing: []runtime.Object{
+				&autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](1),
+						MaxReplicas:    5,
+						Metrics:        []autoscalingv2.MetricSpec{cpuMetric},
+						Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+							ScaleUp: &autoscalingv2.HPAScalingRules{
+								StabilizationWindowSeconds: ptr.To[int32](60),
+								SelectPolicy:               ptr.To(autoscalingv2.MaxChangePolicySelect),
+								Policies:                   []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PodsScalingPolicy, Value: 2, PeriodSeconds: 30}},
+							},
+							ScaleDown: &autoscalingv2.HPAScalingRules{
+								SelectPolicy: ptr.To(autoscalingv2.MaxChangePolicySelect),
+								Policies:     []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15}},
+							},
+						},
+					},
+				},
+			},
+			input: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: scaleTargetRef,
+					MaxReplicas:    5,
+					Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+						ScaleUp: &autoscalingv2.HPAScalingRules{
+							StabilizationWindowSeconds: ptr.To[int32](60),
+							Policies:                   []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PodsScalingPolicy, Value: 2, PeriodSeconds: 30}},
+						},
+					},
+				},
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "horizontalpodautoscalers") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update max replicas",
+			existing: []runtime.Object{
+				&autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](2),
+						MaxReplicas:    5,
+						Metrics:        []autoscalingv2.MetricSpec{memoryMetric(70)},
+						Behavior:       serverBehavior,
+					},
+					Status: status,
+				},
+			},
+			input: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: scaleTargetRef,
+					MinReplicas:    ptr.To[int32](2),
+					MaxReplicas:    10,
+					Metrics:        []autoscalingv2.MetricSpec{memoryMetric(70)},
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "horizontalpodautoscalers") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](2),
+						MaxReplicas:    10,
+						Metrics:        []autoscalingv2.MetricSpec{memoryMetric(70)},
+						Behavior:       serverBehavior,
+					},
+					Status: status,
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*autoscalingv2.HorizontalPodAutoscaler)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "update metrics and behavior",
+			existing: []runtime.Object{
+				&autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](1),
+						MaxReplicas:    5,
+						Metrics:        []autoscalingv2.MetricSpec{cpuMetric},
+						Behavior:       serverBehavior,
+					},
+				},
+			},
+			input: &autoscalingv2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: scaleTargetRef,
+					MaxReplicas:    5,
+					Metrics:        []autoscalingv2.MetricSpec{cpuMetric, memoryMetric(70)},
+					Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+						ScaleDown: &autoscalingv2.HPAScalingRules{SelectPolicy: ptr.To(autoscalingv2.DisabledPolicySelect)},
+					},
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "horizontalpodautoscalers") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &autoscalingv2.HorizontalPodAutoscaler{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+						ScaleTargetRef: scaleTargetRef,
+						MinReplicas:    ptr.To[int32](1),
+						MaxReplicas:    5,
+						Metrics:        []autoscalingv2.MetricSpec{cpuMetric, memoryMetric(70)},
+						Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+							ScaleUp: scaleUpDefaults,
+							ScaleDown: &autoscalingv2.HPAScalingRules{
+								SelectPolicy: ptr.To(autoscalingv2.DisabledPolicySelect),
+								Policies:     []autoscalingv2.HPAScalingPolicy{{Type: autoscalingv2.PercentScalingPolicy, Value: 100, PeriodSeconds: 15}},
+							},
+						},
+					},
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*autoscalingv2.HorizontalPodAutoscaler)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyHorizontalPodAutoscaler(context.TODO(), client.AutoscalingV2(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
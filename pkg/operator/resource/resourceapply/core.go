@@ -249,12 +249,16 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 	existingCopy := existing.DeepCopy()
 
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+	// the secrets and image pull secrets are also managed by the token controllers,
+	// so the required ones are added to the existing ones rather than replacing them
+	ensureServiceAccountSecrets(&modified, &existingCopy.Secrets, required.Secrets)
+	ensureServiceAccountImagePullSecrets(&modified, &existingCopy.ImagePullSecrets, required.ImagePullSecrets)
 	if !modified {
 		cache.UpdateCachedResourceMetadata(required, existingCopy)
 		return existingCopy, false, nil
 	}
 	if klog.V(2).Enabled() {
-		klog.Infof("ServiceAccount %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
+		klog.Infof("ServiceAccount %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
 	}
 	actual, err := client.ServiceAccounts(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 	resourcehelper.ReportUpdateEvent(recorder, required, err)
@@ -262,8 +266,61 @@ func ApplyServiceAccountImproved(ctx context.Context, client coreclientv1.Servic
 	return actual, true, err
 }
 
+// ensureServiceAccountSecrets appends the required secrets missing in the existing ones.
+func ensureServiceAccountSecrets(modified *bool, existing *[]corev1.ObjectReference, required []corev1.ObjectReference) {
+	for _, requiredSecret := range required {
+		found := false
+		for _, existingSecret := range *existing {
+			if existingSecret.Name == requiredSecret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*modified = true
+			*existing = append(*existing, requiredSecret)
+		}
+	}
+}
+
+// ensureServiceAccountImagePullSecrets appends the required image pull secrets missing in the existing ones.
+func ensureServiceAccountImagePullSecrets(modified *bool, existing *[]corev1.LocalObjectReference, required []corev1.LocalObjectReference) {
+	for _, requiredSecret := range required {
+		found := false
+		for _, existingSecret := range *existing {
+			if existingSecret.Name == requiredSecret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*modified = true
+			*existing = append(*existing, requiredSecret)
+		}
+	}
+}
+
 // ApplyConfigMap merges objectmeta, requires data
 func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache) (*corev1.ConfigMap, bool, error) {
+	actual, outcome, err := ApplyConfigMapWithOutcome(ctx, client, recorder, required, cache)
+	return actual, outcome.Changed(), err
+}
+
+// ApplyConfigMapWithOutcome is like ApplyConfigMapImproved but reports whether the ConfigMap was created, updated,
+// updated without any change on the server side, or left unchanged.
+func ApplyConfigMapWithOutcome(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache) (*corev1.ConfigMap, ApplyOutcome, error) {
+	return applyConfigMap(ctx, client, recorder, required, cache, false)
+}
+
+// ApplyConfigMapWithRecreate merges objectmeta, requires data.
+// When recreateImmutable is set and the existing ConfigMap is immutable, a change of its content is applied by
+// deleting and re-creating the ConfigMap, because the server rejects any update to the content of immutable ConfigMaps.
+func ApplyConfigMapWithRecreate(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, recreateImmutable bool) (*corev1.ConfigMap, bool, error) {
+	actual, outcome, err := applyConfigMap(ctx, client, recorder, required, noCache, recreateImmutable)
+	return actual, outcome.Changed(), err
+}
+
+func applyConfigMap(ctx context.Context, client coreclientv1.ConfigMapsGetter, recorder events.Recorder, required *corev1.ConfigMap, cache ResourceCache, recreateImmutable bool) (*corev1.ConfigMap, ApplyOutcome, error) {
 	existing, err := client.ConfigMaps(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
 	if apierrors.IsNotFound(err) {
 		requiredCopy := required.DeepCopy()
@@ -271,14 +328,14 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ConfigMap), metav1.CreateOptions{})
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(required, actual)
-		return actual, true, err
+		return actual, ApplyOutcomeCreated, err
 	}
 	if err != nil {
-		return nil, false, err
+		return nil, ApplyOutcomeUnchanged, err
 	}
 
 	if cache.SafeToSkipApply(required, existing) {
-		return existing, false, nil
+		return existing, ApplyOutcomeUnchanged, nil
 	}
 
 	modified := false
@@ -329,7 +386,7 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 	dataSame := len(modifiedKeys) == 0
 	if dataSame && !modified {
 		cache.UpdateCachedResourceMetadata(required, existingCopy)
-		return existingCopy, false, nil
+		return existingCopy, ApplyOutcomeUnchanged, nil
 	}
 	existingCopy.Data = required.Data
 	existingCopy.BinaryData = required.BinaryData
@@ -341,6 +398,24 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 		existingCopy.Data["ca-bundle.crt"] = existingCABundle
 	}
 
+	if recreateImmutable && !dataSame && ptr.Deref(existing.Immutable, false) {
+		if klog.V(2).Enabled() {
+			klog.Infof("ConfigMap %q is immutable, re-creating it with changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, required))
+		}
+		deleteErr := client.ConfigMaps(required.Namespace).Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+		resourcehelper.ReportDeleteEvent(recorder, existingCopy, deleteErr)
+		if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+			return nil, ApplyOutcomeUnchanged, deleteErr
+		}
+
+		// clear the RV and track the original actual and error for the return like our create value.
+		existingCopy.ResourceVersion = ""
+		actual, err := client.ConfigMaps(required.Namespace).Create(ctx, existingCopy, metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, existingCopy, err)
+		cache.UpdateCachedResourceMetadata(required, actual)
+		return actual, ApplyOutcomeUpdated, err
+	}
+
 	actual, err := client.ConfigMaps(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
 
 	var details string
@@ -353,20 +428,27 @@ func ApplyConfigMapImproved(ctx context.Context, client coreclientv1.ConfigMapsG
 	}
 	resourcehelper.ReportUpdateEvent(recorder, required, err, details)
 	cache.UpdateCachedResourceMetadata(required, actual)
-	return actual, true, err
+	return actual, updateOutcome(existing, actual, err), err
 }
 
 // ApplySecret merges objectmeta, requires data
 func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, requiredInput *corev1.Secret, cache ResourceCache) (*corev1.Secret, bool, error) {
+	actual, outcome, err := ApplySecretWithOutcome(ctx, client, recorder, requiredInput, cache)
+	return actual, outcome.Changed(), err
+}
+
+// ApplySecretWithOutcome is like ApplySecretImproved but reports whether the Secret was created, updated,
+// updated without any change on the server side, or left unchanged.
+func ApplySecretWithOutcome(ctx context.Context, client coreclientv1.SecretsGetter, recorder events.Recorder, requiredInput *corev1.Secret, cache ResourceCache) (*corev1.Secret, ApplyOutcome, error) {
 	// copy the stringData to data.  Error on a data content conflict inside required.  This is usually a bug.
 
 	existing, err := client.Secrets(requiredInput.Namespace).Get(ctx, requiredInput.Name, metav1.GetOptions{})
 	if err != nil && !apierrors.IsNotFound(err) {
-		return nil, false, err
+		return nil, ApplyOutcomeUnchanged, err
 	}
 
 	if cache.SafeToSkipApply(requiredInput, existing) {
-		return existing, false, nil
+		return existing, ApplyOutcomeUnchanged, nil
 	}
 
 	required := requiredInput.DeepCopy()
@@ -376,7 +458,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 	for k, v := range required.StringData {
 		if dataV, ok := required.Data[k]; ok {
 			if string(dataV) != v {
-				return nil, false, fmt.Errorf("Secret.stringData[%q] conflicts with Secret.data[%q]", k, k)
+				return nil, ApplyOutcomeUnchanged, fmt.Errorf("Secret.stringData[%q] conflicts with Secret.data[%q]", k, k)
 			}
 		}
 		required.Data[k] = []byte(v)
@@ -389,10 +471,10 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.Secret), metav1.CreateOptions{})
 		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
 		cache.UpdateCachedResourceMetadata(requiredInput, actual)
-		return actual, true, err
+		return actual, ApplyOutcomeCreated, err
 	}
 	if err != nil {
-		return nil, false, err
+		return nil, ApplyOutcomeUnchanged, err
 	}
 
 	existingCopy := existing.DeepCopy()
@@ -424,7 +506,7 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 
 	if equality.Semantic.DeepEqual(existingCopy, existing) {
 		cache.UpdateCachedResourceMetadata(requiredInput, existingCopy)
-		return existing, false, nil
+		return existing, ApplyOutcomeUnchanged, nil
 	}
 
 	if klog.V(4).Enabled() {
@@ -442,22 +524,93 @@ func ApplySecretImproved(ctx context.Context, client coreclientv1.SecretsGetter,
 		resourcehelper.ReportUpdateEvent(recorder, existingCopy, err)
 
 		if err == nil {
-			return actual, true, err
+			return actual, updateOutcome(existing, actual, err), err
 		}
 		if !strings.Contains(err.Error(), "field is immutable") {
-			return actual, true, err
+			return actual, ApplyOutcomeUpdated, err
 		}
 	}
 
 	// if the field was immutable on a secret, we're going to be stuck until we delete it.  Try to delete and then create
 	deleteErr := client.Secrets(required.Namespace).Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
 	resourcehelper.ReportDeleteEvent(recorder, existingCopy, deleteErr)
+	if deleteErr != nil && !apierrors.IsNotFound(deleteErr) {
+		// the create would fail as the secret still exists, report the cause instead
+		return nil, ApplyOutcomeUnchanged, deleteErr
+	}
 
 	// clear the RV and track the original actual and error for the return like our create value.
 	existingCopy.ResourceVersion = ""
 	actual, err = client.Secrets(required.Namespace).Create(ctx, existingCopy, metav1.CreateOptions{})
 	resourcehelper.ReportCreateEvent(recorder, existingCopy, err)
 	cache.UpdateCachedResourceMetadata(requiredInput, actual)
+	return actual, ApplyOutcomeUpdated, err
+}
+
+// ApplyResourceQuota merges objectmeta, requires spec. The status is owned by the server and ignored.
+func ApplyResourceQuota(ctx context.Context, client coreclientv1.ResourceQuotasGetter, recorder events.Recorder, required *corev1.ResourceQuota) (*corev1.ResourceQuota, bool, error) {
+	existing, err := client.ResourceQuotas(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		requiredCopy.Status = corev1.ResourceQuotaStatus{}
+		actual, err := client.ResourceQuotas(requiredCopy.Namespace).
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.ResourceQuota), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+	specSame := equality.Semantic.DeepEqual(existingCopy.Spec, required.Spec)
+	if specSame && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Spec = *required.Spec.DeepCopy()
+	if klog.V(2).Enabled() {
+		klog.Infof("ResourceQuota %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.ResourceQuotas(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+// ApplyLimitRange merges objectmeta, requires spec.
+func ApplyLimitRange(ctx context.Context, client coreclientv1.LimitRangesGetter, recorder events.Recorder, required *corev1.LimitRange) (*corev1.LimitRange, bool, error) {
+	existing, err := client.LimitRanges(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		actual, err := client.LimitRanges(requiredCopy.Namespace).
+			Create(ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*corev1.LimitRange), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+	specSame := equality.Semantic.DeepEqual(existingCopy.Spec, required.Spec)
+	if specSame && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Spec = *required.Spec.DeepCopy()
+	if klog.V(2).Enabled() {
+		klog.Infof("LimitRange %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.LimitRanges(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
 	return actual, true, err
 }
 
@@ -696,3 +849,27 @@ func DeleteSecret(ctx context.Context, client coreclientv1.SecretsGetter, record
 	resourcehelper.ReportDeleteEvent(recorder, required, err)
 	return nil, true, nil
 }
+
+func DeleteResourceQuota(ctx context.Context, client coreclientv1.ResourceQuotasGetter, recorder events.Recorder, required *corev1.ResourceQuota) (*corev1.ResourceQuota, bool, error) {
+	err := client.ResourceQuotas(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
+
+func DeleteLimitRange(ctx context.Context, client coreclientv1.LimitRangesGetter, recorder events.Recorder, required *corev1.LimitRange) (*corev1.LimitRange, bool, error) {
+	err := client.LimitRanges(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
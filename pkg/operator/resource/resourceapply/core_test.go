@@ -13,12 +13,15 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 )
@@ -336,6 +339,352 @@ func TestApplyConfigMap(t *testing.T) {
 	}
 }
 
+func TestApplyWithOutcome(t *testing.T) {
+	// bumpResourceVersion makes the fake client behave like a server that changed the object on update
+	bumpResourceVersion := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		action.(clienttesting.UpdateAction).GetObject().(metav1.Object).SetResourceVersion("43")
+		return false, nil, nil
+	}
+
+	tests := []struct {
+		name              string
+		existing          []runtime.Object
+		serverChangesObj  bool
+		applyConfigMap    *corev1.ConfigMap
+		applySecret       *corev1.Secret
+		expectedOutcome   ApplyOutcome
+		expectedRequested string
+	}{
+		{
+			name:              "configmap created",
+			applyConfigMap:    &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string]string{"foo": "bar"}},
+			expectedOutcome:   ApplyOutcomeCreated,
+			expectedRequested: "create",
+		},
+		{
+			name: "configmap updated",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Data: map[string]string{"foo": "baz"}},
+			},
+			serverChangesObj:  true,
+			applyConfigMap:    &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string]string{"foo": "bar"}},
+			expectedOutcome:   ApplyOutcomeUpdated,
+			expectedRequested: "update",
+		},
+		{
+			name: "configmap update without change on the server side",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Data: map[string]string{"foo": "baz"}},
+			},
+			applyConfigMap:    &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string]string{"foo": "bar"}},
+			expectedOutcome:   ApplyOutcomeUpdateNoOp,
+			expectedRequested: "update",
+		},
+		{
+			name: "configmap unchanged",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Data: map[string]string{"foo": "bar"}},
+			},
+			applyConfigMap:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string]string{"foo": "bar"}},
+			expectedOutcome: ApplyOutcomeUnchanged,
+		},
+		{
+			name:              "secret created",
+			applySecret:       &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string][]byte{"foo": []byte("bar")}},
+			expectedOutcome:   ApplyOutcomeCreated,
+			expectedRequested: "create",
+		},
+		{
+			name: "secret updated",
+			existing: []runtime.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"foo": []byte("baz")}},
+			},
+			serverChangesObj:  true,
+			applySecret:       &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string][]byte{"foo": []byte("bar")}},
+			expectedOutcome:   ApplyOutcomeUpdated,
+			expectedRequested: "update",
+		},
+		{
+			name: "secret update without change on the server side",
+			existing: []runtime.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"foo": []byte("baz")}},
+			},
+			applySecret:       &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string][]byte{"foo": []byte("bar")}},
+			expectedOutcome:   ApplyOutcomeUpdateNoOp,
+			expectedRequested: "update",
+		},
+		{
+			name: "secret unchanged",
+			existing: []runtime.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"foo": []byte("bar")}},
+			},
+			applySecret:     &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string][]byte{"foo": []byte("bar")}},
+			expectedOutcome: ApplyOutcomeUnchanged,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			if test.serverChangesObj {
+				client.PrependReactor("update", "*", bumpResourceVersion)
+			}
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			var outcome ApplyOutcome
+			var changed bool
+			var err error
+			if test.applyConfigMap != nil {
+				if _, outcome, err = ApplyConfigMapWithOutcome(context.TODO(), client.CoreV1(), recorder, test.applyConfigMap, noCache); err != nil {
+					t.Fatal(err)
+				}
+				_, changed, err = ApplyConfigMap(context.TODO(), fake.NewSimpleClientset(test.existing...).CoreV1(), recorder, test.applyConfigMap)
+			} else {
+				if _, outcome, err = ApplySecretWithOutcome(context.TODO(), client.CoreV1(), recorder, test.applySecret, noCache); err != nil {
+					t.Fatal(err)
+				}
+				_, changed, err = ApplySecret(context.TODO(), fake.NewSimpleClientset(test.existing...).CoreV1(), recorder, test.applySecret)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if outcome != test.expectedOutcome {
+				t.Errorf("expected outcome %q, got %q", test.expectedOutcome, outcome)
+			}
+			if changed != outcome.Changed() {
+				t.Errorf("expected the backward compatible changed %v to match the outcome %q", changed, outcome)
+			}
+			var requested string
+			for _, action := range client.Actions() {
+				if action.GetVerb() != "get" {
+					requested = action.GetVerb()
+				}
+			}
+			if requested != test.expectedRequested {
+				t.Errorf("expected the %q request to be sent, got %q: %s", test.expectedRequested, requested, spew.Sdump(client.Actions()))
+			}
+		})
+	}
+}
+
+func TestApplyWithOutcomeUpdateError(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"}, Data: map[string]string{"foo": "baz"}})
+	client.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(corev1.Resource("configmaps"), "foo", fmt.Errorf("conflict"))
+	})
+	recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+	required := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"}, Data: map[string]string{"foo": "bar"}}
+	_, outcome, err := ApplyConfigMapWithOutcome(context.TODO(), client.CoreV1(), recorder, required, noCache)
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if outcome != ApplyOutcomeUpdated {
+		t.Errorf("expected outcome %q, got %q", ApplyOutcomeUpdated, outcome)
+	}
+}
+
+func TestApplyConfigMapWithRecreate(t *testing.T) {
+	tests := []struct {
+		name              string
+		existing          []runtime.Object
+		input             *corev1.ConfigMap
+		recreateImmutable bool
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "recreate immutable on data change",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", ResourceVersion: "42"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "value"},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Immutable:  ptr.To(true),
+				Data:       map[string]string{"configmap": "new-value"},
+			},
+			recreateImmutable: true,
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 3 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "configmaps") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("delete", "configmaps") || actions[1].(clienttesting.DeleteAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[2].Matches("create", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "new-value"},
+				}
+				actual := actions[2].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "recreate immutable on binary data removal",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "value"},
+					BinaryData: map[string][]byte{"binconfigmap": []byte("value")},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Immutable:  ptr.To(true),
+				Data:       map[string]string{"configmap": "value"},
+			},
+			recreateImmutable: true,
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 3 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("delete", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[2].Matches("create", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "no-op on identical immutable",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "value"},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Immutable:  ptr.To(true),
+				Data:       map[string]string{"configmap": "value"},
+			},
+			recreateImmutable: true,
+
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "configmaps") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update immutable on metadata only change",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "value"},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"new": "merge"}},
+				Immutable:  ptr.To(true),
+				Data:       map[string]string{"configmap": "value"},
+			},
+			recreateImmutable: true,
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update mutable on data change",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Data:       map[string]string{"configmap": "value"},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Data:       map[string]string{"configmap": "new-value"},
+			},
+			recreateImmutable: true,
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update immutable on data change without the option",
+			existing: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Immutable:  ptr.To(true),
+					Data:       map[string]string{"configmap": "value"},
+				},
+			},
+			input: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Immutable:  ptr.To(true),
+				Data:       map[string]string{"configmap": "new-value"},
+			},
+			recreateImmutable: false,
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "configmaps") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyConfigMapWithRecreate(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input, test.recreateImmutable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
 func TestApplySecret(t *testing.T) {
 	m := metav1.ObjectMeta{
 		Name:        "test",
@@ -533,50 +882,176 @@ func TestApplySecret(t *testing.T) {
 				},
 			},
 		},
-	}
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			client := fake.NewSimpleClientset(tc.existing...)
-			got, changed, err := ApplySecret(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), tc.required)
-			if !reflect.DeepEqual(tc.err, err) {
-				t.Errorf("expected error %v, got %v", tc.err, err)
-				return
-			}
-
-			if !equality.Semantic.DeepEqual(tc.expected, got) {
-				t.Errorf("objects don't match %s", cmp.Diff(tc.expected, got))
-			}
-
-			if tc.changed != changed {
-				t.Errorf("expected changed %t, got %t", tc.changed, changed)
-			}
-
-			gotActions := client.Actions()
-			if !equality.Semantic.DeepEqual(tc.actions, gotActions) {
-				t.Errorf("actions don't match: %s", cmp.Diff(tc.actions, gotActions))
-			}
-		})
-	}
-}
-
-func TestApplyNamespace(t *testing.T) {
-	tests := []struct {
-		name     string
-		existing []runtime.Object
-		input    *corev1.Namespace
-
-		expectedModified bool
-		verifyActions    func(actions []clienttesting.Action, t *testing.T)
-	}{
 		{
-			name: "create",
-			input: &corev1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: map[string]string{"something-": ""}},
+			name: "recreates the secret if its type changes from Opaque to TLS, keeping the data",
+			existing: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: m,
+					Type:       corev1.SecretTypeOpaque,
+					Data: map[string][]byte{
+						"tls.crt": []byte("crt"),
+						"tls.key": []byte("key"),
+					},
+				},
 			},
-
-			expectedModified: true,
-			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
-				if len(actions) != 2 {
+			required: &corev1.Secret{
+				ObjectMeta: m,
+				Type:       corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					"tls.crt": []byte("crt"),
+					"tls.key": []byte("key"),
+				},
+			},
+			changed: true,
+			expected: &corev1.Secret{
+				ObjectMeta: m,
+				Type:       corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					"tls.crt": []byte("crt"),
+					"tls.key": []byte("key"),
+				},
+			},
+			actions: []clienttesting.Action{
+				clienttesting.GetActionImpl{
+					Name: m.Name,
+					ActionImpl: clienttesting.ActionImpl{
+						Namespace: m.Namespace,
+						Verb:      "get",
+						Resource:  r,
+					},
+				},
+				clienttesting.DeleteActionImpl{
+					Name: m.Name,
+					ActionImpl: clienttesting.ActionImpl{
+						Namespace: m.Namespace,
+						Verb:      "delete",
+						Resource:  r,
+					},
+				},
+				clienttesting.CreateActionImpl{
+					ActionImpl: clienttesting.ActionImpl{
+						Namespace: m.Namespace,
+						Verb:      "create",
+						Resource:  r,
+					},
+					Object: &corev1.Secret{
+						ObjectMeta: m,
+						Type:       corev1.SecretTypeTLS,
+						Data: map[string][]byte{
+							"tls.crt": []byte("crt"),
+							"tls.key": []byte("key"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "no-op when the type and data match",
+			existing: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: m,
+					Type:       corev1.SecretTypeTLS,
+					Data: map[string][]byte{
+						"tls.crt": []byte("crt"),
+					},
+				},
+			},
+			required: &corev1.Secret{
+				ObjectMeta: m,
+				Type:       corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					"tls.crt": []byte("crt"),
+				},
+			},
+			changed: false,
+			expected: &corev1.Secret{
+				ObjectMeta: m,
+				Type:       corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					"tls.crt": []byte("crt"),
+				},
+			},
+			actions: []clienttesting.Action{
+				clienttesting.GetActionImpl{
+					Name: m.Name,
+					ActionImpl: clienttesting.ActionImpl{
+						Namespace: m.Namespace,
+						Verb:      "get",
+						Resource:  r,
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tc.existing...)
+			got, changed, err := ApplySecret(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), tc.required)
+			if !reflect.DeepEqual(tc.err, err) {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+				return
+			}
+
+			if !equality.Semantic.DeepEqual(tc.expected, got) {
+				t.Errorf("objects don't match %s", cmp.Diff(tc.expected, got))
+			}
+
+			if tc.changed != changed {
+				t.Errorf("expected changed %t, got %t", tc.changed, changed)
+			}
+
+			gotActions := client.Actions()
+			if !equality.Semantic.DeepEqual(tc.actions, gotActions) {
+				t.Errorf("actions don't match: %s", cmp.Diff(tc.actions, gotActions))
+			}
+		})
+	}
+}
+
+func TestApplySecretTypeChangeDeleteError(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Type:       corev1.SecretTypeOpaque,
+	})
+	client.PrependReactor("delete", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "test", fmt.Errorf("nope"))
+	})
+
+	_, changed, err := ApplySecret(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+	})
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("expected the delete error to be returned, got %v", err)
+	}
+	if changed {
+		t.Error("expected no change to be reported")
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" {
+			t.Errorf("unexpected create after a failed delete: %v", action)
+		}
+	}
+}
+
+func TestApplyNamespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.Namespace
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Annotations: map[string]string{"something-": ""}},
+			},
+
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
 					t.Fatal(spew.Sdump(actions))
 				}
 				if !actions[0].Matches("get", "namespaces") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
@@ -1728,3 +2203,373 @@ func TestApplyService(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyResourceQuota(t *testing.T) {
+	spec := func(pods string) corev1.ResourceQuotaSpec {
+		return corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourcePods:   resource.MustParse(pods),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.ResourceQuota
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("10"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "resourcequotas") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "server owned status and equivalent quantities don't cause an update",
+			existing: []runtime.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec: corev1.ResourceQuotaSpec{
+						Hard: corev1.ResourceList{
+							corev1.ResourcePods:   resource.MustParse("10"),
+							corev1.ResourceMemory: resource.MustParse("1024Mi"),
+						},
+					},
+					Status: corev1.ResourceQuotaStatus{
+						Hard: spec("10").Hard,
+						Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+					},
+				},
+			},
+			input: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("10"),
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update on spec change keeps the status",
+			existing: []runtime.Object{
+				&corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("10"),
+					Status: corev1.ResourceQuotaStatus{
+						Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+					},
+				},
+			},
+			input: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("20"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "resourcequotas") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &corev1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("20"),
+					Status: corev1.ResourceQuotaStatus{
+						Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+					},
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ResourceQuota)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyResourceQuota(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
+func TestApplyLimitRange(t *testing.T) {
+	spec := func(defaultMemory string) corev1.LimitRangeSpec {
+		return corev1.LimitRangeSpec{
+			Limits: []corev1.LimitRangeItem{
+				{
+					Type:    corev1.LimitTypeContainer,
+					Default: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(defaultMemory)},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.LimitRange
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("512Mi"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "limitranges") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "no-op",
+			existing: []runtime.Object{
+				&corev1.LimitRange{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("512Mi"),
+				},
+			},
+			input: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("512Mi"),
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update on spec change",
+			existing: []runtime.Object{
+				&corev1.LimitRange{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("512Mi"),
+				},
+			},
+			input: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("1Gi"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "limitranges") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &corev1.LimitRange{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("1Gi"),
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.LimitRange)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "update on label change",
+			existing: []runtime.Object{
+				&corev1.LimitRange{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("512Mi"),
+				},
+			},
+			input: &corev1.LimitRange{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"new": "merge"}},
+				Spec:       spec("512Mi"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "limitranges") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyLimitRange(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
+func TestApplyServiceAccount(t *testing.T) {
+	injectedSecrets := []corev1.ObjectReference{{Name: "foo-token-abcde"}, {Name: "foo-dockercfg-abcde"}}
+	injectedImagePullSecrets := []corev1.LocalObjectReference{{Name: "foo-dockercfg-abcde"}}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *corev1.ServiceAccount
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &corev1.ServiceAccount{
+				ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "serviceaccounts") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "injected secrets are preserved",
+			existing: []runtime.Object{
+				&corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Secrets:          injectedSecrets,
+					ImagePullSecrets: injectedImagePullSecrets,
+				},
+			},
+			input: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "injected secrets are preserved on label change",
+			existing: []runtime.Object{
+				&corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Secrets:          injectedSecrets,
+					ImagePullSecrets: injectedImagePullSecrets,
+				},
+			},
+			input: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"new": "merge"}},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "serviceaccounts") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"new": "merge"}},
+					Secrets:          injectedSecrets,
+					ImagePullSecrets: injectedImagePullSecrets,
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ServiceAccount)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "required secrets are added to the injected ones",
+			existing: []runtime.Object{
+				&corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Secrets:          injectedSecrets,
+					ImagePullSecrets: injectedImagePullSecrets,
+				},
+			},
+			input: &corev1.ServiceAccount{
+				ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Secrets:          []corev1.ObjectReference{{Name: "foo-token-abcde"}, {Name: "extra"}},
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "serviceaccounts") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &corev1.ServiceAccount{
+					ObjectMeta:       metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Secrets:          append(append([]corev1.ObjectReference{}, injectedSecrets...), corev1.ObjectReference{Name: "extra"}),
+					ImagePullSecrets: append(append([]corev1.LocalObjectReference{}, injectedImagePullSecrets...), corev1.LocalObjectReference{Name: "registry"}),
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*corev1.ServiceAccount)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyServiceAccount(context.TODO(), client.CoreV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	discoveryclientv1 "k8s.io/client-go/kubernetes/typed/discovery/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// ApplyEndpointSlice merges objectmeta and requires the endpoints and ports to match.
+// The port names and protocols defaulted by the server are not considered a difference.
+// A change of the immutable addressType is reported as an error.
+func ApplyEndpointSlice(ctx context.Context, client discoveryclientv1.EndpointSlicesGetter, recorder events.Recorder, required *discoveryv1.EndpointSlice) (*discoveryv1.EndpointSlice, bool, error) {
+	existing, err := client.EndpointSlices(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		actual, err := client.EndpointSlices(required.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*discoveryv1.EndpointSlice), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if existing.AddressType != required.AddressType {
+		return existing, false, fmt.Errorf("unable to update EndpointSlice %s/%s: addressType is immutable, desired/actual addressType: %s/%s",
+			required.Namespace, required.Name, required.AddressType, existing.AddressType)
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	requiredPorts := defaultEndpointPorts(required.Ports)
+	contentSame := equality.Semantic.DeepEqual(existingCopy.Endpoints, required.Endpoints) &&
+		equality.Semantic.DeepEqual(defaultEndpointPorts(existingCopy.Ports), requiredPorts)
+	if contentSame && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Endpoints = required.Endpoints
+	existingCopy.Ports = requiredPorts
+
+	if klog.V(2).Enabled() {
+		klog.Infof("EndpointSlice %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.EndpointSlices(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+// defaultEndpointPorts returns a copy of the given ports with the names and protocols defaulted the way the server does.
+func defaultEndpointPorts(ports []discoveryv1.EndpointPort) []discoveryv1.EndpointPort {
+	if ports == nil {
+		return nil
+	}
+	ret := make([]discoveryv1.EndpointPort, 0, len(ports))
+	for _, port := range ports {
+		port = *port.DeepCopy()
+		if port.Name == nil {
+			port.Name = ptr.To("")
+		}
+		if port.Protocol == nil {
+			port.Protocol = ptr.To(corev1.ProtocolTCP)
+		}
+		ret = append(ret, port)
+	}
+	return ret
+}
+
+func DeleteEndpointSlice(ctx context.Context, client discoveryclientv1.EndpointSlicesGetter, recorder events.Recorder, required *discoveryv1.EndpointSlice) (*discoveryv1.EndpointSlice, bool, error) {
+	err := client.EndpointSlices(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
@@ -0,0 +1,189 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyEndpointSlice(t *testing.T) {
+	endpoints := func(addresses ...string) []discoveryv1.Endpoint {
+		ret := []discoveryv1.Endpoint{}
+		for _, address := range addresses {
+			ret = append(ret, discoveryv1.Endpoint{Addresses: []string{address}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}})
+		}
+		return ret
+	}
+	requiredPorts := []discoveryv1.EndpointPort{{Port: ptr.To[int32](8443)}}
+	defaultedPorts := []discoveryv1.EndpointPort{{Name: ptr.To(""), Protocol: ptr.To(corev1.ProtocolTCP), Port: ptr.To[int32](8443)}}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *discoveryv1.EndpointSlice
+
+		expectedModified bool
+		expectedError    string
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   endpoints("10.0.0.1"),
+				Ports:       requiredPorts,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "endpointslices") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "endpointslices") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "server defaulted ports and foreign labels don't cause an update",
+			existing: []runtime.Object{
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"endpointslice.kubernetes.io/managed-by": "someone"}},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   endpoints("10.0.0.1"),
+					Ports:       defaultedPorts,
+				},
+			},
+			input: &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   endpoints("10.0.0.1"),
+				Ports:       requiredPorts,
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "endpointslices") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update endpoints",
+			existing: []runtime.Object{
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   endpoints("10.0.0.1"),
+					Ports:       defaultedPorts,
+				},
+			},
+			input: &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   endpoints("10.0.0.1", "10.0.0.2"),
+				Ports:       requiredPorts,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "endpointslices") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   endpoints("10.0.0.1", "10.0.0.2"),
+					Ports:       defaultedPorts,
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*discoveryv1.EndpointSlice)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "update ports",
+			existing: []runtime.Object{
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   endpoints("10.0.0.1"),
+					Ports:       defaultedPorts,
+				},
+			},
+			input: &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   endpoints("10.0.0.1"),
+				Ports:       []discoveryv1.EndpointPort{{Name: ptr.To("https"), Port: ptr.To[int32](8443)}},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "endpointslices") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "immutable address type",
+			existing: []runtime.Object{
+				&discoveryv1.EndpointSlice{
+					ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   endpoints("10.0.0.1"),
+				},
+			},
+			input: &discoveryv1.EndpointSlice{
+				ObjectMeta:  metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				AddressType: discoveryv1.AddressTypeIPv6,
+				Endpoints:   endpoints("fd00::1"),
+			},
+			expectedError: "unable to update EndpointSlice one-ns/foo: addressType is immutable, desired/actual addressType: IPv6/IPv4",
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyEndpointSlice(context.TODO(), client.DiscoveryV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			switch {
+			case len(test.expectedError) == 0 && err != nil:
+				t.Fatal(err)
+			case len(test.expectedError) > 0 && (err == nil || err.Error() != test.expectedError):
+				t.Fatalf("expected error %q, got %v", test.expectedError, err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
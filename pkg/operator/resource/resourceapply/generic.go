@@ -7,11 +7,14 @@ import (
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -35,6 +38,9 @@ type ApplyResult struct {
 	Type    string
 	Result  runtime.Object
 	Changed bool
+	// Outcome details Changed for ConfigMaps and Secrets, the only kinds whose apply functions report it.
+	// It is ApplyOutcomeUnknown for the other kinds, for server-side applies and for deletes.
+	Outcome ApplyOutcome
 	Error   error
 }
 
@@ -84,11 +90,23 @@ func (c *ClientHolder) WithMigrationClient(client migrationclient.Interface) *Cl
 }
 
 // ApplyDirectly applies the given manifest files to API server.
+// Every file is decoded and routed to the Apply function of its kind, the returned results follow the order of the files.
+// A file that can't be read or decoded, or whose kind is not handled, is reported in its result without affecting the others.
+// The cache can be nil to always apply.
 func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.Recorder, cache ResourceCache, manifests AssetFunc, files ...string) []ApplyResult {
+	return ApplyDirectlyWithOptions(ctx, clients, recorder, cache, ApplyOptions{}, manifests, files...)
+}
+
+// ApplyDirectlyWithOptions is like ApplyDirectly, but applies the manifest files as configured by the options.
+// In server-side apply mode, the cache is not used and only the typed kinds handled by ApplyDirectly are supported.
+func ApplyDirectlyWithOptions(ctx context.Context, clients *ClientHolder, recorder events.Recorder, cache ResourceCache, opts ApplyOptions, manifests AssetFunc, files ...string) []ApplyResult {
 	ret := []ApplyResult{}
+	if cache == nil {
+		cache = noCache
+	}
 
 	for _, file := range files {
-		result := ApplyResult{File: file}
+		result := ApplyResult{File: file, Outcome: ApplyOutcomeUnknown}
 		objBytes, err := manifests(file)
 		if err != nil {
 			result.Error = fmt.Errorf("missing %q: %v", file, err)
@@ -103,6 +121,12 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 		}
 		result.Type = fmt.Sprintf("%T", requiredObj)
 
+		if opts.ServerSideApply {
+			result.Result, result.Changed, result.Error = serverSideApply(ctx, clients, recorder, requiredObj, objBytes, opts.FieldManager)
+			ret = append(ret, result)
+			continue
+		}
+
 		// NOTE: Do not add CR resources into this switch otherwise the protobuf client can cause problems.
 		switch t := requiredObj.(type) {
 		case *corev1.Namespace:
@@ -134,14 +158,40 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 			if client == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
 			} else {
-				result.Result, result.Changed, result.Error = ApplyConfigMapImproved(ctx, client, recorder, t, cache)
+				result.Result, result.Outcome, result.Error = ApplyConfigMapWithOutcome(ctx, client, recorder, t, cache)
+				result.Changed = result.Outcome.Changed()
 			}
 		case *corev1.Secret:
 			client := clients.secretsGetter()
 			if client == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
 			} else {
-				result.Result, result.Changed, result.Error = ApplySecretImproved(ctx, client, recorder, t, cache)
+				result.Result, result.Outcome, result.Error = ApplySecretWithOutcome(ctx, client, recorder, t, cache)
+				result.Changed = result.Outcome.Changed()
+			}
+		case *corev1.ResourceQuota:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyResourceQuota(ctx, clients.kubeClient.CoreV1(), recorder, t)
+			}
+		case *corev1.LimitRange:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyLimitRange(ctx, clients.kubeClient.CoreV1(), recorder, t)
+			}
+		case *discoveryv1.EndpointSlice:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyEndpointSlice(ctx, clients.kubeClient.DiscoveryV1(), recorder, t)
+			}
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyHorizontalPodAutoscaler(ctx, clients.kubeClient.AutoscalingV2(), recorder, t)
 			}
 		case *networkingv1.NetworkPolicy:
 			if clients.kubeClient == nil {
@@ -149,6 +199,12 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 			} else {
 				result.Result, result.Changed, result.Error = ApplyNetworkPolicy(ctx, clients.kubeClient.NetworkingV1(), recorder, t)
 			}
+		case *networkingv1.Ingress:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyIngress(ctx, clients.kubeClient.NetworkingV1(), recorder, t)
+			}
 		case *rbacv1.ClusterRole:
 			if clients.kubeClient == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
@@ -191,6 +247,12 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 			} else {
 				result.Result, result.Changed, result.Error = ApplyStorageClass(ctx, clients.kubeClient.StorageV1(), recorder, t)
 			}
+		case *schedulingv1.PriorityClass:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				result.Result, result.Changed, result.Error = ApplyPriorityClass(ctx, clients.kubeClient.SchedulingV1(), recorder, t)
+			}
 		case *admissionregistrationv1.ValidatingWebhookConfiguration:
 			if clients.kubeClient == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
@@ -255,12 +317,16 @@ func ApplyDirectly(ctx context.Context, clients *ClientHolder, recorder events.R
 	return ret
 }
 
+// DeleteAll deletes the objects of the given manifest files from API server, e.g. during a teardown.
+// Every file is decoded and routed to the Delete function of its kind, the returned results follow the order of the files.
+// Objects which are already gone are not an error, their result is reported as not changed.
+// A file that can't be read or decoded, or whose kind is not handled, is reported in its result without affecting the others.
 func DeleteAll(ctx context.Context, clients *ClientHolder, recorder events.Recorder, manifests AssetFunc,
 	files ...string) []ApplyResult {
 	ret := []ApplyResult{}
 
 	for _, file := range files {
-		result := ApplyResult{File: file}
+		result := ApplyResult{File: file, Outcome: ApplyOutcomeUnknown}
 		objBytes, err := manifests(file)
 		if err != nil {
 			result.Error = fmt.Errorf("missing %q: %v", file, err)
@@ -314,12 +380,42 @@ func DeleteAll(ctx context.Context, clients *ClientHolder, recorder events.Recor
 			} else {
 				_, result.Changed, result.Error = DeleteSecret(ctx, client, recorder, t)
 			}
+		case *corev1.ResourceQuota:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeleteResourceQuota(ctx, clients.kubeClient.CoreV1(), recorder, t)
+			}
+		case *corev1.LimitRange:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeleteLimitRange(ctx, clients.kubeClient.CoreV1(), recorder, t)
+			}
+		case *discoveryv1.EndpointSlice:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeleteEndpointSlice(ctx, clients.kubeClient.DiscoveryV1(), recorder, t)
+			}
+		case *autoscalingv2.HorizontalPodAutoscaler:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeleteHorizontalPodAutoscaler(ctx, clients.kubeClient.AutoscalingV2(), recorder, t)
+			}
 		case *networkingv1.NetworkPolicy:
 			if clients.kubeClient == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
 			} else {
 				_, result.Changed, result.Error = DeleteNetworkPolicy(ctx, clients.kubeClient.NetworkingV1(), recorder, t)
 			}
+		case *networkingv1.Ingress:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeleteIngress(ctx, clients.kubeClient.NetworkingV1(), recorder, t)
+			}
 		case *rbacv1.ClusterRole:
 			if clients.kubeClient == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
@@ -374,6 +470,12 @@ func DeleteAll(ctx context.Context, clients *ClientHolder, recorder events.Recor
 			} else {
 				_, result.Changed, result.Error = DeleteStorageClass(ctx, clients.kubeClient.StorageV1(), recorder, t)
 			}
+		case *schedulingv1.PriorityClass:
+			if clients.kubeClient == nil {
+				result.Error = fmt.Errorf("missing kubeClient")
+			} else {
+				_, result.Changed, result.Error = DeletePriorityClass(ctx, clients.kubeClient.SchedulingV1(), recorder, t)
+			}
 		case *admissionregistrationv1.ValidatingWebhookConfiguration:
 			if clients.kubeClient == nil {
 				result.Error = fmt.Errorf("missing kubeClient")
@@ -2,11 +2,25 @@ package resourceapply
 
 import (
 	"context"
-	clocktesting "k8s.io/utils/clock/testing"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/davecgh/go-spew/spew"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	"github.com/openshift/library-go/pkg/operator/events"
 )
@@ -30,3 +44,292 @@ metadata:
 		t.Fatal(ret[0].Error)
 	}
 }
+
+func TestApplyDirectlyMixedAssets(t *testing.T) {
+	assets := map[string]string{
+		"namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: sample-ns
+`,
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sample-config
+  namespace: sample-ns
+data:
+  foo: bar
+`,
+		"role.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: sample-role
+  namespace: sample-ns
+rules:
+- apiGroups: [""]
+  resources: ["configmaps"]
+  verbs: ["get"]
+`,
+		"servicemonitor.yaml": `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: sample-monitor
+  namespace: sample-ns
+spec:
+  endpoints:
+  - port: metrics
+`,
+		"widget.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: sample-widget
+`,
+		"invalid.yaml": `kind: [`,
+	}
+	content := func(name string) ([]byte, error) {
+		asset, ok := assets[name]
+		if !ok {
+			return nil, fmt.Errorf("asset %q not found", name)
+		}
+		return []byte(asset), nil
+	}
+
+	dynamicScheme := runtime.NewScheme()
+	dynamicScheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}, &unstructured.Unstructured{})
+	kubeClient := fake.NewSimpleClientset()
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(dynamicScheme)
+	clients := (&ClientHolder{}).WithKubernetes(kubeClient).WithDynamicClient(dynamicClient)
+	recorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+
+	files := []string{"namespace.yaml", "configmap.yaml", "role.yaml", "servicemonitor.yaml", "widget.yaml", "invalid.yaml", "missing.yaml"}
+	results := ApplyDirectly(context.TODO(), clients, recorder, nil, content, files...)
+	if len(results) != len(files) {
+		t.Fatalf("expected a result per file, got %s", spew.Sdump(results))
+	}
+
+	expected := []struct {
+		resultType string
+		outcome    ApplyOutcome
+		err        string
+	}{
+		{resultType: "*v1.Namespace", outcome: ApplyOutcomeUnknown},
+		{resultType: "*v1.ConfigMap", outcome: ApplyOutcomeCreated},
+		{resultType: "*v1.Role", outcome: ApplyOutcomeUnknown},
+		{resultType: "*unstructured.Unstructured", outcome: ApplyOutcomeUnknown},
+		{resultType: "*unstructured.Unstructured", outcome: ApplyOutcomeUnknown, err: "unsupported object type: example.com/v1, Kind=Widget"},
+		{outcome: ApplyOutcomeUnknown, err: `cannot decode "invalid.yaml"`},
+		{outcome: ApplyOutcomeUnknown, err: `missing "missing.yaml": asset "missing.yaml" not found`},
+	}
+	for i, result := range results {
+		if result.File != files[i] {
+			t.Errorf("expected result %d for %q, got %q", i, files[i], result.File)
+		}
+		if result.Type != expected[i].resultType {
+			t.Errorf("expected %q to be applied as %q, got %q", result.File, expected[i].resultType, result.Type)
+		}
+		if result.Outcome != expected[i].outcome {
+			t.Errorf("expected the outcome of %q to be %q, got %q", result.File, expected[i].outcome, result.Outcome)
+		}
+		switch {
+		case len(expected[i].err) == 0:
+			if result.Error != nil {
+				t.Errorf("unexpected error applying %q: %v", result.File, result.Error)
+			}
+			if !result.Changed || result.Result == nil {
+				t.Errorf("expected %q to be created, got %s", result.File, spew.Sdump(result))
+			}
+		case result.Error == nil || !strings.HasPrefix(result.Error.Error(), expected[i].err):
+			t.Errorf("expected error %q applying %q, got %v", expected[i].err, result.File, result.Error)
+		}
+	}
+
+	if _, err := kubeClient.RbacV1().Roles("sample-ns").Get(context.TODO(), "sample-role", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the role to be created: %v", err)
+	}
+	serviceMonitorGVR := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+	if _, err := dynamicClient.Resource(serviceMonitorGVR).Namespace("sample-ns").Get(context.TODO(), "sample-monitor", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the service monitor to be created: %v", err)
+	}
+}
+
+func TestApplyDirectlyServerSideApply(t *testing.T) {
+	assets := map[string]string{
+		"namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: sample-ns
+`,
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sample-config
+  namespace: sample-ns
+data:
+  foo: bar
+`,
+	}
+	content := func(name string) ([]byte, error) {
+		return []byte(assets[name]), nil
+	}
+
+	kubeClient := fake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-config", Namespace: "sample-ns"},
+		Data:       map[string]string{"foo": "baz"},
+	})
+	clients := (&ClientHolder{}).WithKubernetes(kubeClient)
+	recorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+
+	files := []string{"namespace.yaml", "configmap.yaml"}
+	results := ApplyDirectlyWithOptions(context.TODO(), clients, recorder, nil, ApplyOptions{ServerSideApply: true, FieldManager: "sample-operator"}, content, files...)
+	for _, result := range results {
+		if result.Error != nil {
+			t.Errorf("unexpected error applying %q: %v", result.File, result.Error)
+		}
+		if !result.Changed || result.Result == nil {
+			t.Errorf("expected %q to be changed, got %s", result.File, spew.Sdump(result))
+		}
+	}
+
+	var patched []string
+	for _, action := range kubeClient.Actions() {
+		switch action := action.(type) {
+		case clienttesting.PatchAction:
+			patched = append(patched, action.GetName())
+			if action.GetPatchType() != types.ApplyPatchType {
+				t.Errorf("expected %q to be server-side applied, got patch type %q", action.GetName(), action.GetPatchType())
+			}
+			opts := action.(clienttesting.PatchActionImpl).PatchOptions
+			if opts.FieldManager != "sample-operator" || opts.Force == nil || !*opts.Force {
+				t.Errorf("expected %q to be forcibly applied by sample-operator, got %#v", action.GetName(), opts)
+			}
+		case clienttesting.UpdateAction, clienttesting.CreateAction:
+			t.Errorf("unexpected %s of %s", action.GetVerb(), action.GetResource().Resource)
+		}
+	}
+	if !equality.Semantic.DeepEqual(patched, []string{"sample-ns", "sample-config"}) {
+		t.Errorf("expected the namespace and the configmap to be patched, got %v", patched)
+	}
+
+	configMap, err := kubeClient.CoreV1().ConfigMaps("sample-ns").Get(context.TODO(), "sample-config", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configMap.Data["foo"] != "bar" {
+		t.Errorf("expected the configmap to be applied, got %v", configMap.Data)
+	}
+
+	results = ApplyDirectlyWithOptions(context.TODO(), clients, recorder, nil, ApplyOptions{ServerSideApply: true, FieldManager: "sample-operator"}, content, "configmap.yaml")
+	if results[0].Error != nil || results[0].Changed {
+		t.Errorf("expected the configmap to be applied again without change, got %s", spew.Sdump(results[0]))
+	}
+
+	results = ApplyDirectlyWithOptions(context.TODO(), clients, recorder, nil, ApplyOptions{ServerSideApply: true}, content, "configmap.yaml")
+	if results[0].Error == nil || results[0].Error.Error() != "missing field manager for server-side apply" {
+		t.Errorf("expected the missing field manager to be reported, got %v", results[0].Error)
+	}
+}
+
+func TestDeleteAllMixedAssets(t *testing.T) {
+	assets := map[string]string{
+		"namespace.yaml": `apiVersion: v1
+kind: Namespace
+metadata:
+  name: sample-ns
+`,
+		"configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sample-config
+  namespace: sample-ns
+`,
+		"role.yaml": `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: sample-role
+  namespace: sample-ns
+`,
+		"servicemonitor.yaml": `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: sample-monitor
+  namespace: sample-ns
+`,
+		"widget.yaml": `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: sample-widget
+`,
+		"invalid.yaml": `kind: [`,
+	}
+	content := func(name string) ([]byte, error) {
+		asset, ok := assets[name]
+		if !ok {
+			return nil, fmt.Errorf("asset %q not found", name)
+		}
+		return []byte(asset), nil
+	}
+
+	serviceMonitorGVR := schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "servicemonitors"}
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetAPIVersion("monitoring.coreos.com/v1")
+	serviceMonitor.SetKind("ServiceMonitor")
+	serviceMonitor.SetNamespace("sample-ns")
+	serviceMonitor.SetName("sample-monitor")
+
+	dynamicScheme := runtime.NewScheme()
+	dynamicScheme.AddKnownTypeWithName(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}, &unstructured.Unstructured{})
+	// the config map is already gone
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sample-ns"}},
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Namespace: "sample-ns", Name: "sample-role"}},
+	)
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(dynamicScheme, serviceMonitor)
+	clients := (&ClientHolder{}).WithKubernetes(kubeClient).WithDynamicClient(dynamicClient)
+	recorder := events.NewInMemoryRecorder("", clocktesting.NewFakePassiveClock(time.Now()))
+
+	files := []string{"namespace.yaml", "configmap.yaml", "role.yaml", "servicemonitor.yaml", "widget.yaml", "invalid.yaml", "missing.yaml"}
+	results := DeleteAll(context.TODO(), clients, recorder, content, files...)
+	if len(results) != len(files) {
+		t.Fatalf("expected a result per file, got %s", spew.Sdump(results))
+	}
+
+	expected := []struct {
+		resultType string
+		changed    bool
+		err        string
+	}{
+		{resultType: "*v1.Namespace", changed: true},
+		{resultType: "*v1.ConfigMap"},
+		{resultType: "*v1.Role", changed: true},
+		{resultType: "*unstructured.Unstructured", changed: true},
+		{resultType: "*unstructured.Unstructured", err: "unsupported object type: example.com/v1, Kind=Widget"},
+		{err: `cannot decode "invalid.yaml"`},
+		{err: `missing "missing.yaml": asset "missing.yaml" not found`},
+	}
+	for i, result := range results {
+		if result.File != files[i] {
+			t.Errorf("expected result %d for %q, got %q", i, files[i], result.File)
+		}
+		if result.Type != expected[i].resultType {
+			t.Errorf("expected %q to be deleted as %q, got %q", result.File, expected[i].resultType, result.Type)
+		}
+		switch {
+		case len(expected[i].err) == 0:
+			if result.Error != nil {
+				t.Errorf("unexpected error deleting %q: %v", result.File, result.Error)
+			}
+			if result.Changed != expected[i].changed {
+				t.Errorf("expected %q to be reported with changed=%v, got %s", result.File, expected[i].changed, spew.Sdump(result))
+			}
+		case result.Error == nil || !strings.HasPrefix(result.Error.Error(), expected[i].err):
+			t.Errorf("expected error %q deleting %q, got %v", expected[i].err, result.File, result.Error)
+		}
+	}
+
+	if _, err := kubeClient.RbacV1().Roles("sample-ns").Get(context.TODO(), "sample-role", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the role to be deleted, got %v", err)
+	}
+	if _, err := dynamicClient.Resource(serviceMonitorGVR).Namespace("sample-ns").Get(context.TODO(), "sample-monitor", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the service monitor to be deleted, got %v", err)
+	}
+}
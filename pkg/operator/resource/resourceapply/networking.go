@@ -46,6 +46,58 @@ func ApplyNetworkPolicy(ctx context.Context, client networkingclientv1.NetworkPo
 	return actual, true, err
 }
 
+// ApplyIngress merges objectmeta and requires the rules, the TLS configuration, the default backend and the ingress class
+// to match. The status is populated by the server and ignored. When the required spec doesn't set the ingress class,
+// the one defaulted by the server from the default IngressClass is kept.
+func ApplyIngress(ctx context.Context, client networkingclientv1.IngressesGetter, recorder events.Recorder, required *networkingv1.Ingress) (*networkingv1.Ingress, bool, error) {
+	existing, err := client.Ingresses(required.Namespace).Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		requiredCopy.Status = networkingv1.IngressStatus{}
+		actual, err := client.Ingresses(required.Namespace).Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*networkingv1.Ingress), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	requiredSpec := *required.Spec.DeepCopy()
+	if requiredSpec.IngressClassName == nil {
+		requiredSpec.IngressClassName = existingCopy.Spec.IngressClassName
+	}
+	if equality.Semantic.DeepEqual(existingCopy.Spec, requiredSpec) && !modified {
+		return existingCopy, false, nil
+	}
+
+	existingCopy.Spec = requiredSpec
+
+	if klog.V(2).Enabled() {
+		klog.Infof("Ingress %q changes: %v", required.Namespace+"/"+required.Name, JSONPatchNoError(existing, existingCopy))
+	}
+
+	actual, err := client.Ingresses(required.Namespace).Update(ctx, existingCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+func DeleteIngress(ctx context.Context, client networkingclientv1.IngressesGetter, recorder events.Recorder, required *networkingv1.Ingress) (*networkingv1.Ingress, bool, error) {
+	err := client.Ingresses(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
+
 func DeleteNetworkPolicy(ctx context.Context, client networkingclientv1.NetworkPoliciesGetter, recorder events.Recorder, required *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, bool, error) {
 	err := client.NetworkPolicies(required.Namespace).Delete(ctx, required.Name, metav1.DeleteOptions{})
 	if err != nil && apierrors.IsNotFound(err) {
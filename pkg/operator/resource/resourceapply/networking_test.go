@@ -0,0 +1,196 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyIngress(t *testing.T) {
+	spec := func(host, secretName string) networkingv1.IngressSpec {
+		return networkingv1.IngressSpec{
+			TLS: []networkingv1.IngressTLS{{Hosts: []string{host}, SecretName: secretName}},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     "/",
+								PathType: ptr.To(networkingv1.PathTypePrefix),
+								Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+									Name: "console",
+									Port: networkingv1.ServiceBackendPort{Name: "https"},
+								}},
+							},
+						},
+					}},
+				},
+			},
+		}
+	}
+	withClass := func(spec networkingv1.IngressSpec, className string) networkingv1.IngressSpec {
+		spec.IngressClassName = ptr.To(className)
+		return spec
+	}
+	status := networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+		Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.1", Ports: []networkingv1.IngressPortStatus{{Port: 443, Protocol: corev1.ProtocolTCP}}}},
+	}}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *networkingv1.Ingress
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("console.example.com", "console-tls"),
+				Status:     status,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "ingresses") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[1].(clienttesting.CreateAction).GetObject().(*networkingv1.Ingress)
+				if !equality.Semantic.DeepEqual(networkingv1.IngressStatus{}, actual.Status) {
+					t.Errorf("expected the status not to be created, got %v", spew.Sdump(actual.Status))
+				}
+			},
+		},
+		{
+			name: "no-op with the server defaulted class and status",
+			existing: []runtime.Object{
+				&networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       withClass(spec("console.example.com", "console-tls"), "default"),
+					Status:     status,
+				},
+			},
+			input: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("console.example.com", "console-tls"),
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update on TLS and rules change",
+			existing: []runtime.Object{
+				&networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       withClass(spec("console.example.com", "console-tls"), "default"),
+					Status:     status,
+				},
+			},
+			input: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       spec("console.apps.example.com", "console-apps-tls"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "ingresses") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       withClass(spec("console.apps.example.com", "console-apps-tls"), "default"),
+					Status:     status,
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*networkingv1.Ingress)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "update on class change",
+			existing: []runtime.Object{
+				&networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       withClass(spec("console.example.com", "console-tls"), "default"),
+				},
+			},
+			input: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Spec:       withClass(spec("console.example.com", "console-tls"), "internal"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "ingresses") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*networkingv1.Ingress)
+				if className := ptr.Deref(actual.Spec.IngressClassName, ""); className != "internal" {
+					t.Errorf("expected the ingress class internal, got %q", className)
+				}
+			},
+		},
+		{
+			name: "update on label change",
+			existing: []runtime.Object{
+				&networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Spec:       spec("console.example.com", "console-tls"),
+				},
+			},
+			input: &networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"new": "merge"}},
+				Spec:       spec("console.example.com", "console-tls"),
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "ingresses") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyIngress(context.TODO(), client.NetworkingV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package resourceapply
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyOutcome tells what an apply function did to the resource, with more detail than the changed bool
+// returned by most of them.
+type ApplyOutcome string
+
+const (
+	// ApplyOutcomeCreated means the resource didn't exist and was created.
+	ApplyOutcomeCreated ApplyOutcome = "Created"
+	// ApplyOutcomeUpdated means an update was sent and the server changed the resource.
+	// A resource re-created because of an immutable field is reported as updated too.
+	ApplyOutcomeUpdated ApplyOutcome = "Updated"
+	// ApplyOutcomeUpdateNoOp means an update was sent but the server found nothing to change,
+	// e.g. because the diff was only about defaulted fields; the resourceVersion was kept.
+	ApplyOutcomeUpdateNoOp ApplyOutcome = "UpdateNoOp"
+	// ApplyOutcomeUnchanged means the resource was already as required and no request was sent.
+	ApplyOutcomeUnchanged ApplyOutcome = "Unchanged"
+	// ApplyOutcomeUnknown means the outcome wasn't reported, e.g. by ApplyDirectly for the kinds whose apply
+	// functions only return the changed bool. Only ConfigMaps and Secrets report their outcome.
+	ApplyOutcomeUnknown ApplyOutcome = "Unknown"
+)
+
+// Changed returns the changed bool of the apply functions for the outcome: true whenever a create
+// or an update was sent, false when the apply was skipped. It is false for an unknown outcome too,
+// whose changed bool has to be read from the apply function.
+func (o ApplyOutcome) Changed() bool {
+	return o == ApplyOutcomeCreated || o == ApplyOutcomeUpdated || o == ApplyOutcomeUpdateNoOp
+}
+
+// updateOutcome returns the outcome of an update of existing returning actual and err.
+// A failed update is reported as updated like it is reported as changed.
+func updateOutcome(existing, actual metav1.Object, err error) ApplyOutcome {
+	if err == nil && len(actual.GetResourceVersion()) > 0 && actual.GetResourceVersion() == existing.GetResourceVersion() {
+		return ApplyOutcomeUpdateNoOp
+	}
+	return ApplyOutcomeUpdated
+}
@@ -33,9 +33,14 @@ func ApplyClusterRole(ctx context.Context, client rbacclientv1.ClusterRolesGette
 	existingCopy := existing.DeepCopy()
 
 	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
-	rulesContentSame := equality.Semantic.DeepEqual(existingCopy.Rules, required.Rules)
 	aggregationRuleContentSame := equality.Semantic.DeepEqual(existingCopy.AggregationRule, required.AggregationRule)
 
+	// The control plane controller that reconciles ClusterRoles
+	// overwrites any values that are manually specified in the rules field of an aggregate ClusterRole.
+	// As such skip diffing and reconciling on the Rules field when the AggregationRule is set,
+	// otherwise every apply would fight the aggregation controller.
+	rulesContentSame := required.AggregationRule != nil || equality.Semantic.DeepEqual(existingCopy.Rules, required.Rules)
+
 	if aggregationRuleContentSame && rulesContentSame && !modified {
 		return existingCopy, false, nil
 	}
@@ -44,10 +49,7 @@ func ApplyClusterRole(ctx context.Context, client rbacclientv1.ClusterRolesGette
 		existingCopy.AggregationRule = required.AggregationRule
 	}
 
-	// The control plane controller that reconciles ClusterRoles
-	// overwrites any values that are manually specified in the rules field of an aggregate ClusterRole.
-	// As such skip reconciling on the Rules field when the AggregationRule is set.
-	if !rulesContentSame && required.AggregationRule == nil {
+	if !rulesContentSame {
 		existingCopy.Rules = required.Rules
 	}
 
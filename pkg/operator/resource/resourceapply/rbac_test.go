@@ -0,0 +1,397 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyClusterRole(t *testing.T) {
+	aggregationRule := &rbacv1.AggregationRule{
+		ClusterRoleSelectors: []metav1.LabelSelector{
+			{MatchLabels: map[string]string{"rbac.example.com/aggregate-to-foo": "true"}},
+		},
+	}
+	aggregatedRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *rbacv1.ClusterRole
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create aggregated role",
+			input: &rbacv1.ClusterRole{
+				ObjectMeta:      metav1.ObjectMeta{Name: "foo"},
+				AggregationRule: aggregationRule,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "clusterroles") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "clusterroles") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "aggregated role with controller managed rules does not churn",
+			existing: []runtime.Object{
+				&rbacv1.ClusterRole{
+					ObjectMeta:      metav1.ObjectMeta{Name: "foo"},
+					AggregationRule: aggregationRule,
+					Rules:           aggregatedRules,
+				},
+			},
+			input: &rbacv1.ClusterRole{
+				ObjectMeta:      metav1.ObjectMeta{Name: "foo"},
+				AggregationRule: aggregationRule,
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "clusterroles") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "aggregated role reconciles labels but keeps controller managed rules",
+			existing: []runtime.Object{
+				&rbacv1.ClusterRole{
+					ObjectMeta:      metav1.ObjectMeta{Name: "foo"},
+					AggregationRule: aggregationRule,
+					Rules:           aggregatedRules,
+				},
+			},
+			input: &rbacv1.ClusterRole{
+				ObjectMeta:      metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"new": "merge"}},
+				AggregationRule: aggregationRule,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "clusterroles") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &rbacv1.ClusterRole{
+					ObjectMeta:      metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"new": "merge"}},
+					AggregationRule: aggregationRule,
+					Rules:           aggregatedRules,
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*rbacv1.ClusterRole)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "non-aggregated role reconciles rules",
+			existing: []runtime.Object{
+				&rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Rules:      aggregatedRules,
+				},
+			},
+			input: &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "clusterroles") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &rbacv1.ClusterRole{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Rules: []rbacv1.PolicyRule{
+						{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+					},
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*rbacv1.ClusterRole)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyClusterRole(context.TODO(), client.RbacV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
+func TestApplyRole(t *testing.T) {
+	rules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get", "list"}},
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *rbacv1.Role
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Rules:      rules,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "roles") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "roles") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "skip on no change",
+			existing: []runtime.Object{
+				&rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					Rules:      rules,
+				},
+			},
+			input: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Rules:      rules,
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "roles") || actions[0].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "same name in another namespace is created",
+			existing: []runtime.Object{
+				&rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "other-ns", Name: "foo"},
+					Rules:      rules,
+				},
+			},
+			input: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Rules:      rules,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "roles") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update rules",
+			existing: []runtime.Object{
+				&rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"extra": "leave-alone"}},
+					Rules:      rules,
+				},
+			},
+			input: &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "roles") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo", Labels: map[string]string{"extra": "leave-alone"}},
+					Rules: []rbacv1.PolicyRule{
+						{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+					},
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*rbacv1.Role)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyRole(context.TODO(), client.RbacV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
+
+func TestApplyRoleBinding(t *testing.T) {
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "foo"}
+	subjects := []rbacv1.Subject{
+		{Kind: "ServiceAccount", Namespace: "one-ns", Name: "operator"},
+	}
+
+	tests := []struct {
+		name     string
+		existing []runtime.Object
+		input    *rbacv1.RoleBinding
+
+		expectedModified bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				RoleRef:    roleRef,
+				Subjects:   subjects,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "rolebindings") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "rolebindings") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "skip on no change, user subjects default their api group",
+			existing: []runtime.Object{
+				&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					RoleRef:    roleRef,
+					Subjects:   append([]rbacv1.Subject{{Kind: "User", APIGroup: rbacv1.GroupName, Name: "admin"}}, subjects...),
+				},
+			},
+			input: &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "foo"},
+				Subjects:   append([]rbacv1.Subject{{Kind: "User", Name: "admin"}}, subjects...),
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "rolebindings") || actions[0].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update subjects",
+			existing: []runtime.Object{
+				&rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					RoleRef:    roleRef,
+					Subjects:   subjects,
+				},
+			},
+			input: &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+				RoleRef:    roleRef,
+				Subjects: []rbacv1.Subject{
+					{Kind: "ServiceAccount", Namespace: "one-ns", Name: "operand"},
+				},
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "rolebindings") || actions[1].GetNamespace() != "one-ns" {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "one-ns", Name: "foo"},
+					RoleRef:    roleRef,
+					Subjects: []rbacv1.Subject{
+						{Kind: "ServiceAccount", Namespace: "one-ns", Name: "operand"},
+					},
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*rbacv1.RoleBinding)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyRoleBinding(context.TODO(), client.RbacV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package resourceapply
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// ApplyFunc applies a single resource, typically by calling one of the Apply* functions of this package.
+type ApplyFunc func() (runtime.Object, bool, error)
+
+// ApplyWithRetry calls fn and retries it with the given backoff as long as it fails with a conflict,
+// retry.DefaultBackoff is a sensible default. Other errors are returned right away.
+// The result of the last attempt is returned, along with the conflict error if the retries were exhausted.
+//
+// The Apply* functions read the existing object before updating it, so retrying them resolves
+// conflicts caused by a stale read.
+func ApplyWithRetry(fn ApplyFunc, backoff wait.Backoff) (runtime.Object, bool, error) {
+	var (
+		obj      runtime.Object
+		modified bool
+	)
+	err := retry.OnError(backoff, errors.IsConflict, func() error {
+		var err error
+		obj, modified, err = fn()
+		return err
+	})
+	return obj, modified, err
+}
@@ -0,0 +1,102 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyWithRetry(t *testing.T) {
+	backoff := wait.Backoff{Steps: 3, Duration: time.Millisecond}
+	conflictErr := errors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "foo", fmt.Errorf("injected conflict"))
+
+	tests := []struct {
+		name string
+		// errs are injected into consecutive update calls
+		errs []error
+
+		expectedModified bool
+		expectedErr      error
+		expectedUpdates  int
+	}{
+		{
+			name:             "no conflict",
+			expectedModified: true,
+			expectedUpdates:  1,
+		},
+		{
+			name:             "conflicts then success",
+			errs:             []error{conflictErr, conflictErr},
+			expectedModified: true,
+			expectedUpdates:  3,
+		},
+		{
+			name: "conflicts exhaust the retries",
+			errs: []error{conflictErr, conflictErr, conflictErr},
+			// the result of the last attempt is returned, ApplyConfigMap reports failed updates as modified
+			expectedModified: true,
+			expectedErr:      conflictErr,
+			expectedUpdates:  3,
+		},
+		{
+			name:             "other errors are not retried",
+			errs:             []error{fmt.Errorf("boom"), conflictErr},
+			expectedModified: true,
+			expectedErr:      fmt.Errorf("boom"),
+			expectedUpdates:  1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+				Data:       map[string]string{"key": "old"},
+			})
+			updates := 0
+			client.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				updates++
+				if len(test.errs) >= updates {
+					return true, nil, test.errs[updates-1]
+				}
+				return false, nil, nil
+			})
+			required := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+				Data:       map[string]string{"key": "new"},
+			}
+			recorder := events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now()))
+
+			obj, modified, err := ApplyWithRetry(func() (runtime.Object, bool, error) {
+				return ApplyConfigMap(context.TODO(), client.CoreV1(), recorder, required)
+			}, backoff)
+			if test.expectedErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expectedErr != nil && (err == nil || err.Error() != test.expectedErr.Error()) {
+				t.Fatalf("expected error %v, got %v", test.expectedErr, err)
+			}
+			if modified != test.expectedModified {
+				t.Errorf("expected modified %v, got %v", test.expectedModified, modified)
+			}
+			if updates != test.expectedUpdates {
+				t.Errorf("expected %d update attempts, got %d", test.expectedUpdates, updates)
+			}
+			if err == nil && obj.(*corev1.ConfigMap).Data["key"] != "new" {
+				t.Errorf("expected the updated configmap to be returned, got %v", obj)
+			}
+		})
+	}
+}
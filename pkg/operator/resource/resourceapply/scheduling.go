@@ -0,0 +1,113 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	schedulingclientv1 "k8s.io/client-go/kubernetes/typed/scheduling/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+)
+
+// ApplyPriorityClass merges objectmeta, tries to write everything else.
+// A change of the immutable value or preemptionPolicy is reported as an error, see ApplyPriorityClassWithRecreate.
+func ApplyPriorityClass(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass) (*schedulingv1.PriorityClass, bool, error) {
+	return applyPriorityClass(ctx, client, recorder, required, false)
+}
+
+// ApplyPriorityClassWithRecreate merges objectmeta, tries to write everything else.
+// When recreateImmutable is set, a change of the immutable value or preemptionPolicy is applied by
+// deleting and re-creating the PriorityClass.
+func ApplyPriorityClassWithRecreate(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass, recreateImmutable bool) (*schedulingv1.PriorityClass, bool, error) {
+	return applyPriorityClass(ctx, client, recorder, required, recreateImmutable)
+}
+
+func applyPriorityClass(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass, recreateImmutable bool) (*schedulingv1.PriorityClass, bool, error) {
+	existing, err := client.PriorityClasses().Get(ctx, required.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		requiredCopy := required.DeepCopy()
+		actual, err := client.PriorityClasses().Create(
+			ctx, resourcemerge.WithCleanLabelsAndAnnotations(requiredCopy).(*schedulingv1.PriorityClass), metav1.CreateOptions{})
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	modified := false
+	existingCopy := existing.DeepCopy()
+	resourcemerge.EnsureObjectMeta(&modified, &existingCopy.ObjectMeta, required.ObjectMeta)
+
+	// PriorityClass doesn't have a spec, copy required to get all fields
+	// and then overwrite ObjectMeta and TypeMeta from the original.
+	requiredCopy := required.DeepCopy()
+	requiredCopy.ObjectMeta = *existingCopy.ObjectMeta.DeepCopy()
+	requiredCopy.TypeMeta = existingCopy.TypeMeta
+	// preemptionPolicy is defaulted by the server
+	if requiredCopy.PreemptionPolicy == nil {
+		requiredCopy.PreemptionPolicy = existingCopy.PreemptionPolicy
+	}
+
+	contentSame := equality.Semantic.DeepEqual(existingCopy, requiredCopy)
+	if contentSame && !modified {
+		return existing, false, nil
+	}
+
+	if klog.V(2).Enabled() {
+		klog.Infof("PriorityClass %q changes: %v", required.Name, JSONPatchNoError(existingCopy, requiredCopy))
+	}
+
+	if priorityClassNeedsRecreate(existingCopy, requiredCopy) {
+		if !recreateImmutable {
+			return existing, false, fmt.Errorf("unable to update PriorityClass %s: value and preemptionPolicy are immutable, desired/actual value: %d/%d, desired/actual preemptionPolicy: %v/%v",
+				required.Name, requiredCopy.Value, existingCopy.Value, ptr.Deref(requiredCopy.PreemptionPolicy, ""), ptr.Deref(existingCopy.PreemptionPolicy, ""))
+		}
+
+		requiredCopy.ObjectMeta.ResourceVersion = ""
+		err = client.PriorityClasses().Delete(ctx, existingCopy.Name, metav1.DeleteOptions{})
+		resourcehelper.ReportDeleteEvent(recorder, requiredCopy, err, "Deleting PriorityClass to re-create it with an updated value")
+		if err != nil && !apierrors.IsNotFound(err) {
+			return existing, false, err
+		}
+		actual, err := client.PriorityClasses().Create(ctx, requiredCopy, metav1.CreateOptions{})
+		if err != nil {
+			err = fmt.Errorf("failed to re-create PriorityClass %s: %w", existingCopy.Name, err)
+		}
+		resourcehelper.ReportCreateEvent(recorder, requiredCopy, err)
+		return actual, true, err
+	}
+
+	actual, err := client.PriorityClasses().Update(ctx, requiredCopy, metav1.UpdateOptions{})
+	resourcehelper.ReportUpdateEvent(recorder, required, err)
+	return actual, true, err
+}
+
+func priorityClassNeedsRecreate(oldPC, newPC *schedulingv1.PriorityClass) bool {
+	// Based on kubernetes/kubernetes/pkg/apis/scheduling/validation/validation.go,
+	// these fields are immutable.
+	if oldPC.Value != newPC.Value {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(oldPC.PreemptionPolicy, newPC.PreemptionPolicy)
+}
+
+func DeletePriorityClass(ctx context.Context, client schedulingclientv1.PriorityClassesGetter, recorder events.Recorder, required *schedulingv1.PriorityClass) (*schedulingv1.PriorityClass, bool, error) {
+	err := client.PriorityClasses().Delete(ctx, required.Name, metav1.DeleteOptions{})
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	resourcehelper.ReportDeleteEvent(recorder, required, err)
+	return nil, true, nil
+}
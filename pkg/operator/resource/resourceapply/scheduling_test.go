@@ -0,0 +1,207 @@
+package resourceapply
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	clocktesting "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+)
+
+func TestApplyPriorityClass(t *testing.T) {
+	preemptLowerPriority := corev1.PreemptLowerPriority
+
+	tests := []struct {
+		name              string
+		existing          []runtime.Object
+		input             *schedulingv1.PriorityClass
+		recreateImmutable bool
+
+		expectedModified bool
+		expectedFailure  bool
+		verifyActions    func(actions []clienttesting.Action, t *testing.T)
+	}{
+		{
+			name: "create",
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Value:      1000,
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "priorityclasses") || actions[0].(clienttesting.GetAction).GetName() != "foo" {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("create", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "no-op with a server defaulted preemptionPolicy",
+			existing: []runtime.Object{
+				&schedulingv1.PriorityClass{
+					ObjectMeta:       metav1.ObjectMeta{Name: "foo"},
+					Value:            1000,
+					Description:      "bar",
+					PreemptionPolicy: &preemptLowerPriority,
+				},
+			},
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "foo"},
+				Value:       1000,
+				Description: "bar",
+			},
+			expectedModified: false,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[0].Matches("get", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "update description",
+			existing: []runtime.Object{
+				&schedulingv1.PriorityClass{
+					ObjectMeta:  metav1.ObjectMeta{Name: "foo"},
+					Value:       1000,
+					Description: "bar",
+				},
+			},
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta:  metav1.ObjectMeta{Name: "foo"},
+				Value:       1000,
+				Description: "baz",
+			},
+			expectedModified: true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 2 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("update", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+				expected := &schedulingv1.PriorityClass{
+					ObjectMeta:  metav1.ObjectMeta{Name: "foo"},
+					Value:       1000,
+					Description: "baz",
+				}
+				actual := actions[1].(clienttesting.UpdateAction).GetObject().(*schedulingv1.PriorityClass)
+				if !equality.Semantic.DeepEqual(expected, actual) {
+					t.Error(JSONPatchNoError(expected, actual))
+				}
+			},
+		},
+		{
+			name: "value change without recreate fails",
+			existing: []runtime.Object{
+				&schedulingv1.PriorityClass{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Value:      1000,
+				},
+			},
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Value:      2000,
+			},
+			expectedModified: false,
+			expectedFailure:  true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 1 {
+					t.Fatal(spew.Sdump(actions))
+				}
+			},
+		},
+		{
+			name: "value change with recreate",
+			existing: []runtime.Object{
+				&schedulingv1.PriorityClass{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+					Value:      1000,
+				},
+			},
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+				Value:      2000,
+			},
+			recreateImmutable: true,
+			expectedModified:  true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 3 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("delete", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[2].Matches("create", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+				actual := actions[2].(clienttesting.CreateAction).GetObject().(*schedulingv1.PriorityClass)
+				if actual.Value != 2000 {
+					t.Errorf("expected value 2000, got %d", actual.Value)
+				}
+			},
+		},
+		{
+			name: "preemptionPolicy change with recreate",
+			existing: []runtime.Object{
+				&schedulingv1.PriorityClass{
+					ObjectMeta:       metav1.ObjectMeta{Name: "foo"},
+					Value:            1000,
+					PreemptionPolicy: &preemptLowerPriority,
+				},
+			},
+			input: &schedulingv1.PriorityClass{
+				ObjectMeta:       metav1.ObjectMeta{Name: "foo"},
+				Value:            1000,
+				PreemptionPolicy: ptr.To(corev1.PreemptNever),
+			},
+			recreateImmutable: true,
+			expectedModified:  true,
+			verifyActions: func(actions []clienttesting.Action, t *testing.T) {
+				if len(actions) != 3 {
+					t.Fatal(spew.Sdump(actions))
+				}
+				if !actions[1].Matches("delete", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+				if !actions[2].Matches("create", "priorityclasses") {
+					t.Error(spew.Sdump(actions))
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.existing...)
+			_, actualModified, err := ApplyPriorityClassWithRecreate(context.TODO(), client.SchedulingV1(), events.NewInMemoryRecorder("test", clocktesting.NewFakePassiveClock(time.Now())), test.input, test.recreateImmutable)
+			if err != nil && !test.expectedFailure {
+				t.Fatal(err)
+			}
+			if err == nil && test.expectedFailure {
+				t.Fatal("expected an error")
+			}
+			if test.expectedModified != actualModified {
+				t.Errorf("expected %v, got %v", test.expectedModified, actualModified)
+			}
+			test.verifyActions(client.Actions(), t)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package resourceapply
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	migrationv1alpha1 "sigs.k8s.io/kube-storage-version-migrator/pkg/apis/migration/v1alpha1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourcehelper"
+)
+
+// ApplyOptions configures how ApplyDirectlyWithOptions applies the manifests.
+type ApplyOptions struct {
+	// ServerSideApply makes the manifests server-side applied as they are, instead of merged into the existing
+	// objects by the Apply function of their kind. Conflicts with other field managers are forced, the operator
+	// is expected to own the fields of its manifests.
+	ServerSideApply bool
+	// FieldManager is the field manager the manifests are server-side applied with, it is required with ServerSideApply.
+	FieldManager string
+}
+
+// serverSideApplyClient is implemented by the typed clients of all the kinds supporting server-side apply.
+type serverSideApplyClient[T runtime.Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+}
+
+// serverSideApply routes the required object to the typed client of its kind and server-side applies the manifest.
+func serverSideApply(ctx context.Context, clients *ClientHolder, recorder events.Recorder, required runtime.Object, manifest []byte, fieldManager string) (runtime.Object, bool, error) {
+	if len(fieldManager) == 0 {
+		return nil, false, fmt.Errorf("missing field manager for server-side apply")
+	}
+	data, err := yaml.YAMLToJSON(manifest)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := required.(type) {
+	case *apiextensionsv1.CustomResourceDefinition:
+		if clients.apiExtensionsClient == nil {
+			return nil, false, fmt.Errorf("missing apiExtensionsClient")
+		}
+		return serverSideApplyWith(ctx, clients.apiExtensionsClient.ApiextensionsV1().CustomResourceDefinitions(), recorder, t, data, fieldManager)
+	case *migrationv1alpha1.StorageVersionMigration:
+		if clients.migrationClient == nil {
+			return nil, false, fmt.Errorf("missing migrationClient")
+		}
+		return serverSideApplyWith(ctx, clients.migrationClient.MigrationV1alpha1().StorageVersionMigrations(), recorder, t, data, fieldManager)
+	}
+
+	if clients.kubeClient == nil {
+		return nil, false, fmt.Errorf("missing kubeClient")
+	}
+	switch t := required.(type) {
+	case *corev1.Namespace:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().Namespaces(), recorder, t, data, fieldManager)
+	case *corev1.Service:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().Services(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.Pod:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().Pods(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.ServiceAccount:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().ServiceAccounts(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.ConfigMap:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().ConfigMaps(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.Secret:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().Secrets(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.ResourceQuota:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().ResourceQuotas(t.Namespace), recorder, t, data, fieldManager)
+	case *corev1.LimitRange:
+		return serverSideApplyWith(ctx, clients.kubeClient.CoreV1().LimitRanges(t.Namespace), recorder, t, data, fieldManager)
+	case *discoveryv1.EndpointSlice:
+		return serverSideApplyWith(ctx, clients.kubeClient.DiscoveryV1().EndpointSlices(t.Namespace), recorder, t, data, fieldManager)
+	case *autoscalingv2.HorizontalPodAutoscaler:
+		return serverSideApplyWith(ctx, clients.kubeClient.AutoscalingV2().HorizontalPodAutoscalers(t.Namespace), recorder, t, data, fieldManager)
+	case *networkingv1.NetworkPolicy:
+		return serverSideApplyWith(ctx, clients.kubeClient.NetworkingV1().NetworkPolicies(t.Namespace), recorder, t, data, fieldManager)
+	case *networkingv1.Ingress:
+		return serverSideApplyWith(ctx, clients.kubeClient.NetworkingV1().Ingresses(t.Namespace), recorder, t, data, fieldManager)
+	case *rbacv1.ClusterRole:
+		return serverSideApplyWith(ctx, clients.kubeClient.RbacV1().ClusterRoles(), recorder, t, data, fieldManager)
+	case *rbacv1.ClusterRoleBinding:
+		return serverSideApplyWith(ctx, clients.kubeClient.RbacV1().ClusterRoleBindings(), recorder, t, data, fieldManager)
+	case *rbacv1.Role:
+		return serverSideApplyWith(ctx, clients.kubeClient.RbacV1().Roles(t.Namespace), recorder, t, data, fieldManager)
+	case *rbacv1.RoleBinding:
+		return serverSideApplyWith(ctx, clients.kubeClient.RbacV1().RoleBindings(t.Namespace), recorder, t, data, fieldManager)
+	case *policyv1.PodDisruptionBudget:
+		return serverSideApplyWith(ctx, clients.kubeClient.PolicyV1().PodDisruptionBudgets(t.Namespace), recorder, t, data, fieldManager)
+	case *storagev1.StorageClass:
+		return serverSideApplyWith(ctx, clients.kubeClient.StorageV1().StorageClasses(), recorder, t, data, fieldManager)
+	case *storagev1.CSIDriver:
+		return serverSideApplyWith(ctx, clients.kubeClient.StorageV1().CSIDrivers(), recorder, t, data, fieldManager)
+	case *schedulingv1.PriorityClass:
+		return serverSideApplyWith(ctx, clients.kubeClient.SchedulingV1().PriorityClasses(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1.ValidatingWebhookConfiguration:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1.ValidatingAdmissionPolicy:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicies(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1.ValidatingAdmissionPolicyBinding:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1().ValidatingAdmissionPolicyBindings(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1beta1.ValidatingAdmissionPolicy:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1beta1().ValidatingAdmissionPolicies(), recorder, t, data, fieldManager)
+	case *admissionregistrationv1beta1.ValidatingAdmissionPolicyBinding:
+		return serverSideApplyWith(ctx, clients.kubeClient.AdmissionregistrationV1beta1().ValidatingAdmissionPolicyBindings(), recorder, t, data, fieldManager)
+	}
+	return nil, false, fmt.Errorf("server-side apply is not supported for %T", required)
+}
+
+// serverSideApplyWith server-side applies the given JSON manifest of the required object with the client.
+// The object is reported as changed when it was created or differs from the existing one in more than its
+// resourceVersion and managedFields.
+func serverSideApplyWith[T runtime.Object](ctx context.Context, client serverSideApplyClient[T], recorder events.Recorder, required T, data []byte, fieldManager string) (runtime.Object, bool, error) {
+	requiredMeta, err := meta.Accessor(required)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := client.Get(ctx, requiredMeta.GetName(), metav1.GetOptions{})
+	found := !apierrors.IsNotFound(err)
+	if err != nil && found {
+		return nil, false, err
+	}
+
+	actual, err := client.Patch(ctx, requiredMeta.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: fieldManager, Force: ptr.To(true)})
+	if !found {
+		resourcehelper.ReportCreateEvent(recorder, required, err)
+		return actual, err == nil, err
+	}
+	if err != nil {
+		resourcehelper.ReportUpdateEvent(recorder, required, err)
+		return actual, false, err
+	}
+	changed := !equality.Semantic.DeepEqual(withoutApplyMetadata(existing), withoutApplyMetadata(actual))
+	if changed {
+		resourcehelper.ReportUpdateEvent(recorder, required, nil)
+	}
+	return actual, changed, nil
+}
+
+// withoutApplyMetadata returns a copy of the object without the metadata updated by any apply.
+func withoutApplyMetadata(obj runtime.Object) runtime.Object {
+	obj = obj.DeepCopyObject()
+	if objMeta, err := meta.Accessor(obj); err == nil {
+		objMeta.SetResourceVersion("")
+		objMeta.SetManagedFields(nil)
+	}
+	return obj
+}
@@ -28,7 +28,7 @@ func ApplyKnownUnstructured(ctx context.Context, client dynamic.Interface, recor
 
 	}
 
-	return nil, false, fmt.Errorf("unsupported object type: %s", obj.GetKind())
+	return nil, false, fmt.Errorf("unsupported object type: %s", obj.GroupVersionKind())
 }
 
 // DeleteKnownUnstructured deletes few selected Unstructured types
@@ -47,5 +47,5 @@ func DeleteKnownUnstructured(ctx context.Context, client dynamic.Interface, reco
 
 	}
 
-	return nil, false, fmt.Errorf("unsupported object type: %s", obj.GetKind())
+	return nil, false, fmt.Errorf("unsupported object type: %s", obj.GroupVersionKind())
 }
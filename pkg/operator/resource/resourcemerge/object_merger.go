@@ -13,6 +13,8 @@ import (
 )
 
 // EnsureObjectMeta writes namespace, name, labels, and annotations.  Don't set other things here.
+// Owner references are merged like labels and annotations: the ones set on existing by other controllers
+// (e.g. garbage-collection owners) are kept, unless required explicitly removes them with a "-" UID suffix.
 // TODO finalizer support maybe?
 func EnsureObjectMeta(modified *bool, existing *metav1.ObjectMeta, required metav1.ObjectMeta) {
 	SetStringIfSet(modified, &existing.Namespace, required.Namespace)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -95,6 +96,74 @@ func TestMergeOwnerRefs(t *testing.T) {
 	}
 }
 
+func TestEnsureObjectMetaKeepsForeignOwnerRefs(t *testing.T) {
+	foreignOwner := newOwnerRef("ReplicaSet", "foreign", "apps/v1", "uid1")
+	requiredOwner := newOwnerRef("Kind", "test", "group/v1", "uid2")
+
+	tests := []struct {
+		name     string
+		required metav1.ObjectMeta
+		expected []metav1.OwnerReference
+		modified bool
+	}{
+		{
+			name:     "required without owner references",
+			required: metav1.ObjectMeta{Namespace: "ns", Name: "foo"},
+			expected: []metav1.OwnerReference{foreignOwner},
+			modified: false,
+		},
+		{
+			name:     "required with other owner references",
+			required: metav1.ObjectMeta{Namespace: "ns", Name: "foo", OwnerReferences: []metav1.OwnerReference{requiredOwner}},
+			expected: []metav1.OwnerReference{foreignOwner, requiredOwner},
+			modified: true,
+		},
+		{
+			name:     "required explicitly removing the foreign owner reference",
+			required: metav1.ObjectMeta{Namespace: "ns", Name: "foo", OwnerReferences: []metav1.OwnerReference{newOwnerRef("ReplicaSet", "foreign", "apps/v1", "uid1-")}},
+			expected: []metav1.OwnerReference{},
+			modified: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			existing := metav1.ObjectMeta{Namespace: "ns", Name: "foo", OwnerReferences: []metav1.OwnerReference{foreignOwner}}
+			modified := false
+			EnsureObjectMeta(&modified, &existing, test.required)
+
+			if !reflect.DeepEqual(existing.OwnerReferences, test.expected) {
+				t.Errorf("expected ownerrefs %v, but got %v", test.expected, existing.OwnerReferences)
+			}
+			if test.modified != modified {
+				t.Errorf("expected ownerrefs updates with %t, but got %t", test.modified, modified)
+			}
+		})
+	}
+
+	t.Run("unstructured", func(t *testing.T) {
+		existing := &unstructured.Unstructured{}
+		existing.SetNamespace("ns")
+		existing.SetName("foo")
+		existing.SetOwnerReferences([]metav1.OwnerReference{foreignOwner})
+		required := &unstructured.Unstructured{}
+		required.SetNamespace("ns")
+		required.SetName("foo")
+		required.SetLabels(map[string]string{"new": "label"})
+
+		modified := false
+		if err := EnsureObjectMetaForUnstructured(&modified, existing, required); err != nil {
+			t.Fatal(err)
+		}
+		if !modified {
+			t.Error("expected the labels to be updated")
+		}
+		if expected := []metav1.OwnerReference{foreignOwner}; !reflect.DeepEqual(existing.GetOwnerReferences(), expected) {
+			t.Errorf("expected ownerrefs %v, but got %v", expected, existing.GetOwnerReferences())
+		}
+	})
+}
+
 func TestCleanOwnerRefs(t *testing.T) {
 	tests := []struct {
 		name     string
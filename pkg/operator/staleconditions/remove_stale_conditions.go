@@ -64,9 +64,5 @@ func (c RemoveStaleConditionsController) sync(ctx context.Context, syncContext f
 		}
 	}
 
-	if jsonPatch.IsEmpty() {
-		return nil
-	}
-
-	return c.operatorClient.PatchOperatorStatus(ctx, jsonPatch)
+	return v1helpers.PatchOperatorStatus(ctx, c.operatorClient, jsonPatch)
 }
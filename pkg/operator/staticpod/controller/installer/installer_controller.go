@@ -630,6 +630,7 @@ func prepareNodeInstallerConditionApplyConfiguration(nodeStatuses []*applyoperat
 	// Available means that we have at least one pod at the latest level
 	numAvailable := 0
 	numProgressing := 0
+	progressingNodes := []string{}
 	counts := map[int32]int{}
 	failingCount := map[int32]int{}
 	failing := map[int32][]string{}
@@ -650,6 +651,7 @@ func prepareNodeInstallerConditionApplyConfiguration(nodeStatuses []*applyoperat
 
 		if latestAvailableRevision != currNodeStatus.CurrentRevision {
 			numProgressing += 1
+			progressingNodes = append(progressingNodes, nodeProgressDescription(currNodeStatus))
 		}
 	}
 
@@ -687,9 +689,11 @@ func prepareNodeInstallerConditionApplyConfiguration(nodeStatuses []*applyoperat
 		WithReason("AllNodesAtLatestRevision").
 		WithMessage(fmt.Sprintf("%s", revisionDescription))
 	if numProgressing > 0 {
+		// list the nodes that are not there yet, so that a node stuck installing can be spotted
 		progressingCondition = progressingCondition.
 			WithStatus(operatorv1.ConditionTrue).
-			WithReason("")
+			WithReason("").
+			WithMessage(fmt.Sprintf("%s; progressing nodes: %s", revisionDescription, strings.Join(progressingNodes, ", ")))
 	}
 
 	degradedCondition := applyoperatorv1.OperatorCondition().
@@ -712,6 +716,14 @@ func prepareNodeInstallerConditionApplyConfiguration(nodeStatuses []*applyoperat
 	return []*applyoperatorv1.OperatorConditionApplyConfiguration{availableCondition, progressingCondition, degradedCondition}
 }
 
+// nodeProgressDescription describes the revision of a node that is not at the latest available revision.
+func nodeProgressDescription(nodeStatus *operatorv1.NodeStatus) string {
+	if nodeStatus.TargetRevision == 0 || nodeStatus.TargetRevision == nodeStatus.CurrentRevision {
+		return fmt.Sprintf("%s at revision %d", nodeStatus.NodeName, nodeStatus.CurrentRevision)
+	}
+	return fmt.Sprintf("%s at revision %d installing revision %d", nodeStatus.NodeName, nodeStatus.CurrentRevision, nodeStatus.TargetRevision)
+}
+
 func prepareInstallerDegradedConditionApplyConfigurationFor(err error) *applyoperatorv1.OperatorConditionApplyConfiguration {
 	installerDegradedCondition := applyoperatorv1.OperatorCondition().
 		WithType(condition.InstallerControllerDegradedConditionType).
@@ -2400,6 +2400,73 @@ func TestSetConditions(t *testing.T) {
 
 }
 
+func TestSetConditionsProgressingNodes(t *testing.T) {
+	fakeStaticPodOperatorClient := v1helpers.NewFakeStaticPodOperatorClient(
+		&operatorv1.StaticPodOperatorSpec{
+			OperatorSpec: operatorv1.OperatorSpec{
+				ManagementState: operatorv1.Managed,
+			},
+		},
+		&operatorv1.StaticPodOperatorStatus{},
+		nil,
+		nil,
+	)
+	applyConditions := func(nodeStatuses []operatorv1.NodeStatus) *operatorv1.OperatorCondition {
+		t.Helper()
+		nodeStatusApplyConfigurations := prepareNodeStatusApplyConfigurationFor(nodeStatuses, nil)
+		operatorConditionApplyConfigurations := prepareNodeInstallerConditionApplyConfiguration(nodeStatusApplyConfigurations, 3)
+		statusApplyConfiguration := applyoperatorv1.StaticPodOperatorStatus().WithConditions(operatorConditionApplyConfigurations...)
+		if err := fakeStaticPodOperatorClient.ApplyStaticPodOperatorStatus(context.TODO(), "test", statusApplyConfiguration); err != nil {
+			t.Fatal(err)
+		}
+		_, status, _, err := fakeStaticPodOperatorClient.GetStaticPodOperatorState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		progressingCondition := v1helpers.FindOperatorCondition(status.Conditions, condition.NodeInstallerProgressingConditionType)
+		if progressingCondition == nil {
+			t.Fatal("Progressing condition: not found")
+		}
+		return progressingCondition
+	}
+
+	// node-b lags behind while node-a has already been rolled out
+	progressingCondition := applyConditions([]operatorv1.NodeStatus{
+		{NodeName: "node-a", CurrentRevision: 3},
+		{NodeName: "node-b", CurrentRevision: 2, TargetRevision: 3},
+	})
+	if progressingCondition.Status != operatorv1.ConditionTrue {
+		t.Errorf("Progressing condition: expected status %v, actual status %v", operatorv1.ConditionTrue, progressingCondition.Status)
+	}
+	expectedMessage := "1 node is at revision 2; 1 node is at revision 3; progressing nodes: node-b at revision 2 installing revision 3"
+	if progressingCondition.Message != expectedMessage {
+		t.Errorf("Progressing condition: expected message %q, actual message %q", expectedMessage, progressingCondition.Message)
+	}
+
+	// node-b has not been picked up yet
+	progressingCondition = applyConditions([]operatorv1.NodeStatus{
+		{NodeName: "node-a", CurrentRevision: 3},
+		{NodeName: "node-b", CurrentRevision: 2},
+	})
+	expectedMessage = "1 node is at revision 2; 1 node is at revision 3; progressing nodes: node-b at revision 2"
+	if progressingCondition.Message != expectedMessage {
+		t.Errorf("Progressing condition: expected message %q, actual message %q", expectedMessage, progressingCondition.Message)
+	}
+
+	// the installation completed on node-b
+	progressingCondition = applyConditions([]operatorv1.NodeStatus{
+		{NodeName: "node-a", CurrentRevision: 3},
+		{NodeName: "node-b", CurrentRevision: 3},
+	})
+	if progressingCondition.Status != operatorv1.ConditionFalse {
+		t.Errorf("Progressing condition: expected status %v, actual status %v", operatorv1.ConditionFalse, progressingCondition.Status)
+	}
+	expectedMessage = "2 nodes are at revision 3"
+	if progressingCondition.Message != expectedMessage {
+		t.Errorf("Progressing condition: expected message %q, actual message %q", expectedMessage, progressingCondition.Message)
+	}
+}
+
 func TestEnsureRequiredResources(t *testing.T) {
 	tests := []struct {
 		name           string
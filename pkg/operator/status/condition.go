@@ -19,6 +19,11 @@ import (
 //
 // If inertia is non-nil, then resist returning a condition with a status opposite the defaultConditionStatus.
 func UnionCondition(conditionType string, defaultConditionStatus operatorv1.ConditionStatus, inertia Inertia, allConditions ...operatorv1.OperatorCondition) operatorv1.OperatorCondition {
+	return unionCondition(time.Now(), conditionType, defaultConditionStatus, inertia, allConditions...)
+}
+
+// unionCondition is UnionCondition evaluating the inertia of the bad conditions at the given time.
+func unionCondition(now time.Time, conditionType string, defaultConditionStatus operatorv1.ConditionStatus, inertia Inertia, allConditions ...operatorv1.OperatorCondition) operatorv1.OperatorCondition {
 	var oppositeConditionStatus operatorv1.ConditionStatus
 	if defaultConditionStatus == operatorv1.ConditionTrue {
 		oppositeConditionStatus = operatorv1.ConditionFalse
@@ -54,7 +59,6 @@ func UnionCondition(conditionType string, defaultConditionStatus operatorv1.Cond
 	if inertia == nil {
 		elderBadConditions = badConditions
 	} else {
-		now := time.Now()
 		for _, condition := range badConditions {
 			if condition.LastTransitionTime.Time.Before(now.Add(-inertia(condition))) {
 				elderBadConditions = append(elderBadConditions, condition)
@@ -90,7 +94,11 @@ func UnionCondition(conditionType string, defaultConditionStatus operatorv1.Cond
 //
 // If inertia is non-nil, then resist returning a condition with a status opposite the defaultConditionStatus.
 func UnionClusterCondition(conditionType configv1.ClusterStatusConditionType, defaultConditionStatus operatorv1.ConditionStatus, inertia Inertia, allConditions ...operatorv1.OperatorCondition) configv1.ClusterOperatorStatusCondition {
-	cnd := UnionCondition(string(conditionType), defaultConditionStatus, inertia, allConditions...)
+	return unionClusterCondition(time.Now(), conditionType, defaultConditionStatus, inertia, allConditions...)
+}
+
+func unionClusterCondition(now time.Time, conditionType configv1.ClusterStatusConditionType, defaultConditionStatus operatorv1.ConditionStatus, inertia Inertia, allConditions ...operatorv1.OperatorCondition) configv1.ClusterOperatorStatusCondition {
+	cnd := unionCondition(now, string(conditionType), defaultConditionStatus, inertia, allConditions...)
 	return OperatorConditionToClusterOperatorCondition(cnd)
 }
 
@@ -0,0 +1,28 @@
+package status
+
+import (
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+)
+
+// OperatorDisabled is the informational condition reporting that a component was turned off, e.g. because
+// the feature it implements is disabled. It doesn't affect the health of the component.
+const OperatorDisabled configv1.ClusterStatusConditionType = "Disabled"
+
+// SetDisabledConditions sets the standard conditions of a disabled component: a disabled component is
+// Available=True, Progressing=False and Degraded=False since there is nothing to do, and Disabled=True.
+// All four conditions are given the same reason and message, e.g. "FeatureDisabled".
+func SetDisabledConditions(conditions *[]configv1.ClusterOperatorStatusCondition, reason, message string, clock clock.PassiveClock) {
+	for _, condition := range []configv1.ClusterOperatorStatusCondition{
+		{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+		{Type: configv1.OperatorProgressing, Status: configv1.ConditionFalse},
+		{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse},
+		{Type: OperatorDisabled, Status: configv1.ConditionTrue},
+	} {
+		condition.Reason = reason
+		condition.Message = message
+		configv1helpers.SetStatusCondition(conditions, condition, clock)
+	}
+}
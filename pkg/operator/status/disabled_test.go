@@ -0,0 +1,84 @@
+package status
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestSetDisabledConditions(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	disabled := func(conditionType configv1.ClusterStatusConditionType, status configv1.ConditionStatus, transition metav1.Time) configv1.ClusterOperatorStatusCondition {
+		return configv1.ClusterOperatorStatusCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             "FeatureDisabled",
+			Message:            "the feature is turned off",
+			LastTransitionTime: transition,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing []configv1.ClusterOperatorStatusCondition
+		expected []configv1.ClusterOperatorStatusCondition
+	}{
+		{
+			name: "no conditions",
+			expected: []configv1.ClusterOperatorStatusCondition{
+				disabled(configv1.OperatorAvailable, configv1.ConditionTrue, metav1.NewTime(now)),
+				disabled(configv1.OperatorProgressing, configv1.ConditionFalse, metav1.NewTime(now)),
+				disabled(configv1.OperatorDegraded, configv1.ConditionFalse, metav1.NewTime(now)),
+				disabled(OperatorDisabled, configv1.ConditionTrue, metav1.NewTime(now)),
+			},
+		},
+		{
+			name: "enabled component is disabled",
+			existing: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionFalse, Reason: "NoPods", LastTransitionTime: earlier},
+				{Type: configv1.OperatorProgressing, Status: configv1.ConditionTrue, Reason: "Rolling", LastTransitionTime: earlier},
+				{Type: configv1.OperatorDegraded, Status: configv1.ConditionFalse, Reason: "AsExpected", LastTransitionTime: earlier},
+				{Type: configv1.OperatorUpgradeable, Status: configv1.ConditionTrue, Reason: "AsExpected", LastTransitionTime: earlier},
+				{Type: OperatorDisabled, Status: configv1.ConditionFalse, Reason: "AsExpected", LastTransitionTime: earlier},
+			},
+			expected: []configv1.ClusterOperatorStatusCondition{
+				disabled(configv1.OperatorAvailable, configv1.ConditionTrue, metav1.NewTime(now)),
+				disabled(configv1.OperatorProgressing, configv1.ConditionFalse, metav1.NewTime(now)),
+				disabled(configv1.OperatorDegraded, configv1.ConditionFalse, earlier),
+				{Type: configv1.OperatorUpgradeable, Status: configv1.ConditionTrue, Reason: "AsExpected", LastTransitionTime: earlier},
+				disabled(OperatorDisabled, configv1.ConditionTrue, metav1.NewTime(now)),
+			},
+		},
+		{
+			name: "already disabled",
+			existing: []configv1.ClusterOperatorStatusCondition{
+				disabled(configv1.OperatorAvailable, configv1.ConditionTrue, earlier),
+				disabled(configv1.OperatorProgressing, configv1.ConditionFalse, earlier),
+				disabled(configv1.OperatorDegraded, configv1.ConditionFalse, earlier),
+				disabled(OperatorDisabled, configv1.ConditionTrue, earlier),
+			},
+			expected: []configv1.ClusterOperatorStatusCondition{
+				disabled(configv1.OperatorAvailable, configv1.ConditionTrue, earlier),
+				disabled(configv1.OperatorProgressing, configv1.ConditionFalse, earlier),
+				disabled(configv1.OperatorDegraded, configv1.ConditionFalse, earlier),
+				disabled(OperatorDisabled, configv1.ConditionTrue, earlier),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conditions := test.existing
+			SetDisabledConditions(&conditions, "FeatureDisabled", "the feature is turned off", clocktesting.NewFakePassiveClock(now))
+			if diff := cmp.Diff(test.expected, conditions); diff != "" {
+				t.Errorf("unexpected conditions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package status
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/utils/clock"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1client "github.com/openshift/client-go/config/clientset/versioned/typed/config/v1"
+	configv1informers "github.com/openshift/client-go/config/informers/externalversions/config/v1"
+	"github.com/openshift/library-go/pkg/controller/factory"
+	"github.com/openshift/library-go/pkg/operator/events"
+	operatorv1helpers "github.com/openshift/library-go/pkg/operator/v1helpers"
+)
+
+// ClusterOperatorSource describes one ClusterOperator managed by a MultiStatusSyncer.
+type ClusterOperatorSource struct {
+	// Name is the name of the ClusterOperator.
+	Name string
+	// RelatedObjects are published in the status of the ClusterOperator.
+	RelatedObjects []configv1.ObjectReference
+	// OperatorClient provides the operator conditions that are aggregated into the conditions of the ClusterOperator.
+	OperatorClient operatorv1helpers.OperatorClient
+}
+
+// MultiStatusSyncer manages the status of several ClusterOperators owned by the same operator,
+// e.g. when the operands are split. Every ClusterOperator aggregates the conditions of its own
+// source independently, the operand versions are shared.
+type MultiStatusSyncer struct {
+	name          string
+	syncers       []*StatusSyncer
+	versionGetter VersionGetter
+
+	controllerFactory *factory.Factory
+	recorder          events.Recorder
+}
+
+var _ factory.Controller = &MultiStatusSyncer{}
+
+// NewMultiClusterOperatorStatusController returns a controller that syncs the status of a ClusterOperator
+// per source, the same way NewClusterOperatorStatusController does for a single one.
+func NewMultiClusterOperatorStatusController(
+	name string,
+	sources []ClusterOperatorSource,
+	clusterOperatorClient configv1client.ClusterOperatorsGetter,
+	clusterOperatorInformer configv1informers.ClusterOperatorInformer,
+	versionGetter VersionGetter,
+	recorder events.Recorder,
+	clock clock.PassiveClock,
+) *MultiStatusSyncer {
+	informers := []factory.Informer{clusterOperatorInformer.Informer()}
+	syncers := make([]*StatusSyncer, 0, len(sources))
+	for _, source := range sources {
+		syncers = append(syncers, NewClusterOperatorStatusController(
+			source.Name,
+			source.RelatedObjects,
+			clusterOperatorClient,
+			clusterOperatorInformer,
+			source.OperatorClient,
+			versionGetter,
+			recorder,
+			clock,
+		))
+		informers = append(informers, source.OperatorClient.Informer())
+	}
+
+	return &MultiStatusSyncer{
+		name:              name,
+		syncers:           syncers,
+		versionGetter:     versionGetter,
+		controllerFactory: factory.New().ResyncEvery(time.Minute).WithInformers(informers...),
+		recorder:          recorder.WithComponentSuffix("status-controller"),
+	}
+}
+
+func (c *MultiStatusSyncer) Name() string {
+	return c.name
+}
+
+// ClusterOperatorNames returns the names of the managed ClusterOperators.
+func (c *MultiStatusSyncer) ClusterOperatorNames() []string {
+	names := make([]string, 0, len(c.syncers))
+	for _, syncer := range c.syncers {
+		names = append(names, syncer.Name())
+	}
+	return names
+}
+
+func (c *MultiStatusSyncer) Run(ctx context.Context, workers int) {
+	c.controllerFactory.
+		WithPostStartHooks(c.watchVersionGetterPostRunHook).
+		WithSync(c.Sync).
+		ToController(
+			"StatusSyncer_"+strings.Join(c.ClusterOperatorNames(), "_"),
+			c.recorder,
+		).
+		Run(ctx, workers)
+}
+
+// WithDegradedInertia returns a copy of the MultiStatusSyncer with the
+// requested inertia function for the degraded conditions of all managed ClusterOperators.
+func (c *MultiStatusSyncer) WithDegradedInertia(inertia Inertia) *MultiStatusSyncer {
+	return c.withSyncers(func(syncer *StatusSyncer) *StatusSyncer {
+		return syncer.WithDegradedInertia(inertia)
+	})
+}
+
+// WithDegradedRecoveryGrace returns a copy of the MultiStatusSyncer that delays
+// clearing the Degraded condition of all managed ClusterOperators by the given grace period.
+func (c *MultiStatusSyncer) WithDegradedRecoveryGrace(grace time.Duration) *MultiStatusSyncer {
+	return c.withSyncers(func(syncer *StatusSyncer) *StatusSyncer {
+		return syncer.WithDegradedRecoveryGrace(grace)
+	})
+}
+
+// WithVersionRemoval returns a copy of the MultiStatusSyncer that will remove versions
+// that are missing in VersionGetter from the status of all managed ClusterOperators.
+func (c *MultiStatusSyncer) WithVersionRemoval() *MultiStatusSyncer {
+	return c.withSyncers((*StatusSyncer).WithVersionRemoval)
+}
+
+func (c *MultiStatusSyncer) withSyncers(fn func(*StatusSyncer) *StatusSyncer) *MultiStatusSyncer {
+	output := *c
+	output.syncers = make([]*StatusSyncer, 0, len(c.syncers))
+	for _, syncer := range c.syncers {
+		output.syncers = append(output.syncers, fn(syncer))
+	}
+	return &output
+}
+
+// Sync syncs the status of every managed ClusterOperator. A failure for one of them
+// doesn't prevent the others from being synced, the errors are aggregated.
+func (c *MultiStatusSyncer) Sync(ctx context.Context, syncCtx factory.SyncContext) error {
+	var errs []error
+	for _, syncer := range c.syncers {
+		if err := syncer.Sync(ctx, syncCtx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *MultiStatusSyncer) watchVersionGetterPostRunHook(ctx context.Context, syncCtx factory.SyncContext) error {
+	return watchVersionGetter(ctx, syncCtx, c.versionGetter)
+}
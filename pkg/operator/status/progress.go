@@ -0,0 +1,25 @@
+package status
+
+import (
+	"fmt"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// RolloutProgressingCondition returns a progressing condition of the given type reporting how many of the
+// total units, e.g. "nodes", are updated, like "3 of 5 nodes updated.". The condition is True with reason
+// RolloutInProgress until all of them are updated, and False with reason AsExpected afterwards.
+// Set it by e.g. v1helpers.UpdateConditionFn.
+func RolloutProgressingCondition(conditionType string, updated, total int, units string) operatorv1.OperatorCondition {
+	condition := operatorv1.OperatorCondition{
+		Type:    conditionType,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  "AsExpected",
+		Message: fmt.Sprintf("%d of %d %s updated.", updated, total, units),
+	}
+	if updated < total {
+		condition.Status = operatorv1.ConditionTrue
+		condition.Reason = "RolloutInProgress"
+	}
+	return condition
+}
@@ -0,0 +1,71 @@
+package status
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+func TestRolloutProgressingCondition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		updated  int
+		total    int
+		expected operatorv1.OperatorCondition
+	}{
+		{
+			name:    "none updated",
+			updated: 0,
+			total:   5,
+			expected: operatorv1.OperatorCondition{
+				Type:    "NodeInstallerProgressing",
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "RolloutInProgress",
+				Message: "0 of 5 nodes updated.",
+			},
+		},
+		{
+			name:    "some updated",
+			updated: 3,
+			total:   5,
+			expected: operatorv1.OperatorCondition{
+				Type:    "NodeInstallerProgressing",
+				Status:  operatorv1.ConditionTrue,
+				Reason:  "RolloutInProgress",
+				Message: "3 of 5 nodes updated.",
+			},
+		},
+		{
+			name:    "all updated",
+			updated: 5,
+			total:   5,
+			expected: operatorv1.OperatorCondition{
+				Type:    "NodeInstallerProgressing",
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "AsExpected",
+				Message: "5 of 5 nodes updated.",
+			},
+		},
+		{
+			name:    "nothing to update",
+			updated: 0,
+			total:   0,
+			expected: operatorv1.OperatorCondition{
+				Type:    "NodeInstallerProgressing",
+				Status:  operatorv1.ConditionFalse,
+				Reason:  "AsExpected",
+				Message: "0 of 0 nodes updated.",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := RolloutProgressingCondition("NodeInstallerProgressing", tc.updated, tc.total, "nodes")
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected condition (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package status
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+// ReconcileRelatedObjects returns the related objects that should be published given the
+// existing list and the desired set.
+//
+// Entries from existing that are not part of desired are pruned, entries from desired that
+// are missing in existing are appended. The order of retained entries is preserved and new
+// entries are appended in the order they appear in desired, so that repeated reconciliation
+// with the same input yields the same output. Duplicates are dropped.
+func ReconcileRelatedObjects(existing, desired []configv1.ObjectReference) []configv1.ObjectReference {
+	desiredSet := make(map[configv1.ObjectReference]struct{}, len(desired))
+	for _, obj := range desired {
+		desiredSet[obj] = struct{}{}
+	}
+
+	seen := make(map[configv1.ObjectReference]struct{}, len(desired))
+	result := make([]configv1.ObjectReference, 0, len(desired))
+	for _, obj := range existing {
+		if _, ok := desiredSet[obj]; !ok {
+			continue
+		}
+		if _, ok := seen[obj]; ok {
+			continue
+		}
+		seen[obj] = struct{}{}
+		result = append(result, obj)
+	}
+	for _, obj := range desired {
+		if _, ok := seen[obj]; ok {
+			continue
+		}
+		seen[obj] = struct{}{}
+		result = append(result, obj)
+	}
+	return result
+}
+
+// RelatedObjectsFromManifests returns the related objects referencing the given objects, e.g. decoded from the manifests
+// the operator applies, in their order and without duplicates. The resource of an object is looked up by its kind
+// with the mapper, so the objects must have their kind set, as the objects decoded by resourceread do.
+func RelatedObjectsFromManifests(mapper meta.RESTMapper, objs ...runtime.Object) ([]configv1.ObjectReference, error) {
+	seen := make(map[configv1.ObjectReference]struct{}, len(objs))
+	result := make([]configv1.ObjectReference, 0, len(objs))
+	for i, obj := range objs {
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		if gvk.Empty() {
+			return nil, fmt.Errorf("object %d of type %T has no kind set", i, obj)
+		}
+		objMeta, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("object %d of kind %s: %w", i, gvk.Kind, err)
+		}
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("object %d of kind %s: %w", i, gvk.Kind, err)
+		}
+		ref := configv1.ObjectReference{
+			Group:     gvk.Group,
+			Resource:  mapping.Resource.Resource,
+			Namespace: objMeta.GetNamespace(),
+			Name:      objMeta.GetName(),
+		}
+		if _, ok := seen[ref]; ok {
+			continue
+		}
+		seen[ref] = struct{}{}
+		result = append(result, ref)
+	}
+	return result, nil
+}
@@ -0,0 +1,185 @@
+package status
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"github.com/openshift/library-go/pkg/operator/resource/resourceread"
+)
+
+func TestReconcileRelatedObjects(t *testing.T) {
+	ref := func(name string) configv1.ObjectReference {
+		return configv1.ObjectReference{
+			Group:     "A",
+			Resource:  "A",
+			Namespace: "A",
+			Name:      name,
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		existing []configv1.ObjectReference
+		desired  []configv1.ObjectReference
+		expected []configv1.ObjectReference
+	}{
+		{
+			name:     "empty",
+			expected: []configv1.ObjectReference{},
+		},
+		{
+			name:     "add to empty",
+			desired:  []configv1.ObjectReference{ref("A"), ref("B")},
+			expected: []configv1.ObjectReference{ref("A"), ref("B")},
+		},
+		{
+			name:     "no change",
+			existing: []configv1.ObjectReference{ref("A"), ref("B")},
+			desired:  []configv1.ObjectReference{ref("A"), ref("B")},
+			expected: []configv1.ObjectReference{ref("A"), ref("B")},
+		},
+		{
+			name:     "existing order is preserved",
+			existing: []configv1.ObjectReference{ref("B"), ref("A")},
+			desired:  []configv1.ObjectReference{ref("A"), ref("B")},
+			expected: []configv1.ObjectReference{ref("B"), ref("A")},
+		},
+		{
+			name:     "new entries are appended",
+			existing: []configv1.ObjectReference{ref("B")},
+			desired:  []configv1.ObjectReference{ref("C"), ref("B"), ref("A")},
+			expected: []configv1.ObjectReference{ref("B"), ref("C"), ref("A")},
+		},
+		{
+			name:     "stale entries are removed",
+			existing: []configv1.ObjectReference{ref("A"), ref("B"), ref("C")},
+			desired:  []configv1.ObjectReference{ref("C"), ref("A")},
+			expected: []configv1.ObjectReference{ref("A"), ref("C")},
+		},
+		{
+			name:     "add and remove",
+			existing: []configv1.ObjectReference{ref("A"), ref("B")},
+			desired:  []configv1.ObjectReference{ref("B"), ref("D")},
+			expected: []configv1.ObjectReference{ref("B"), ref("D")},
+		},
+		{
+			name:     "remove all",
+			existing: []configv1.ObjectReference{ref("A"), ref("B")},
+			expected: []configv1.ObjectReference{},
+		},
+		{
+			name:     "duplicates are dropped",
+			existing: []configv1.ObjectReference{ref("A"), ref("A")},
+			desired:  []configv1.ObjectReference{ref("A"), ref("B"), ref("B")},
+			expected: []configv1.ObjectReference{ref("A"), ref("B")},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := ReconcileRelatedObjects(tc.existing, tc.desired)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRelatedObjectsFromManifests(t *testing.T) {
+	manifests := []string{
+		`apiVersion: v1
+kind: Namespace
+metadata:
+  name: sample-ns
+`,
+		`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sample-config
+  namespace: sample-ns
+`,
+		`apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: sample-role
+`,
+		`apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: sample-policy
+  namespace: sample-ns
+`,
+		`apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: sample-monitor
+  namespace: sample-ns
+`,
+		`apiVersion: v1
+kind: Endpoints
+metadata:
+  name: sample-endpoints
+  namespace: sample-ns
+`,
+		`apiVersion: security.openshift.io/v1
+kind: SecurityContextConstraints
+metadata:
+  name: sample-scc
+`,
+		`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: sample-config
+  namespace: sample-ns
+data:
+  foo: bar
+`,
+	}
+	var objs []runtime.Object
+	for _, manifest := range manifests {
+		objs = append(objs, resourceread.ReadGenericWithUnstructuredOrDie([]byte(manifest)))
+	}
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}, meta.RESTScopeNamespace)
+	// the resources of kinds ending with "s" can't be guessed
+	mapper.AddSpecific(schema.GroupVersionKind{Version: "v1", Kind: "Endpoints"},
+		schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}, schema.GroupVersionResource{Version: "v1", Resource: "endpoints"}, meta.RESTScopeNamespace)
+	sccGVR := schema.GroupVersionResource{Group: "security.openshift.io", Version: "v1", Resource: "securitycontextconstraints"}
+	mapper.AddSpecific(schema.GroupVersionKind{Group: "security.openshift.io", Version: "v1", Kind: "SecurityContextConstraints"}, sccGVR, sccGVR, meta.RESTScopeRoot)
+
+	actual, err := RelatedObjectsFromManifests(mapper, objs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []configv1.ObjectReference{
+		{Resource: "namespaces", Name: "sample-ns"},
+		{Resource: "configmaps", Namespace: "sample-ns", Name: "sample-config"},
+		{Group: "rbac.authorization.k8s.io", Resource: "clusterroles", Name: "sample-role"},
+		{Group: "networking.k8s.io", Resource: "networkpolicies", Namespace: "sample-ns", Name: "sample-policy"},
+		{Group: "monitoring.coreos.com", Resource: "servicemonitors", Namespace: "sample-ns", Name: "sample-monitor"},
+		{Resource: "endpoints", Namespace: "sample-ns", Name: "sample-endpoints"},
+		{Group: "security.openshift.io", Resource: "securitycontextconstraints", Name: "sample-scc"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	if _, err := RelatedObjectsFromManifests(mapper, &corev1.ConfigMap{}); err == nil {
+		t.Error("expected an error for an object without kind")
+	}
+	unknown := resourceread.ReadGenericWithUnstructuredOrDie([]byte("apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: sample-widget\n"))
+	if _, err := RelatedObjectsFromManifests(mapper, unknown); err == nil {
+		t.Error("expected an error for an object of an unknown kind")
+	}
+}
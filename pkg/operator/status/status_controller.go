@@ -52,6 +52,8 @@ type StatusSyncer struct {
 	controllerFactory *factory.Factory
 	recorder          events.Recorder
 	degradedInertia   Inertia
+	// degradedRecoveryGrace is how long the degraded conditions must have been cleared before Degraded is cleared.
+	degradedRecoveryGrace time.Duration
 
 	removeUnusedVersions bool
 }
@@ -117,12 +119,28 @@ func (c *StatusSyncer) Run(ctx context.Context, workers int) {
 
 // WithDegradedInertia returns a copy of the StatusSyncer with the
 // requested inertia function for degraded conditions.
+//
+// A degraded operator condition is only published once it has been degraded
+// for at least its inertia duration, as measured by the clock of the StatusSyncer,
+// so that transient errors don't make the ClusterOperator flap. Recovery is published
+// as soon as it is observed.
 func (c *StatusSyncer) WithDegradedInertia(inertia Inertia) *StatusSyncer {
 	output := *c
 	output.degradedInertia = inertia
 	return &output
 }
 
+// WithDegradedRecoveryGrace returns a copy of the StatusSyncer that
+// keeps a published Degraded=True until the degraded operator conditions
+// have all been cleared for at least the given grace period, as measured
+// by the clock of the StatusSyncer, so that a recovering operator doesn't
+// make the ClusterOperator flap.
+func (c *StatusSyncer) WithDegradedRecoveryGrace(grace time.Duration) *StatusSyncer {
+	output := *c
+	output.degradedRecoveryGrace = grace
+	return &output
+}
+
 // WithVersionRemoval returns a copy of the StatusSyncer that will
 // remove versions that are missing in VersionGetter from the status.
 func (c *StatusSyncer) WithVersionRemoval() *StatusSyncer {
@@ -215,7 +233,13 @@ func (c StatusSyncer) Sync(ctx context.Context, syncCtx factory.SyncContext) err
 		clusterOperatorObj.Status.RelatedObjects = c.relatedObjects
 	}
 
-	configv1helpers.SetStatusCondition(&clusterOperatorObj.Status.Conditions, UnionClusterCondition(configv1.OperatorDegraded, operatorv1.ConditionFalse, c.degradedInertia, currentDetailedStatus.Conditions...), c.clock)
+	degradedCondition := unionClusterCondition(c.clock.Now(), configv1.OperatorDegraded, operatorv1.ConditionFalse, c.degradedInertia, currentDetailedStatus.Conditions...)
+	if remaining := c.degradedRecoveryGraceRemaining(clusterOperatorObj.Status.Conditions, degradedCondition); remaining > 0 {
+		// keep the published Degraded=True and check again once the grace period ends
+		syncCtx.Queue().AddAfter(factory.DefaultQueueKey, remaining)
+	} else {
+		configv1helpers.SetStatusCondition(&clusterOperatorObj.Status.Conditions, degradedCondition, c.clock)
+	}
 	configv1helpers.SetStatusCondition(&clusterOperatorObj.Status.Conditions, UnionClusterCondition(configv1.OperatorProgressing, operatorv1.ConditionFalse, nil, currentDetailedStatus.Conditions...), c.clock)
 	configv1helpers.SetStatusCondition(&clusterOperatorObj.Status.Conditions, UnionClusterCondition(configv1.OperatorAvailable, operatorv1.ConditionTrue, nil, currentDetailedStatus.Conditions...), c.clock)
 	configv1helpers.SetStatusCondition(&clusterOperatorObj.Status.Conditions, UnionClusterCondition(configv1.OperatorUpgradeable, operatorv1.ConditionTrue, nil, currentDetailedStatus.Conditions...), c.clock)
@@ -238,6 +262,19 @@ func (c StatusSyncer) Sync(ctx context.Context, syncCtx factory.SyncContext) err
 	return nil
 }
 
+// degradedRecoveryGraceRemaining returns how long the published Degraded=True must be kept
+// before the recovery reported by the given degraded condition is published.
+func (c *StatusSyncer) degradedRecoveryGraceRemaining(existingConditions []configv1.ClusterOperatorStatusCondition, degradedCondition configv1.ClusterOperatorStatusCondition) time.Duration {
+	if c.degradedRecoveryGrace <= 0 || degradedCondition.Status != configv1.ConditionFalse {
+		return 0
+	}
+	existing := configv1helpers.FindStatusCondition(existingConditions, configv1.OperatorDegraded)
+	if existing == nil || existing.Status != configv1.ConditionTrue {
+		return 0
+	}
+	return degradedCondition.LastTransitionTime.Add(c.degradedRecoveryGrace).Sub(c.clock.Now())
+}
+
 func skipOperatorStatusChangedEvent(originalStatus, newStatus configv1.ClusterOperatorStatus) bool {
 	originalCopy := *originalStatus.DeepCopy()
 	for i, condition := range originalCopy.Conditions {
@@ -276,9 +313,14 @@ func (c *StatusSyncer) syncStatusVersions(clusterOperatorObj *configv1.ClusterOp
 }
 
 func (c *StatusSyncer) watchVersionGetterPostRunHook(ctx context.Context, syncCtx factory.SyncContext) error {
+	return watchVersionGetter(ctx, syncCtx, c.versionGetter)
+}
+
+// watchVersionGetter queues a sync every time a version changes, until the context is cancelled.
+func watchVersionGetter(ctx context.Context, syncCtx factory.SyncContext, versionGetter VersionGetter) error {
 	defer utilruntime.HandleCrash()
 
-	versionCh := c.versionGetter.VersionChangedChannel()
+	versionCh := versionGetter.VersionChangedChannel()
 	// always kick at least once
 	syncCtx.Queue().Add(factory.DefaultQueueKey)
 
@@ -15,6 +15,7 @@ import (
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
 	"github.com/openshift/client-go/config/clientset/versioned/fake"
+	configinformers "github.com/openshift/client-go/config/informers/externalversions"
 	configv1listers "github.com/openshift/client-go/config/listers/config/v1"
 	applyoperatorv1 "github.com/openshift/client-go/operator/applyconfigurations/operator/v1"
 	"github.com/openshift/library-go/pkg/apiserver/jsonpatch"
@@ -802,3 +803,202 @@ func TestSkipOperatorStatusChangedEvent(t *testing.T) {
 		})
 	}
 }
+
+// newDegradedTestSyncer returns a StatusSyncer for the OPERATOR_NAME cluster operator, configured by configure,
+// whose operator reports the given conditions, and a function syncing it and checking the published Degraded status.
+func newDegradedTestSyncer(t *testing.T, fakeClock *clocktesting.FakeClock, conditions []operatorv1.OperatorCondition, configure func(*StatusSyncer) *StatusSyncer) (*StatusSyncer, *statusClient, func(configv1.ConditionStatus)) {
+	clusterOperator := &configv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{Name: "OPERATOR_NAME", ResourceVersion: "12"},
+	}
+	clusterOperatorClient := fake.NewSimpleClientset(clusterOperator)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	indexer.Add(clusterOperator)
+
+	statusClient := &statusClient{
+		t:      t,
+		status: operatorv1.OperatorStatus{Conditions: conditions},
+	}
+	controller := configure(&StatusSyncer{
+		clusterOperatorName:   "OPERATOR_NAME",
+		clusterOperatorClient: clusterOperatorClient.ConfigV1(),
+		clusterOperatorLister: configv1listers.NewClusterOperatorLister(indexer),
+		operatorClient:        statusClient,
+		versionGetter:         NewVersionGetter(),
+		clock:                 fakeClock,
+	})
+
+	syncAndCheckDegraded := func(expectedStatus configv1.ConditionStatus) {
+		t.Helper()
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("status", fakeClock))); err != nil {
+			t.Fatalf("unexpected sync error: %v", err)
+		}
+		result, err := clusterOperatorClient.ConfigV1().ClusterOperators().Get(context.TODO(), "OPERATOR_NAME", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := indexer.Update(result); err != nil {
+			t.Fatal(err)
+		}
+		actual := v1helpers.FindStatusCondition(result.Status.Conditions, configv1.OperatorDegraded)
+		if actual == nil || actual.Status != expectedStatus {
+			t.Fatalf("expected Degraded=%s, got %#v", expectedStatus, actual)
+		}
+	}
+	return controller, statusClient, syncAndCheckDegraded
+}
+
+func TestDegradedInertia(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	degradedSince := metav1.NewTime(fakeClock.Now())
+
+	_, statusClient, syncAndCheckDegraded := newDegradedTestSyncer(t, fakeClock, []operatorv1.OperatorCondition{
+		{Type: "TypeADegraded", Status: operatorv1.ConditionTrue, LastTransitionTime: degradedSince, Reason: "Error", Message: "a transient error"},
+	}, func(syncer *StatusSyncer) *StatusSyncer {
+		return syncer.WithDegradedInertia(MustNewInertia(2 * time.Minute).Inertia)
+	})
+
+	// a brief blip is suppressed
+	fakeClock.Step(30 * time.Second)
+	syncAndCheckDegraded(configv1.ConditionFalse)
+
+	// a persisting error is published once the inertia has passed
+	fakeClock.Step(2 * time.Minute)
+	syncAndCheckDegraded(configv1.ConditionTrue)
+
+	// recovery is published right away
+	statusClient.status.Conditions = []operatorv1.OperatorCondition{
+		{Type: "TypeADegraded", Status: operatorv1.ConditionFalse, LastTransitionTime: metav1.NewTime(fakeClock.Now())},
+	}
+	syncAndCheckDegraded(configv1.ConditionFalse)
+}
+
+func TestDegradedRecoveryGrace(t *testing.T) {
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+
+	_, statusClient, syncAndCheckDegraded := newDegradedTestSyncer(t, fakeClock, []operatorv1.OperatorCondition{
+		{Type: "TypeADegraded", Status: operatorv1.ConditionTrue, LastTransitionTime: metav1.NewTime(fakeClock.Now()), Reason: "Error", Message: "an error"},
+	}, func(syncer *StatusSyncer) *StatusSyncer {
+		return syncer.WithDegradedRecoveryGrace(time.Minute)
+	})
+	setDegraded := func(status operatorv1.ConditionStatus) {
+		statusClient.status.Conditions = []operatorv1.OperatorCondition{
+			{Type: "TypeADegraded", Status: status, LastTransitionTime: metav1.NewTime(fakeClock.Now())},
+		}
+	}
+
+	// degradation is published right away
+	syncAndCheckDegraded(configv1.ConditionTrue)
+
+	// a fresh recovery is not published
+	fakeClock.Step(time.Minute)
+	setDegraded(operatorv1.ConditionFalse)
+	fakeClock.Step(30 * time.Second)
+	syncAndCheckDegraded(configv1.ConditionTrue)
+
+	// an error coming back during the grace period restarts it
+	setDegraded(operatorv1.ConditionTrue)
+	syncAndCheckDegraded(configv1.ConditionTrue)
+	fakeClock.Step(10 * time.Second)
+	setDegraded(operatorv1.ConditionFalse)
+	fakeClock.Step(50 * time.Second)
+	syncAndCheckDegraded(configv1.ConditionTrue)
+
+	// the recovery is published once it lasted for the grace period
+	fakeClock.Step(10 * time.Second)
+	syncAndCheckDegraded(configv1.ConditionFalse)
+
+	// an operator which is not degraded is not affected by the grace period
+	fakeClock.Step(time.Second)
+	syncAndCheckDegraded(configv1.ConditionFalse)
+}
+
+func TestMultiStatusSyncer(t *testing.T) {
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	clusterOperatorClient := fake.NewSimpleClientset()
+	clusterOperatorInformer := configinformers.NewSharedInformerFactory(clusterOperatorClient, 0).Config().V1().ClusterOperators()
+
+	operandAStatus := &statusClient{
+		t: t,
+		status: operatorv1.OperatorStatus{
+			Conditions: []operatorv1.OperatorCondition{
+				{Type: "TypeADegraded", Status: operatorv1.ConditionTrue, LastTransitionTime: metav1.NewTime(fakeClock.Now().Add(-time.Hour)), Reason: "Error", Message: "operand A is broken"},
+				{Type: "TypeAAvailable", Status: operatorv1.ConditionTrue},
+			},
+		},
+	}
+	operandBStatus := &statusClient{
+		t: t,
+		status: operatorv1.OperatorStatus{
+			Conditions: []operatorv1.OperatorCondition{
+				{Type: "TypeBDegraded", Status: operatorv1.ConditionFalse},
+				{Type: "TypeBAvailable", Status: operatorv1.ConditionFalse, Reason: "NoPods", Message: "operand B has no pods"},
+			},
+		},
+	}
+	relatedObject := configv1.ObjectReference{Resource: "namespaces", Name: "operand-b"}
+
+	controller := NewMultiClusterOperatorStatusController(
+		"test",
+		[]ClusterOperatorSource{
+			{Name: "operand-a", OperatorClient: operandAStatus},
+			{Name: "operand-b", OperatorClient: operandBStatus, RelatedObjects: []configv1.ObjectReference{relatedObject}},
+		},
+		clusterOperatorClient.ConfigV1(),
+		clusterOperatorInformer,
+		NewVersionGetter(),
+		events.NewInMemoryRecorder("status", fakeClock),
+		fakeClock,
+	).WithDegradedInertia(MustNewInertia(2 * time.Minute).Inertia)
+
+	if names := controller.ClusterOperatorNames(); !reflect.DeepEqual(names, []string{"operand-a", "operand-b"}) {
+		t.Fatalf("unexpected cluster operator names: %v", names)
+	}
+
+	syncAndGet := func() (*configv1.ClusterOperator, *configv1.ClusterOperator) {
+		t.Helper()
+		if err := controller.Sync(context.TODO(), factory.NewSyncContext("test", events.NewInMemoryRecorder("status", fakeClock))); err != nil {
+			t.Fatalf("unexpected sync error: %v", err)
+		}
+		var ret []*configv1.ClusterOperator
+		for _, name := range []string{"operand-a", "operand-b"} {
+			clusterOperator, err := clusterOperatorClient.ConfigV1().ClusterOperators().Get(context.TODO(), name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := clusterOperatorInformer.Informer().GetIndexer().Update(clusterOperator); err != nil {
+				t.Fatal(err)
+			}
+			ret = append(ret, clusterOperator)
+		}
+		return ret[0], ret[1]
+	}
+	expectCondition := func(clusterOperator *configv1.ClusterOperator, conditionType configv1.ClusterStatusConditionType, expectedStatus configv1.ConditionStatus) {
+		t.Helper()
+		actual := v1helpers.FindStatusCondition(clusterOperator.Status.Conditions, conditionType)
+		if actual == nil || actual.Status != expectedStatus {
+			t.Errorf("expected clusteroperator/%s %s=%s, got %#v", clusterOperator.Name, conditionType, expectedStatus, actual)
+		}
+	}
+
+	// the first sync creates both cluster operators with their own conditions
+	operandA, operandB := syncAndGet()
+	expectCondition(operandA, configv1.OperatorDegraded, configv1.ConditionTrue)
+	expectCondition(operandA, configv1.OperatorAvailable, configv1.ConditionTrue)
+	expectCondition(operandB, configv1.OperatorDegraded, configv1.ConditionFalse)
+	expectCondition(operandB, configv1.OperatorAvailable, configv1.ConditionFalse)
+	if len(operandA.Status.RelatedObjects) != 0 {
+		t.Errorf("expected no related objects for clusteroperator/operand-a, got %v", operandA.Status.RelatedObjects)
+	}
+	if !reflect.DeepEqual(operandB.Status.RelatedObjects, []configv1.ObjectReference{relatedObject}) {
+		t.Errorf("unexpected related objects for clusteroperator/operand-b: %v", operandB.Status.RelatedObjects)
+	}
+
+	// a change in one source only affects its own cluster operator
+	operandBStatus.status.Conditions = []operatorv1.OperatorCondition{
+		{Type: "TypeBDegraded", Status: operatorv1.ConditionFalse},
+		{Type: "TypeBAvailable", Status: operatorv1.ConditionTrue},
+	}
+	operandA, operandB = syncAndGet()
+	expectCondition(operandA, configv1.OperatorDegraded, configv1.ConditionTrue)
+	expectCondition(operandB, configv1.OperatorAvailable, configv1.ConditionTrue)
+}
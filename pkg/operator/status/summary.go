@@ -0,0 +1,60 @@
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	configv1helpers "github.com/openshift/library-go/pkg/config/clusteroperator/v1helpers"
+)
+
+// summaryCondition describes how a condition contributes to the summary of the cluster operator conditions.
+type summaryCondition struct {
+	conditionType configv1.ClusterStatusConditionType
+	// badStatus is the status of the condition that is reported in the summary.
+	badStatus configv1.ConditionStatus
+	// badLabel prefixes the message of the condition when it has the badStatus.
+	badLabel string
+}
+
+// summaryConditions are ordered by decreasing severity.
+var summaryConditions = []summaryCondition{
+	{conditionType: configv1.OperatorDegraded, badStatus: configv1.ConditionTrue, badLabel: "Degraded"},
+	{conditionType: configv1.OperatorAvailable, badStatus: configv1.ConditionFalse, badLabel: "Unavailable"},
+	{conditionType: configv1.OperatorProgressing, badStatus: configv1.ConditionTrue, badLabel: "Progressing"},
+}
+
+// SummarizeClusterOperatorConditions composes the Degraded, Available and Progressing conditions into a single
+// human-readable line, e.g. for a status display next to the `oc get clusteroperator` columns.
+//
+// Conditions with a bad status (Degraded=True, Available=False, Progressing=True) or an unknown or missing status
+// are listed with the first line of their message, by decreasing severity and separated by "; ", e.g.
+// "Degraded: 1 of 3 pods are crashing; Progressing: 2 of 3 nodes updated.". When all of them are fine, the summary
+// is "Available".
+func SummarizeClusterOperatorConditions(conditions []configv1.ClusterOperatorStatusCondition) string {
+	var parts []string
+	for _, summary := range summaryConditions {
+		condition := configv1helpers.FindStatusCondition(conditions, summary.conditionType)
+		switch {
+		case condition == nil:
+			parts = append(parts, fmt.Sprintf("%s: Unknown", summary.conditionType))
+		case condition.Status == summary.badStatus:
+			parts = append(parts, summaryPart(summary.badLabel, condition.Message))
+		case condition.Status != configv1.ConditionTrue && condition.Status != configv1.ConditionFalse:
+			parts = append(parts, summaryPart(fmt.Sprintf("%s: Unknown", summary.conditionType), condition.Message))
+		}
+	}
+	if len(parts) == 0 {
+		return "Available"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// summaryPart returns the label followed by the first line of the message, if any.
+func summaryPart(label, message string) string {
+	message = strings.TrimSpace(strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+	if len(message) == 0 {
+		return label
+	}
+	return fmt.Sprintf("%s: %s", label, message)
+}
@@ -0,0 +1,75 @@
+package status
+
+import (
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+)
+
+func TestSummarizeClusterOperatorConditions(t *testing.T) {
+	condition := func(conditionType configv1.ClusterStatusConditionType, status configv1.ConditionStatus, message string) configv1.ClusterOperatorStatusCondition {
+		return configv1.ClusterOperatorStatusCondition{Type: conditionType, Status: status, Message: message}
+	}
+
+	testCases := []struct {
+		name       string
+		conditions []configv1.ClusterOperatorStatusCondition
+		expected   string
+	}{
+		{
+			name: "healthy",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionTrue, "All is well"),
+				condition(configv1.OperatorProgressing, configv1.ConditionFalse, "AsExpected"),
+				condition(configv1.OperatorDegraded, configv1.ConditionFalse, ""),
+			},
+			expected: "Available",
+		},
+		{
+			name: "progressing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+				condition(configv1.OperatorProgressing, configv1.ConditionTrue, "3 of 5 nodes updated."),
+				condition(configv1.OperatorDegraded, configv1.ConditionFalse, ""),
+			},
+			expected: "Progressing: 3 of 5 nodes updated.",
+		},
+		{
+			name: "degraded, unavailable and progressing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorProgressing, configv1.ConditionTrue, "rolling out"),
+				condition(configv1.OperatorAvailable, configv1.ConditionFalse, "no pods are running"),
+				condition(configv1.OperatorDegraded, configv1.ConditionTrue, "pods are crashing\nNodeControllerDegraded: all is well"),
+			},
+			expected: "Degraded: pods are crashing; Unavailable: no pods are running; Progressing: rolling out",
+		},
+		{
+			name: "degraded without a message",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionTrue, ""),
+				condition(configv1.OperatorProgressing, configv1.ConditionFalse, ""),
+				condition(configv1.OperatorDegraded, configv1.ConditionTrue, ""),
+			},
+			expected: "Degraded",
+		},
+		{
+			name: "unknown and missing",
+			conditions: []configv1.ClusterOperatorStatusCondition{
+				condition(configv1.OperatorAvailable, configv1.ConditionUnknown, "can't tell"),
+				condition(configv1.OperatorDegraded, configv1.ConditionFalse, ""),
+			},
+			expected: "Available: Unknown: can't tell; Progressing: Unknown",
+		},
+		{
+			name:     "no conditions",
+			expected: "Degraded: Unknown; Available: Unknown; Progressing: Unknown",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := SummarizeClusterOperatorConditions(tc.conditions); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package v1helpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// ComponentStatus is the operator status of one of the components, e.g. operands, rolled up into the status of an operator.
+type ComponentStatus struct {
+	// Name identifies the component in the messages of the aggregated conditions.
+	Name   string
+	Status operatorv1.OperatorStatus
+}
+
+// componentConditionRule describes how the conditions of a type are aggregated.
+type componentConditionRule struct {
+	conditionType string
+	// goodStatus is the status of the aggregated condition when all components report it.
+	goodStatus operatorv1.ConditionStatus
+	// missingIsGood is true when a component without the condition counts as reporting the goodStatus.
+	missingIsGood bool
+}
+
+var componentConditionRules = []componentConditionRule{
+	{conditionType: operatorv1.OperatorStatusTypeAvailable, goodStatus: operatorv1.ConditionTrue},
+	{conditionType: operatorv1.OperatorStatusTypeProgressing, goodStatus: operatorv1.ConditionFalse, missingIsGood: true},
+	{conditionType: operatorv1.OperatorStatusTypeDegraded, goodStatus: operatorv1.ConditionFalse, missingIsGood: true},
+	{conditionType: operatorv1.OperatorStatusTypeUpgradeable, goodStatus: operatorv1.ConditionTrue, missingIsGood: true},
+}
+
+// AggregateComponentConditions rolls the Available, Progressing, Degraded and Upgradeable conditions of the
+// given components up into conditions of the same types, returned in that order.
+//
+// An aggregated condition has the opposite of its good status (Available=False, Progressing=True, Degraded=True
+// or Upgradeable=False) if any component reports it, the status Unknown if any component reports an unknown status
+// or is missing Available, and the good status otherwise. Components without Progressing, Degraded or Upgradeable
+// count as reporting the good status. The message lists the messages of the components which determined the status,
+// prefixed with the component name; the reason is the component reason if only one of them did.
+func AggregateComponentConditions(components ...ComponentStatus) []operatorv1.OperatorCondition {
+	sortedComponents := append([]ComponentStatus{}, components...)
+	sort.SliceStable(sortedComponents, func(i, j int) bool {
+		return sortedComponents[i].Name < sortedComponents[j].Name
+	})
+
+	conditions := make([]operatorv1.OperatorCondition, 0, len(componentConditionRules))
+	for _, rule := range componentConditionRules {
+		conditions = append(conditions, aggregateComponentCondition(rule, sortedComponents))
+	}
+	return conditions
+}
+
+func aggregateComponentCondition(rule componentConditionRule, components []ComponentStatus) operatorv1.OperatorCondition {
+	badStatus := operatorv1.ConditionTrue
+	if rule.goodStatus == operatorv1.ConditionTrue {
+		badStatus = operatorv1.ConditionFalse
+	}
+
+	byStatus := map[operatorv1.ConditionStatus][]ComponentStatus{}
+	componentConditions := map[string]operatorv1.OperatorCondition{}
+	for _, component := range components {
+		condition := FindOperatorCondition(component.Status.Conditions, rule.conditionType)
+		switch {
+		case condition != nil:
+			componentConditions[component.Name] = *condition
+		case rule.missingIsGood:
+			componentConditions[component.Name] = operatorv1.OperatorCondition{Type: rule.conditionType, Status: rule.goodStatus}
+		default:
+			componentConditions[component.Name] = operatorv1.OperatorCondition{Type: rule.conditionType, Status: operatorv1.ConditionUnknown, Reason: "NoData", Message: fmt.Sprintf("%s condition is missing", rule.conditionType)}
+		}
+
+		status := componentConditions[component.Name].Status
+		if status != rule.goodStatus && status != badStatus {
+			status = operatorv1.ConditionUnknown
+		}
+		byStatus[status] = append(byStatus[status], component)
+	}
+
+	aggregated := operatorv1.OperatorCondition{Type: rule.conditionType}
+	var determining []ComponentStatus
+	switch {
+	case len(byStatus[badStatus]) > 0:
+		aggregated.Status = badStatus
+		determining = byStatus[badStatus]
+	case len(byStatus[operatorv1.ConditionUnknown]) > 0:
+		aggregated.Status = operatorv1.ConditionUnknown
+		determining = byStatus[operatorv1.ConditionUnknown]
+	case len(components) == 0:
+		aggregated.Status = operatorv1.ConditionUnknown
+		aggregated.Reason = "NoData"
+		return aggregated
+	default:
+		aggregated.Status = rule.goodStatus
+		aggregated.Reason = "AsExpected"
+		determining = components
+	}
+
+	var messages []string
+	for _, component := range determining {
+		condition := componentConditions[component.Name]
+		if aggregated.LastTransitionTime.Before(&condition.LastTransitionTime) {
+			aggregated.LastTransitionTime = condition.LastTransitionTime
+		}
+		if len(condition.Message) > 0 {
+			messages = append(messages, fmt.Sprintf("%s: %s", component.Name, condition.Message))
+		}
+	}
+	aggregated.Message = strings.Join(messages, "\n")
+	if aggregated.Status != rule.goodStatus {
+		aggregated.Reason = componentsReason(determining, componentConditions)
+	}
+	return aggregated
+}
+
+// componentsReason returns the reason of the single given component, or a reason saying that multiple components
+// determined the status.
+func componentsReason(components []ComponentStatus, componentConditions map[string]operatorv1.OperatorCondition) string {
+	if len(components) == 1 {
+		return componentConditions[components[0].Name].Reason
+	}
+	return "MultipleComponents"
+}
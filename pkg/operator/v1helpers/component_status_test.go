@@ -0,0 +1,96 @@
+package v1helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregateComponentConditions(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	later := metav1.NewTime(time.Now().Truncate(time.Second))
+
+	healthy := func(name string) ComponentStatus {
+		return ComponentStatus{Name: name, Status: operatorv1.OperatorStatus{Conditions: []operatorv1.OperatorCondition{
+			newOperatorCondition("Available", "True", "AsExpected", name+" is available", &earlier),
+			newOperatorCondition("Progressing", "False", "AsExpected", "", &earlier),
+			newOperatorCondition("Degraded", "False", "AsExpected", "", &earlier),
+		}}}
+	}
+
+	tests := []struct {
+		name       string
+		components []ComponentStatus
+		expected   []operatorv1.OperatorCondition
+	}{
+		{
+			name: "no components",
+			expected: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "Unknown", "NoData", "", nil),
+				newOperatorCondition("Progressing", "Unknown", "NoData", "", nil),
+				newOperatorCondition("Degraded", "Unknown", "NoData", "", nil),
+				newOperatorCondition("Upgradeable", "Unknown", "NoData", "", nil),
+			},
+		},
+		{
+			name:       "all healthy",
+			components: []ComponentStatus{healthy("b"), healthy("a")},
+			expected: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "a: a is available\nb: b is available", &earlier),
+				newOperatorCondition("Progressing", "False", "AsExpected", "", &earlier),
+				newOperatorCondition("Degraded", "False", "AsExpected", "", &earlier),
+				newOperatorCondition("Upgradeable", "True", "AsExpected", "", nil),
+			},
+		},
+		{
+			name: "mixed",
+			components: []ComponentStatus{
+				healthy("a"),
+				{Name: "b", Status: operatorv1.OperatorStatus{Conditions: []operatorv1.OperatorCondition{
+					newOperatorCondition("Available", "False", "NoPods", "no pods are running", &later),
+					newOperatorCondition("Progressing", "True", "Rollout", "rolling out", &later),
+					newOperatorCondition("Degraded", "True", "Crashing", "pods are crashing", &later),
+					newOperatorCondition("Upgradeable", "False", "Pinned", "pinned", &later),
+				}}},
+				{Name: "c", Status: operatorv1.OperatorStatus{Conditions: []operatorv1.OperatorCondition{
+					newOperatorCondition("Available", "True", "AsExpected", "", &earlier),
+					newOperatorCondition("Progressing", "True", "Rollout", "rolling out too", &earlier),
+					newOperatorCondition("Degraded", "Unknown", "Unreachable", "can't tell", &earlier),
+				}}},
+			},
+			expected: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "False", "NoPods", "b: no pods are running", &later),
+				newOperatorCondition("Progressing", "True", "MultipleComponents", "b: rolling out\nc: rolling out too", &later),
+				newOperatorCondition("Degraded", "True", "Crashing", "b: pods are crashing", &later),
+				newOperatorCondition("Upgradeable", "False", "Pinned", "b: pinned", &later),
+			},
+		},
+		{
+			name: "unknown",
+			components: []ComponentStatus{
+				healthy("a"),
+				{Name: "b", Status: operatorv1.OperatorStatus{Conditions: []operatorv1.OperatorCondition{
+					newOperatorCondition("Degraded", "Unknown", "Unreachable", "can't tell", &later),
+				}}},
+			},
+			expected: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "Unknown", "NoData", "b: Available condition is missing", nil),
+				newOperatorCondition("Progressing", "False", "AsExpected", "", &earlier),
+				newOperatorCondition("Degraded", "Unknown", "Unreachable", "b: can't tell", &later),
+				newOperatorCondition("Upgradeable", "True", "AsExpected", "", nil),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := AggregateComponentConditions(tc.components...)
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("unexpected conditions (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
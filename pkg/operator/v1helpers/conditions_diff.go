@@ -0,0 +1,49 @@
+package v1helpers
+
+import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// OperatorConditionsDiff describes how a list of operator conditions changed.
+type OperatorConditionsDiff struct {
+	// Added holds the conditions whose type is only present in the new list.
+	Added []operatorv1.OperatorCondition
+	// Removed holds the conditions whose type is only present in the old list.
+	Removed []operatorv1.OperatorCondition
+	// Changed holds the conditions present in both lists with a different status, reason or message.
+	Changed []OperatorConditionChange
+}
+
+// OperatorConditionChange holds the old and the new version of a changed condition.
+type OperatorConditionChange struct {
+	Old operatorv1.OperatorCondition
+	New operatorv1.OperatorCondition
+}
+
+// IsEmpty returns true when the conditions didn't change.
+func (d OperatorConditionsDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffOperatorConditions compares the old and new operator conditions by type.
+// A change of the last transition time alone is not reported.
+// Added and changed conditions are listed in the order of newConditions, removed ones in the order of oldConditions.
+func DiffOperatorConditions(oldConditions, newConditions []operatorv1.OperatorCondition) OperatorConditionsDiff {
+	diff := OperatorConditionsDiff{}
+	for _, newCondition := range newConditions {
+		oldCondition := FindOperatorCondition(oldConditions, newCondition.Type)
+		if oldCondition == nil {
+			diff.Added = append(diff.Added, newCondition)
+			continue
+		}
+		if oldCondition.Status != newCondition.Status || oldCondition.Reason != newCondition.Reason || oldCondition.Message != newCondition.Message {
+			diff.Changed = append(diff.Changed, OperatorConditionChange{Old: *oldCondition, New: newCondition})
+		}
+	}
+	for _, oldCondition := range oldConditions {
+		if FindOperatorCondition(newConditions, oldCondition.Type) == nil {
+			diff.Removed = append(diff.Removed, oldCondition)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,139 @@
+package v1helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiffOperatorConditions(t *testing.T) {
+	lastTransition := metav1.NewTime(time.Now().Add(-time.Hour))
+	later := metav1.NewTime(time.Now())
+
+	tests := []struct {
+		name          string
+		oldConditions []operatorv1.OperatorCondition
+		newConditions []operatorv1.OperatorCondition
+		expected      OperatorConditionsDiff
+	}{
+		{
+			name:     "empty",
+			expected: OperatorConditionsDiff{},
+		},
+		{
+			name: "unchanged",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", &lastTransition),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", &lastTransition),
+			},
+			expected: OperatorConditionsDiff{},
+		},
+		{
+			name: "only the last transition time changed",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", &lastTransition),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", &later),
+			},
+			expected: OperatorConditionsDiff{},
+		},
+		{
+			name: "added",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", nil),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Degraded", "False", "AsExpected", "", nil),
+				newOperatorCondition("Available", "True", "AsExpected", "", nil),
+				newOperatorCondition("Progressing", "False", "AsExpected", "", nil),
+			},
+			expected: OperatorConditionsDiff{
+				Added: []operatorv1.OperatorCondition{
+					newOperatorCondition("Degraded", "False", "AsExpected", "", nil),
+					newOperatorCondition("Progressing", "False", "AsExpected", "", nil),
+				},
+			},
+		},
+		{
+			name: "removed",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", nil),
+				newOperatorCondition("StaleDegraded", "True", "Error", "boom", nil),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", nil),
+			},
+			expected: OperatorConditionsDiff{
+				Removed: []operatorv1.OperatorCondition{
+					newOperatorCondition("StaleDegraded", "True", "Error", "boom", nil),
+				},
+			},
+		},
+		{
+			name: "status flips and message changes",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", &lastTransition),
+				newOperatorCondition("Degraded", "True", "Error", "first", &lastTransition),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "False", "NoPods", "no pods available", &later),
+				newOperatorCondition("Degraded", "True", "Error", "second", &lastTransition),
+			},
+			expected: OperatorConditionsDiff{
+				Changed: []OperatorConditionChange{
+					{
+						Old: newOperatorCondition("Available", "True", "AsExpected", "", &lastTransition),
+						New: newOperatorCondition("Available", "False", "NoPods", "no pods available", &later),
+					},
+					{
+						Old: newOperatorCondition("Degraded", "True", "Error", "first", &lastTransition),
+						New: newOperatorCondition("Degraded", "True", "Error", "second", &lastTransition),
+					},
+				},
+			},
+		},
+		{
+			name: "added, removed and changed",
+			oldConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "False", "NoPods", "", nil),
+				newOperatorCondition("Old", "True", "", "", nil),
+			},
+			newConditions: []operatorv1.OperatorCondition{
+				newOperatorCondition("Available", "True", "AsExpected", "", nil),
+				newOperatorCondition("New", "True", "", "", nil),
+			},
+			expected: OperatorConditionsDiff{
+				Added: []operatorv1.OperatorCondition{
+					newOperatorCondition("New", "True", "", "", nil),
+				},
+				Removed: []operatorv1.OperatorCondition{
+					newOperatorCondition("Old", "True", "", "", nil),
+				},
+				Changed: []OperatorConditionChange{
+					{
+						Old: newOperatorCondition("Available", "False", "NoPods", "", nil),
+						New: newOperatorCondition("Available", "True", "AsExpected", "", nil),
+					},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := DiffOperatorConditions(test.oldConditions, test.newConditions)
+			if diff := cmp.Diff(test.expected, actual); len(diff) > 0 {
+				t.Errorf("unexpected diff (-want +got):\n%s", diff)
+			}
+			if actual.IsEmpty() != (len(test.expected.Added)+len(test.expected.Removed)+len(test.expected.Changed) == 0) {
+				t.Errorf("unexpected IsEmpty() %v", actual.IsEmpty())
+			}
+		})
+	}
+}
@@ -1,15 +1,21 @@
 package v1helpers
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/google/go-cmp/cmp"
 
 	operatorsv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/apiserver/jsonpatch"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 func newOperatorCondition(name, status, reason, message string, lastTransition *metav1.Time) operatorsv1.OperatorCondition {
@@ -287,3 +293,457 @@ func TestRemoveCondition(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveStaleConditions(t *testing.T) {
+	withGeneration := func(condition metav1.Condition, generation int64) metav1.Condition {
+		condition.ObservedGeneration = generation
+		return condition
+	}
+
+	tests := []struct {
+		name               string
+		starting           []metav1.Condition
+		observedGeneration int64
+		expected           []metav1.Condition
+		expectedRemoved    []string
+	}{
+		{
+			name:               "empty",
+			starting:           []metav1.Condition{},
+			observedGeneration: 2,
+			expected:           []metav1.Condition{},
+		},
+		{
+			name: "stale conditions are cleared",
+			starting: []metav1.Condition{
+				withGeneration(newCondition("one", "True", "my-reason", "my-message", nil), 1),
+				withGeneration(newCondition("two", "True", "my-reason", "my-message", nil), 2),
+				withGeneration(newCondition("three", "False", "my-reason", "my-message", nil), 3),
+				withGeneration(newCondition("four", "False", "my-reason", "my-message", nil), 1),
+			},
+			observedGeneration: 2,
+			expected: []metav1.Condition{
+				withGeneration(newCondition("two", "True", "my-reason", "my-message", nil), 2),
+				withGeneration(newCondition("three", "False", "my-reason", "my-message", nil), 3),
+			},
+			expectedRemoved: []string{"one", "four"},
+		},
+		{
+			name: "conditions without a generation are kept",
+			starting: []metav1.Condition{
+				newCondition("one", "True", "my-reason", "my-message", nil),
+			},
+			observedGeneration: 2,
+			expected: []metav1.Condition{
+				newCondition("one", "True", "my-reason", "my-message", nil),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			removed := RemoveStaleConditions(&test.starting, test.observedGeneration)
+			if !equality.Semantic.DeepEqual(test.expectedRemoved, removed) {
+				t.Errorf("expected removed %v, got %v", test.expectedRemoved, removed)
+			}
+			if !equality.Semantic.DeepEqual(test.expected, test.starting) {
+				t.Errorf("%s", diff.ObjectDiff(test.expected, test.starting))
+			}
+		})
+	}
+
+	t.Run("conditions set for the current generation are kept", func(t *testing.T) {
+		conditions := []metav1.Condition{withGeneration(newCondition("one", "True", "my-reason", "my-message", nil), 1)}
+		SetCondition(&conditions, withGeneration(newCondition("one", "False", "my-reason", "my-message", nil), 2))
+		if removed := RemoveStaleConditions(&conditions, 2); len(removed) != 0 || len(conditions) != 1 {
+			t.Errorf("expected the condition recomputed by SetCondition to be kept, got %s", spew.Sdump(conditions))
+		}
+	})
+}
+
+func TestPatchOperatorStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		patch         *jsonpatch.PatchSet
+		expectedPatch string
+	}{
+		{
+			name: "nil patch is a no-op",
+		},
+		{
+			name:  "empty patch is a no-op",
+			patch: jsonpatch.New(),
+		},
+		{
+			name:          "patch is sent",
+			patch:         jsonpatch.New().WithRemove("/status/conditions/0", jsonpatch.NewTestCondition("/status/conditions/0/type", "Stale")),
+			expectedPatch: `[{"op":"test","path":"/status/conditions/0/type","value":"Stale"},{"op":"remove","path":"/status/conditions/0"}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewFakeOperatorClient(&operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{}, nil)
+			if err := PatchOperatorStatus(context.TODO(), client, test.patch); err != nil {
+				t.Fatal(err)
+			}
+
+			actualPatch := client.GetPatchedOperatorStatus()
+			if len(test.expectedPatch) == 0 {
+				if actualPatch != nil {
+					t.Fatalf("expected no patch to be sent, got %v", actualPatch)
+				}
+				return
+			}
+			if actualPatch == nil {
+				t.Fatal("expected a patch to be sent")
+			}
+			actualPatchBytes, err := actualPatch.Marshal()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(actualPatchBytes) != test.expectedPatch {
+				t.Errorf("expected patch %s, got %s", test.expectedPatch, actualPatchBytes)
+			}
+		})
+	}
+}
+
+func TestUpdateObservedGenerationFn(t *testing.T) {
+	client := NewFakeOperatorClientWithObjectMeta(&metav1.ObjectMeta{Generation: 2}, &operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{ObservedGeneration: 1}, nil)
+
+	objectMeta, err := client.GetObjectMeta()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, updated, err := UpdateStatus(context.TODO(), client, UpdateObservedGenerationFn(objectMeta.Generation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Error("expected the status to be updated")
+	}
+	if status.ObservedGeneration != 2 {
+		t.Errorf("expected observedGeneration 2, got %d", status.ObservedGeneration)
+	}
+	_, _, resourceVersion, _ := client.GetOperatorState()
+
+	status, updated, err = UpdateStatus(context.TODO(), client, UpdateObservedGenerationFn(objectMeta.Generation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated {
+		t.Error("didn't expect the status to be updated when the generation is already observed")
+	}
+	if status.ObservedGeneration != 2 {
+		t.Errorf("expected observedGeneration 2, got %d", status.ObservedGeneration)
+	}
+	if _, _, currentResourceVersion, _ := client.GetOperatorState(); currentResourceVersion != resourceVersion {
+		t.Errorf("expected resourceVersion %q to be unchanged, got %q", resourceVersion, currentResourceVersion)
+	}
+}
+
+func TestResetConditionsToUnknownFn(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	existingConditions := func() []operatorsv1.OperatorCondition {
+		return []operatorsv1.OperatorCondition{
+			newOperatorCondition("FooAvailable", "True", "AsExpected", "foo is available", &earlier),
+			newOperatorCondition("FooDegraded", "False", "AsExpected", "", &earlier),
+			newOperatorCondition("BarDegraded", "Unknown", "NotYetSynced", "", &earlier),
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		conditionTypes []string
+		expectedTypes  []string
+	}{
+		{
+			name:          "all existing conditions",
+			expectedTypes: []string{"FooAvailable", "FooDegraded", "BarDegraded"},
+		},
+		{
+			name:           "given conditions",
+			conditionTypes: []string{"FooDegraded", "BazProgressing"},
+			expectedTypes:  []string{"FooDegraded", "BazProgressing"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewFakeOperatorClient(&operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{Conditions: existingConditions()}, nil)
+			status, _, err := UpdateStatus(context.TODO(), client, ResetConditionsToUnknownFn(tc.conditionTypes...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedTypes := sets.New(tc.expectedTypes...)
+			for _, conditionType := range tc.expectedTypes {
+				condition := FindOperatorCondition(status.Conditions, conditionType)
+				if condition == nil {
+					t.Errorf("expected condition %s to be set", conditionType)
+					continue
+				}
+				if condition.Status != operatorsv1.ConditionUnknown || condition.Reason != "NotYetSynced" {
+					t.Errorf("expected condition %s to be Unknown with reason NotYetSynced, got %s with reason %s", conditionType, condition.Status, condition.Reason)
+				}
+			}
+			for _, existing := range existingConditions() {
+				if expectedTypes.Has(existing.Type) {
+					continue
+				}
+				if condition := FindOperatorCondition(status.Conditions, existing.Type); condition == nil || condition.Status != existing.Status {
+					t.Errorf("expected condition %s to be left untouched, got %v", existing.Type, condition)
+				}
+			}
+		})
+	}
+}
+
+func TestSetUnavailable(t *testing.T) {
+	testCases := []struct {
+		name            string
+		reason          string
+		message         string
+		expectedError   bool
+		expectedMessage string
+	}{
+		{
+			name:            "valid reason",
+			reason:          "NoPodsAvailable",
+			message:         "0 of 3 pods are available",
+			expectedMessage: "Unavailable: 0 of 3 pods are available",
+		},
+		{
+			name:            "reason with an acronym",
+			reason:          "APIServerDown",
+			message:         "the API server is down",
+			expectedMessage: "Unavailable: the API server is down",
+		},
+		{
+			name:            "already prefixed message",
+			reason:          "NoPodsAvailable",
+			message:         "Unavailable: 0 of 3 pods are available",
+			expectedMessage: "Unavailable: 0 of 3 pods are available",
+		},
+		{
+			name:          "empty reason",
+			reason:        "",
+			expectedError: true,
+		},
+		{
+			name:          "lower case reason",
+			reason:        "noPodsAvailable",
+			expectedError: true,
+		},
+		{
+			name:          "reason with spaces",
+			reason:        "No Pods Available",
+			expectedError: true,
+		},
+		{
+			name:          "reason with underscores",
+			reason:        "No_Pods",
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &operatorsv1.OperatorStatus{
+				Conditions: []operatorsv1.OperatorCondition{
+					newOperatorCondition(operatorsv1.OperatorStatusTypeAvailable, string(operatorsv1.ConditionTrue), "AsExpected", "", nil),
+				},
+			}
+			original := status.DeepCopy()
+
+			err := SetUnavailable(status, tc.reason, tc.message)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				if !equality.Semantic.DeepEqual(original, status) {
+					t.Errorf("expected the status to be unmodified, got %s", spew.Sdump(status))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			condition := FindOperatorCondition(status.Conditions, operatorsv1.OperatorStatusTypeAvailable)
+			if condition == nil {
+				t.Fatal("expected the Available condition")
+			}
+			if condition.Status != operatorsv1.ConditionFalse || condition.Reason != tc.reason || condition.Message != tc.expectedMessage {
+				t.Errorf("unexpected condition: %s", spew.Sdump(condition))
+			}
+		})
+	}
+}
+
+// advancingOperatorClient observes one more generation every time its status is read.
+type advancingOperatorClient struct {
+	OperatorClient
+	reads int
+}
+
+func (c *advancingOperatorClient) GetOperatorStateWithQuorum(ctx context.Context) (*operatorsv1.OperatorSpec, *operatorsv1.OperatorStatus, string, error) {
+	c.reads++
+	return &operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{ObservedGeneration: int64(c.reads)}, "", nil
+}
+
+func TestWaitForObservedGeneration(t *testing.T) {
+	defer func(interval time.Duration) { observedGenerationPollInterval = interval }(observedGenerationPollInterval)
+	observedGenerationPollInterval = time.Millisecond
+
+	client := &advancingOperatorClient{}
+	if err := WaitForObservedGeneration(context.TODO(), client, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.reads != 3 {
+		t.Errorf("expected to return once generation 3 is observed, got %d reads", client.reads)
+	}
+
+	// an already observed generation returns right away
+	if err := WaitForObservedGeneration(context.TODO(), NewFakeOperatorClient(&operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{ObservedGeneration: 5}, nil), 4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	err := WaitForObservedGeneration(ctx, NewFakeOperatorClient(&operatorsv1.OperatorSpec{}, &operatorsv1.OperatorStatus{ObservedGeneration: 1}, nil), 2)
+	if err == nil {
+		t.Fatalf("expected an error once the context is done")
+	}
+	if expected := "operator did not observe generation 2, last observed generation is 1: context deadline exceeded"; err.Error() != expected {
+		t.Errorf("expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestGetUnsupportedConfigOverrides(t *testing.T) {
+	testCases := []struct {
+		name          string
+		raw           string
+		expected      map[string]interface{}
+		expectedError string
+	}{
+		{
+			name:     "no overrides",
+			expected: map[string]interface{}{},
+		},
+		{
+			name:     "JSON overrides",
+			raw:      `{"operator":{"logLevel":"Debug"},"replicas":2}`,
+			expected: map[string]interface{}{"operator": map[string]interface{}{"logLevel": "Debug"}, "replicas": int64(2)},
+		},
+		{
+			name:     "YAML overrides",
+			raw:      "operator:\n  logLevel: Debug\n",
+			expected: map[string]interface{}{"operator": map[string]interface{}{"logLevel": "Debug"}},
+		},
+		{
+			name:          "malformed overrides",
+			raw:           `{"operator":`,
+			expectedError: "failed to unmarshal the unsupportedConfigOverrides: ",
+		},
+		{
+			name:          "overrides not an object",
+			raw:           `["operator"]`,
+			expectedError: "failed to unmarshal the unsupportedConfigOverrides: ",
+		},
+		{
+			name:          "null overrides",
+			raw:           `null`,
+			expectedError: "failed to unmarshal the unsupportedConfigOverrides: expected an object, got null",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := &operatorsv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(tc.raw)}}
+			actual, err := GetUnsupportedConfigOverrides(spec)
+			if len(tc.expectedError) > 0 {
+				if err == nil || !strings.HasPrefix(err.Error(), tc.expectedError) {
+					t.Fatalf("expected error starting with %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, actual.Object); diff != "" {
+				t.Errorf("unexpected overrides (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeUnsupportedConfigOverrides(t *testing.T) {
+	type overrides struct {
+		Operator struct {
+			LogLevel string `json:"logLevel"`
+		} `json:"operator"`
+	}
+
+	spec := &operatorsv1.OperatorSpec{UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(`{"operator":{"logLevel":"Debug"}}`)}}
+	actual := overrides{}
+	if err := DecodeUnsupportedConfigOverrides(spec, &actual); err != nil {
+		t.Fatal(err)
+	}
+	if actual.Operator.LogLevel != "Debug" {
+		t.Errorf("expected logLevel Debug, got %q", actual.Operator.LogLevel)
+	}
+
+	spec.UnsupportedConfigOverrides.Raw = []byte(`{"operator":{"logLevel":3}}`)
+	if err := DecodeUnsupportedConfigOverrides(spec, &actual); err == nil || !strings.Contains(err.Error(), "failed to unmarshal the unsupportedConfigOverrides") {
+		t.Errorf("expected an unmarshal error, got %v", err)
+	}
+}
+
+func TestOperatorSpecHash(t *testing.T) {
+	spec := func(logLevel operatorsv1.LogLevel, observedConfig, overrides string) *operatorsv1.OperatorSpec {
+		return &operatorsv1.OperatorSpec{
+			ManagementState:            operatorsv1.Managed,
+			LogLevel:                   logLevel,
+			ObservedConfig:             runtime.RawExtension{Raw: []byte(observedConfig)},
+			UnsupportedConfigOverrides: runtime.RawExtension{Raw: []byte(overrides)},
+		}
+	}
+	hash := func(spec *operatorsv1.OperatorSpec, fields ...string) string {
+		t.Helper()
+		specHash, err := OperatorSpecHash(spec, fields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return specHash
+	}
+
+	original := hash(spec(operatorsv1.Normal, `{"a":1,"b":{"c":"d"}}`, ""))
+	if actual := hash(spec(operatorsv1.Normal, `{"a":1,"b":{"c":"d"}}`, "")); actual != original {
+		t.Errorf("expected the hash of the same spec to be stable, got %q and %q", original, actual)
+	}
+	if actual := hash(spec(operatorsv1.Normal, "b:\n  c: d\na: 1\n", "")); actual != original {
+		t.Errorf("expected the hash not to depend on the encoding and key order of the observed config, got %q and %q", original, actual)
+	}
+	if actual := hash(spec(operatorsv1.Debug, `{"a":1,"b":{"c":"d"}}`, "")); actual == original {
+		t.Errorf("expected the hash to change with the log level")
+	}
+	if actual := hash(spec(operatorsv1.Normal, `{"a":2,"b":{"c":"d"}}`, "")); actual == original {
+		t.Errorf("expected the hash to change with the observed config")
+	}
+	if actual := hash(spec(operatorsv1.Normal, `{"a":1,"b":{"c":"d"}}`, `{"e":"f"}`)); actual == original {
+		t.Errorf("expected the hash to change with the unsupported config overrides")
+	}
+
+	// only the given fields are hashed
+	observedConfigHash := hash(spec(operatorsv1.Normal, `{"a":1}`, ""), "observedConfig")
+	if actual := hash(spec(operatorsv1.Debug, `{"a":1}`, `{"e":"f"}`), "observedConfig"); actual != observedConfigHash {
+		t.Errorf("expected the hash not to change with fields that aren't hashed, got %q and %q", observedConfigHash, actual)
+	}
+	if actual := hash(spec(operatorsv1.Normal, `{"a":2}`, ""), "observedConfig"); actual == observedConfigHash {
+		t.Errorf("expected the hash to change with the hashed observed config")
+	}
+
+	if _, err := OperatorSpecHash(spec(operatorsv1.Normal, "", ""), "observedConfg"); err == nil || err.Error() != `unknown operator spec field "observedConfg"` {
+		t.Errorf("expected an error for an unknown field, got %v", err)
+	}
+	if _, err := OperatorSpecHash(spec(operatorsv1.Normal, `{"a":`, "")); err == nil {
+		t.Error("expected an error for a malformed observed config")
+	}
+}
@@ -2,9 +2,14 @@ package v1helpers
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"os"
+	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -12,12 +17,16 @@ import (
 	"github.com/google/go-cmp/cmp"
 	configv1 "github.com/openshift/api/config/v1"
 	operatorv1 "github.com/openshift/api/operator/v1"
+	"github.com/openshift/library-go/pkg/apiserver/jsonpatch"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
@@ -27,6 +36,9 @@ const (
 	progressingConditionTimeout = 15 * time.Minute
 )
 
+// observedGenerationPollInterval is how often WaitForObservedGeneration reads the operator status.
+var observedGenerationPollInterval = time.Second
+
 // SetOperandVersion sets the new version and returns the previous value.
 func SetOperandVersion(versions *[]configv1.OperandVersion, operandVersion configv1.OperandVersion) string {
 	if versions == nil {
@@ -116,6 +128,28 @@ func IsOperatorConditionPresentAndEqual(conditions []operatorv1.OperatorConditio
 	return false
 }
 
+// UnavailableMessagePrefix prefixes the message of the Available=False conditions set by SetUnavailable.
+const UnavailableMessagePrefix = "Unavailable: "
+
+// unavailableReasonRegexp matches CamelCase reasons, e.g. "NoPodsAvailable" or "APIServerDown".
+var unavailableReasonRegexp = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// SetUnavailable sets the Available condition of the given status to False with the given reason and message.
+// The reason must be a non-empty CamelCase word, e.g. "NoPodsAvailable", otherwise an error is returned
+// and the status is not modified. The message is prefixed with UnavailableMessagePrefix.
+func SetUnavailable(status *operatorv1.OperatorStatus, reason, message string) error {
+	if !unavailableReasonRegexp.MatchString(reason) {
+		return fmt.Errorf("invalid unavailable reason %q: must be a non-empty CamelCase word", reason)
+	}
+	SetOperatorCondition(&status.Conditions, operatorv1.OperatorCondition{
+		Type:    operatorv1.OperatorStatusTypeAvailable,
+		Status:  operatorv1.ConditionFalse,
+		Reason:  reason,
+		Message: UnavailableMessagePrefix + strings.TrimPrefix(message, UnavailableMessagePrefix),
+	})
+	return nil
+}
+
 // UpdateOperatorSpecFunc is a func that mutates an operator spec.
 type UpdateOperatorSpecFunc func(spec *operatorv1.OperatorSpec) error
 
@@ -213,6 +247,17 @@ func UpdateStatus(ctx context.Context, client OperatorClient, updateFuncs ...Upd
 	return updatedOperatorStatus, updated, err
 }
 
+// PatchOperatorStatus issues the given JSON patch against the status of the operator resource.
+// Unlike UpdateStatus, it doesn't read the current status first, so it is suited for simple
+// changes that can be guarded by test operations instead of a resourceVersion precondition.
+// Empty patches are not sent.
+func PatchOperatorStatus(ctx context.Context, client OperatorClient, jsonPatch *jsonpatch.PatchSet) error {
+	if jsonPatch == nil || jsonPatch.IsEmpty() {
+		return nil
+	}
+	return client.PatchOperatorStatus(ctx, jsonPatch)
+}
+
 func operatorStatusJSONPatchNoError(original, modified *operatorv1.OperatorStatus) string {
 	if original == nil {
 		return "original object is nil"
@@ -232,6 +277,42 @@ func UpdateConditionFn(cond operatorv1.OperatorCondition) UpdateStatusFunc {
 	}
 }
 
+// ResetConditionsToUnknownFn returns a func to set the given conditions to Unknown with the reason NotYetSynced,
+// so that conditions left over by a previous operator process aren't mistaken for current ones. It is meant to be
+// called once on startup, before the controllers managing the conditions sync. Missing conditions are added.
+// Without condition types, all the existing conditions are reset.
+func ResetConditionsToUnknownFn(conditionTypes ...string) UpdateStatusFunc {
+	return func(oldStatus *operatorv1.OperatorStatus) error {
+		types := conditionTypes
+		if len(types) == 0 {
+			for _, condition := range oldStatus.Conditions {
+				types = append(types, condition.Type)
+			}
+		}
+		for _, conditionType := range types {
+			SetOperatorCondition(&oldStatus.Conditions, operatorv1.OperatorCondition{
+				Type:    conditionType,
+				Status:  operatorv1.ConditionUnknown,
+				Reason:  "NotYetSynced",
+				Message: "The operator has restarted and not synced this condition yet.",
+			})
+		}
+		return nil
+	}
+}
+
+// UpdateObservedGenerationFn returns a func to set status.observedGeneration to the given generation,
+// usually the metadata.generation of the operator resource as returned by OperatorClient.GetObjectMeta.
+// The status is left untouched when the generation is already observed.
+func UpdateObservedGenerationFn(generation int64) UpdateStatusFunc {
+	return func(oldStatus *operatorv1.OperatorStatus) error {
+		if oldStatus.ObservedGeneration != generation {
+			oldStatus.ObservedGeneration = generation
+		}
+		return nil
+	}
+}
+
 // UpdateStaticPodStatusFunc is a func that mutates an operator status.
 type UpdateStaticPodStatusFunc func(status *operatorv1.StaticPodOperatorStatus) error
 
@@ -516,6 +597,7 @@ func SetCondition(conditions *[]metav1.Condition, newCondition metav1.Condition)
 
 	existingCondition.Reason = newCondition.Reason
 	existingCondition.Message = newCondition.Message
+	existingCondition.ObservedGeneration = newCondition.ObservedGeneration
 }
 
 func RemoveCondition(conditions *[]metav1.Condition, conditionType string) {
@@ -532,6 +614,27 @@ func RemoveCondition(conditions *[]metav1.Condition, conditionType string) {
 	*conditions = newConditions
 }
 
+// RemoveStaleConditions removes the conditions computed for a generation older than observedGeneration,
+// usually the status.observedGeneration of the resource, and returns their types so that the caller can
+// recompute them. Conditions that don't record their observedGeneration are kept.
+func RemoveStaleConditions(conditions *[]metav1.Condition, observedGeneration int64) []string {
+	if conditions == nil {
+		return nil
+	}
+	var removed []string
+	newConditions := []metav1.Condition{}
+	for _, condition := range *conditions {
+		if condition.ObservedGeneration != 0 && condition.ObservedGeneration < observedGeneration {
+			removed = append(removed, condition.Type)
+			continue
+		}
+		newConditions = append(newConditions, condition)
+	}
+
+	*conditions = newConditions
+	return removed
+}
+
 func FindCondition(conditions []metav1.Condition, conditionType string) *metav1.Condition {
 	for i := range conditions {
 		if conditions[i].Type == conditionType {
@@ -565,3 +668,117 @@ func IsUpdatingTooLong(operatorStatus *operatorv1.OperatorStatus, progressingCon
 	progressing := FindOperatorCondition(operatorStatus.Conditions, progressingConditionType)
 	return progressing != nil && progressing.Status == operatorv1.ConditionTrue && time.Now().After(progressing.LastTransitionTime.Add(progressingConditionTimeout))
 }
+
+// WaitForObservedGeneration blocks until the status of the operator reports an observedGeneration of at least generation,
+// reading it directly from the server, e.g. to wait for the operator to react to a spec change before checking its conditions.
+// It returns an error once the context is done, reporting the last observed generation.
+func WaitForObservedGeneration(ctx context.Context, client OperatorClient, generation int64) error {
+	var observedGeneration int64
+	err := wait.PollUntilContextCancel(ctx, observedGenerationPollInterval, true, func(ctx context.Context) (bool, error) {
+		_, status, _, err := client.GetOperatorStateWithQuorum(ctx)
+		if err != nil {
+			// keep polling, the read may succeed on a retry
+			klog.V(4).Infof("Unable to read the operator status: %v", err)
+			return false, nil
+		}
+		observedGeneration = status.ObservedGeneration
+		return observedGeneration >= generation, nil
+	})
+	if err != nil {
+		return fmt.Errorf("operator did not observe generation %d, last observed generation is %d: %w", generation, observedGeneration, err)
+	}
+	return nil
+}
+
+// GetUnsupportedConfigOverrides returns the spec.unsupportedConfigOverrides of the given operator spec as an unstructured
+// object. The object is empty when no overrides are set. An error is returned for overrides that aren't a JSON or YAML object.
+func GetUnsupportedConfigOverrides(spec *operatorv1.OperatorSpec) (*unstructured.Unstructured, error) {
+	overrides := map[string]interface{}{}
+	if err := DecodeUnsupportedConfigOverrides(spec, &overrides); err != nil {
+		return nil, err
+	}
+	if overrides == nil {
+		return nil, fmt.Errorf("failed to unmarshal the unsupportedConfigOverrides: expected an object, got null")
+	}
+	return &unstructured.Unstructured{Object: overrides}, nil
+}
+
+// DecodeUnsupportedConfigOverrides unmarshals the spec.unsupportedConfigOverrides of the given operator spec, which may be
+// JSON or YAML, into the value pointed to by into. into is left untouched when no overrides are set.
+func DecodeUnsupportedConfigOverrides(spec *operatorv1.OperatorSpec, into interface{}) error {
+	raw := spec.UnsupportedConfigOverrides.Raw
+	if len(raw) == 0 {
+		return nil
+	}
+	jsonRaw, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal the unsupportedConfigOverrides: %w", err)
+	}
+	// unlike encoding/json, utiljson decodes integers as int64 as expected by unstructured objects
+	if err := utiljson.Unmarshal(jsonRaw, into); err != nil {
+		return fmt.Errorf("failed to unmarshal the unsupportedConfigOverrides: %w", err)
+	}
+	return nil
+}
+
+// OperatorSpecHash returns a stable hash of the given fields of the operator spec, named as in JSON, e.g. "observedConfig",
+// or of the whole spec when no fields are given, so that a controller can skip expensive work when they didn't change
+// since its last sync. The raw unsupportedConfigOverrides and observedConfig are hashed by their content, independently
+// of their encoding and key order. An error is returned for an unknown field or raw content that isn't JSON or YAML.
+func OperatorSpecHash(spec *operatorv1.OperatorSpec, fields ...string) (string, error) {
+	normalized := spec.DeepCopy()
+	for name, raw := range map[string]*runtime.RawExtension{
+		"unsupportedConfigOverrides": &normalized.UnsupportedConfigOverrides,
+		"observedConfig":             &normalized.ObservedConfig,
+	} {
+		if len(raw.Raw) == 0 {
+			raw.Raw = nil
+			continue
+		}
+		jsonRaw, err := yaml.YAMLToJSON(raw.Raw)
+		if err != nil {
+			return "", fmt.Errorf("failed to unmarshal the %s: %w", name, err)
+		}
+		raw.Raw = jsonRaw
+	}
+
+	specJSON, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	// decoding into generic values sorts the keys of all objects when encoding them again
+	specFields := map[string]interface{}{}
+	if err := utiljson.Unmarshal(specJSON, &specFields); err != nil {
+		return "", err
+	}
+
+	selected := specFields
+	if len(fields) > 0 {
+		knownFields := jsonFieldNames(reflect.TypeOf(operatorv1.OperatorSpec{}))
+		selected = map[string]interface{}{}
+		for _, field := range fields {
+			if !knownFields.Has(field) {
+				return "", fmt.Errorf("unknown operator spec field %q", field)
+			}
+			selected[field] = specFields[field]
+		}
+	}
+
+	hasher := fnv.New32()
+	if err := json.NewEncoder(hasher).Encode(selected); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// jsonFieldNames returns the JSON names of the fields of the given struct type.
+func jsonFieldNames(structType reflect.Type) sets.Set[string] {
+	names := sets.New[string]()
+	for i := 0; i < structType.NumField(); i++ {
+		name, _, _ := strings.Cut(structType.Field(i).Tag.Get("json"), ",")
+		if len(name) > 0 && name != "-" {
+			names.Insert(name)
+		}
+	}
+	return names
+}
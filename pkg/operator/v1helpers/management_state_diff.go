@@ -0,0 +1,20 @@
+package v1helpers
+
+import (
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+// ManagementStateChanged compares the management state of the old and new operator spec and returns the new state
+// and true when it changed, e.g. to react to the operator being set to Removed in a sync loop.
+// A nil spec, e.g. when the old spec wasn't observed yet, has an empty management state.
+func ManagementStateChanged(oldSpec, newSpec *operatorv1.OperatorSpec) (operatorv1.ManagementState, bool) {
+	oldState, newState := managementState(oldSpec), managementState(newSpec)
+	return newState, oldState != newState
+}
+
+func managementState(spec *operatorv1.OperatorSpec) operatorv1.ManagementState {
+	if spec == nil {
+		return ""
+	}
+	return spec.ManagementState
+}
@@ -0,0 +1,52 @@
+package v1helpers
+
+import (
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+)
+
+func TestManagementStateChanged(t *testing.T) {
+	spec := func(state operatorv1.ManagementState) *operatorv1.OperatorSpec {
+		return &operatorv1.OperatorSpec{ManagementState: state, LogLevel: operatorv1.Normal}
+	}
+
+	tests := []struct {
+		name            string
+		oldSpec         *operatorv1.OperatorSpec
+		newSpec         *operatorv1.OperatorSpec
+		expectedState   operatorv1.ManagementState
+		expectedChanged bool
+	}{
+		{name: "managed unchanged", oldSpec: spec(operatorv1.Managed), newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed},
+		{name: "unmanaged unchanged", oldSpec: spec(operatorv1.Unmanaged), newSpec: spec(operatorv1.Unmanaged), expectedState: operatorv1.Unmanaged},
+		{name: "removed unchanged", oldSpec: spec(operatorv1.Removed), newSpec: spec(operatorv1.Removed), expectedState: operatorv1.Removed},
+		{name: "managed to unmanaged", oldSpec: spec(operatorv1.Managed), newSpec: spec(operatorv1.Unmanaged), expectedState: operatorv1.Unmanaged, expectedChanged: true},
+		{name: "managed to removed", oldSpec: spec(operatorv1.Managed), newSpec: spec(operatorv1.Removed), expectedState: operatorv1.Removed, expectedChanged: true},
+		{name: "unmanaged to managed", oldSpec: spec(operatorv1.Unmanaged), newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed, expectedChanged: true},
+		{name: "unmanaged to removed", oldSpec: spec(operatorv1.Unmanaged), newSpec: spec(operatorv1.Removed), expectedState: operatorv1.Removed, expectedChanged: true},
+		{name: "removed to managed", oldSpec: spec(operatorv1.Removed), newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed, expectedChanged: true},
+		{name: "removed to unmanaged", oldSpec: spec(operatorv1.Removed), newSpec: spec(operatorv1.Unmanaged), expectedState: operatorv1.Unmanaged, expectedChanged: true},
+		{name: "force to managed", oldSpec: spec(operatorv1.Force), newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed, expectedChanged: true},
+		{name: "unset to managed", oldSpec: spec(""), newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed, expectedChanged: true},
+		{name: "first observation", newSpec: spec(operatorv1.Managed), expectedState: operatorv1.Managed, expectedChanged: true},
+		{name: "spec gone", oldSpec: spec(operatorv1.Managed), expectedState: "", expectedChanged: true},
+		{
+			name:          "other spec changes are ignored",
+			oldSpec:       spec(operatorv1.Managed),
+			newSpec:       &operatorv1.OperatorSpec{ManagementState: operatorv1.Managed, LogLevel: operatorv1.Debug},
+			expectedState: operatorv1.Managed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state, changed := ManagementStateChanged(test.oldSpec, test.newSpec)
+			if state != test.expectedState {
+				t.Errorf("expected state %q, got %q", test.expectedState, state)
+			}
+			if changed != test.expectedChanged {
+				t.Errorf("expected changed %v, got %v", test.expectedChanged, changed)
+			}
+		})
+	}
+}
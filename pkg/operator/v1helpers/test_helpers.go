@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -297,7 +299,64 @@ type fakeOperatorClient struct {
 	resourceVersion          string
 	triggerStatusUpdateError func(rv string, status *operatorv1.OperatorStatus) error
 
+	// statusUpdateErrors and specUpdateErrors are consumed in order, one per update call.
+	statusUpdateErrors []error
+	specUpdateErrors   []error
+
 	patchedOperatorStatus *jsonpatch.PatchSet
+
+	// specSnapshot and statusSnapshot hold copies of the last spec and status written through
+	// their own update methods, they are only set when the status subresource is enforced.
+	specSnapshot   *operatorv1.OperatorSpec
+	statusSnapshot *operatorv1.OperatorStatus
+}
+
+// WithStatusSubresource makes the client enforce that the spec and the status are only changed through their own
+// update methods, like a resource with a status subresource does. Since GetOperatorState returns the stored objects,
+// a test could otherwise mutate the status it got and persist it with UpdateOperatorSpec, which would be lost in a real cluster.
+// UpdateOperatorSpec then rejects changes to the status and UpdateOperatorStatus rejects changes to the spec.
+func (c *fakeOperatorClient) WithStatusSubresource() *fakeOperatorClient {
+	c.specSnapshot = c.fakeOperatorSpec.DeepCopy()
+	c.statusSnapshot = c.fakeOperatorStatus.DeepCopy()
+	return c
+}
+
+// checkUnchanged returns an error if the status subresource is enforced and the current object
+// was modified without going through its update method.
+func checkUnchanged(enforced bool, current, snapshot interface{}, what, method string) error {
+	if !enforced || equality.Semantic.DeepEqual(current, snapshot) {
+		return nil
+	}
+	return errors.NewBadRequest(fmt.Sprintf("the %s was changed without calling %s: %s", what, method, cmp.Diff(snapshot, current)))
+}
+
+func (c *fakeOperatorClient) statusSubresourceEnforced() bool {
+	return c.specSnapshot != nil
+}
+
+// WithStatusUpdateErrors programs the errors returned by subsequent UpdateOperatorStatus calls.
+// Each call consumes the next error in order, a nil entry lets the call proceed normally.
+// Once the sequence is exhausted, updates behave as usual.
+// This is useful to deterministically exercise retry logic, e.g. a conflict followed by a success.
+func (c *fakeOperatorClient) WithStatusUpdateErrors(errs ...error) *fakeOperatorClient {
+	c.statusUpdateErrors = append(c.statusUpdateErrors, errs...)
+	return c
+}
+
+// WithSpecUpdateErrors programs the errors returned by subsequent UpdateOperatorSpec calls.
+// It follows the same semantics as WithStatusUpdateErrors.
+func (c *fakeOperatorClient) WithSpecUpdateErrors(errs ...error) *fakeOperatorClient {
+	c.specUpdateErrors = append(c.specUpdateErrors, errs...)
+	return c
+}
+
+func popUpdateError(errs *[]error) error {
+	if len(*errs) == 0 {
+		return nil
+	}
+	err := (*errs)[0]
+	*errs = (*errs)[1:]
+	return err
 }
 
 func (c *fakeOperatorClient) Informer() cache.SharedIndexInformer {
@@ -321,9 +380,15 @@ func (c *fakeOperatorClient) GetOperatorStateWithQuorum(ctx context.Context) (*o
 }
 
 func (c *fakeOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceVersion string, status *operatorv1.OperatorStatus) (*operatorv1.OperatorStatus, error) {
+	if err := popUpdateError(&c.statusUpdateErrors); err != nil {
+		return nil, err
+	}
 	if c.resourceVersion != resourceVersion {
 		return nil, errors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "TestOperatorConfig"}, "instance", fmt.Errorf("invalid resourceVersion"))
 	}
+	if err := checkUnchanged(c.statusSubresourceEnforced(), c.fakeOperatorSpec, c.specSnapshot, "spec", "UpdateOperatorSpec"); err != nil {
+		return nil, err
+	}
 	rv, err := strconv.Atoi(resourceVersion)
 	if err != nil {
 		return nil, err
@@ -335,19 +400,31 @@ func (c *fakeOperatorClient) UpdateOperatorStatus(ctx context.Context, resourceV
 		}
 	}
 	c.fakeOperatorStatus = status
+	if c.statusSubresourceEnforced() {
+		c.statusSnapshot = status.DeepCopy()
+	}
 	return c.fakeOperatorStatus, nil
 }
 
 func (c *fakeOperatorClient) UpdateOperatorSpec(ctx context.Context, resourceVersion string, spec *operatorv1.OperatorSpec) (*operatorv1.OperatorSpec, string, error) {
+	if err := popUpdateError(&c.specUpdateErrors); err != nil {
+		return nil, c.resourceVersion, err
+	}
 	if c.resourceVersion != resourceVersion {
 		return nil, c.resourceVersion, errors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "TestOperatorConfig"}, "instance", fmt.Errorf("invalid resourceVersion"))
 	}
+	if err := checkUnchanged(c.statusSubresourceEnforced(), c.fakeOperatorStatus, c.statusSnapshot, "status", "UpdateOperatorStatus"); err != nil {
+		return nil, c.resourceVersion, err
+	}
 	rv, err := strconv.Atoi(resourceVersion)
 	if err != nil {
 		return nil, c.resourceVersion, err
 	}
 	c.resourceVersion = strconv.Itoa(rv + 1)
 	c.fakeOperatorSpec = spec
+	if c.statusSubresourceEnforced() {
+		c.specSnapshot = spec.DeepCopy()
+	}
 	return c.fakeOperatorSpec, c.resourceVersion, nil
 }
 
@@ -357,6 +434,9 @@ func (c *fakeOperatorClient) ApplyOperatorSpec(ctx context.Context, fieldManager
 
 func (c *fakeOperatorClient) ApplyOperatorStatus(ctx context.Context, fieldManager string, applyConfiguration *applyoperatorv1.OperatorStatusApplyConfiguration) (err error) {
 	c.fakeOperatorStatus = mergeOperatorStatusApplyConfiguration(c.fakeOperatorStatus, applyConfiguration)
+	if c.statusSubresourceEnforced() {
+		c.statusSnapshot = c.fakeOperatorStatus.DeepCopy()
+	}
 	return nil
 }
 
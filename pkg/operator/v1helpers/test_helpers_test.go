@@ -0,0 +1,154 @@
+package v1helpers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	operatorv1 "github.com/openshift/api/operator/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFakeOperatorClientStatusUpdateErrors(t *testing.T) {
+	conflictErr := errors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "TestOperatorConfig"}, "instance", fmt.Errorf("injected conflict"))
+
+	client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).
+		WithStatusUpdateErrors(conflictErr, conflictErr)
+
+	attempts := 0
+	status, updated, err := UpdateStatus(context.TODO(), client, func(status *operatorv1.OperatorStatus) error {
+		attempts++
+		status.ReadyReplicas = 3
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated {
+		t.Error("expected the status to be updated")
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts (two conflicts then a success), got %d", attempts)
+	}
+	if status.ReadyReplicas != 3 {
+		t.Errorf("expected readyReplicas 3, got %d", status.ReadyReplicas)
+	}
+
+	_, currentStatus, resourceVersion, _ := client.GetOperatorState()
+	if currentStatus.ReadyReplicas != 3 {
+		t.Errorf("expected stored readyReplicas 3, got %d", currentStatus.ReadyReplicas)
+	}
+	if resourceVersion != "1" {
+		t.Errorf("expected injected errors to not bump the resourceVersion, got %q", resourceVersion)
+	}
+}
+
+func TestFakeOperatorClientStatusUpdateNonConflictError(t *testing.T) {
+	client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).
+		WithStatusUpdateErrors(fmt.Errorf("boom"))
+
+	attempts := 0
+	updateFn := func(status *operatorv1.OperatorStatus) error {
+		attempts++
+		status.ReadyReplicas = 3
+		return nil
+	}
+	if _, updated, err := UpdateStatus(context.TODO(), client, updateFn); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected the injected error, got %v", err)
+	} else if updated {
+		t.Error("didn't expect the status to be updated")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a non-conflict error not to be retried, got %d attempts", attempts)
+	}
+
+	// the sequence is exhausted, subsequent updates succeed
+	if _, updated, err := UpdateStatus(context.TODO(), client, updateFn); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	} else if !updated {
+		t.Error("expected the status to be updated")
+	}
+}
+
+func TestFakeOperatorClientSpecUpdateErrors(t *testing.T) {
+	conflictErr := errors.NewConflict(schema.GroupResource{Group: operatorv1.GroupName, Resource: "TestOperatorConfig"}, "instance", fmt.Errorf("injected conflict"))
+
+	client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).
+		WithSpecUpdateErrors(conflictErr)
+
+	attempts := 0
+	spec, updated, err := UpdateSpec(context.TODO(), client, func(spec *operatorv1.OperatorSpec) error {
+		attempts++
+		spec.LogLevel = operatorv1.Debug
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !updated {
+		t.Error("expected the spec to be updated")
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts (a conflict then a success), got %d", attempts)
+	}
+	if spec.LogLevel != operatorv1.Debug {
+		t.Errorf("expected logLevel %q, got %q", operatorv1.Debug, spec.LogLevel)
+	}
+}
+
+func TestFakeOperatorClientStatusSubresource(t *testing.T) {
+	t.Run("status changes through the spec update are rejected", func(t *testing.T) {
+		client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).WithStatusSubresource()
+
+		spec, status, resourceVersion, _ := client.GetOperatorState()
+		status.ReadyReplicas = 3
+		spec.LogLevel = operatorv1.Debug
+		if _, _, err := client.UpdateOperatorSpec(context.TODO(), resourceVersion, spec); !errors.IsBadRequest(err) {
+			t.Fatalf("expected a bad request error, got %v", err)
+		}
+	})
+
+	t.Run("spec changes through the status update are rejected", func(t *testing.T) {
+		client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).WithStatusSubresource()
+
+		spec, status, resourceVersion, _ := client.GetOperatorState()
+		spec.LogLevel = operatorv1.Debug
+		status.ReadyReplicas = 3
+		if _, err := client.UpdateOperatorStatus(context.TODO(), resourceVersion, status); !errors.IsBadRequest(err) {
+			t.Fatalf("expected a bad request error, got %v", err)
+		}
+	})
+
+	t.Run("changes through their own update are accepted", func(t *testing.T) {
+		client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil).WithStatusSubresource()
+
+		if _, _, err := UpdateStatus(context.TODO(), client, func(status *operatorv1.OperatorStatus) error {
+			status.ReadyReplicas = 3
+			return nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := UpdateSpec(context.TODO(), client, func(spec *operatorv1.OperatorSpec) error {
+			spec.LogLevel = operatorv1.Debug
+			return nil
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		spec, status, _, _ := client.GetOperatorState()
+		if status.ReadyReplicas != 3 || spec.LogLevel != operatorv1.Debug {
+			t.Errorf("expected both updates to be stored, got spec %#v and status %#v", spec, status)
+		}
+	})
+
+	t.Run("not enforced by default", func(t *testing.T) {
+		client := NewFakeOperatorClient(&operatorv1.OperatorSpec{}, &operatorv1.OperatorStatus{}, nil)
+
+		spec, status, resourceVersion, _ := client.GetOperatorState()
+		status.ReadyReplicas = 3
+		if _, _, err := client.UpdateOperatorSpec(context.TODO(), resourceVersion, spec); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}